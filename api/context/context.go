@@ -12,8 +12,15 @@ type ctxKey int
 var (
 	logKey   ctxKey = 1
 	auxChKey ctxKey = 2
+	nodeKey  ctxKey = 3
 )
 
+// Node describes the stream node, i.e. an operator,
+// that a context was created for.
+type Node struct {
+	Name string
+}
+
 // WithLogger sets an interface.logger value in context
 func WithLogger(ctx context.Context, log logger.Interface) context.Context {
 	return context.WithValue(ctx, logKey, log)
@@ -35,14 +42,19 @@ func WithAuxChan(ctx context.Context, auxChan chan<- interface{}) context.Contex
 // SendAuxMsg submits an item to be sent to the auxiliary channel.
 // The item can be any arbitrary value that can be used for non-processing
 // messaging such as an event, rejected data, etc.  These messages can be
-// processed using the Plan that is running the flow.
+// processed using the side outputs of the stream that is running the flow.
+// SendAuxMsg blocks until the item is sent or ctx is done.
 func SendAuxMsg(ctx context.Context, item interface{}) error {
 	ch, ok := ctx.Value(auxChKey).(chan<- interface{})
 	if !ok {
 		return fmt.Errorf("Unable to find the auxiliary channel in context")
 	}
-	ch <- item
-	return nil
+	select {
+	case ch <- item:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // GetAuxChan returns the auxiliary channel used for communicating
@@ -51,3 +63,17 @@ func GetAuxChan(ctx context.Context) (chan<- interface{}, bool) {
 	ch, ok := ctx.Value(auxChKey).(chan<- interface{})
 	return ch, ok
 }
+
+// WithNode sets the node that the context is created for
+func WithNode(ctx context.Context, node *Node) context.Context {
+	return context.WithValue(ctx, nodeKey, node)
+}
+
+// GetNodeName returns the name of the node that the context was
+// created for, or def if the context does not belong to a node.
+func GetNodeName(ctx context.Context, def string) string {
+	if node, ok := ctx.Value(nodeKey).(*Node); ok && node.Name != "" {
+		return node.Name
+	}
+	return def
+}
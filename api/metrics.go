@@ -0,0 +1,21 @@
+package api
+
+import "time"
+
+// Metrics records the activity of the nodes of a stream.  Nodes are
+// identified by name, operators added to a Stream are named after the
+// method that added them unless renamed with Stream.Named.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// ItemIn records an item received by the node
+	ItemIn(node string)
+	// ItemOut records an item sent downstream by the node
+	ItemOut(node string)
+	// Error records an item whose processing failed
+	Error(node string)
+	// Latency records the time taken to process an item
+	Latency(node string, d time.Duration)
+	// Occupancy records the number of items buffered in
+	// the output channel of the node and its capacity
+	Occupancy(node string, length, capacity int)
+}
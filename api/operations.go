@@ -1,6 +1,9 @@
 package api
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // UnOperation interface represents unary operations (i.e. Map, Filter, etc)
 type UnOperation interface {
@@ -28,6 +31,20 @@ func (f BinFunc) Apply(ctx context.Context, op1, op2 interface{}) (interface{},
 	return f(ctx, op1, op2)
 }
 
+// KeyedOperation interface represents operations applied to an item
+// along with the state of its key (i.e. ProcessByKey)
+type KeyedOperation interface {
+	Apply(ctx context.Context, state State, data interface{}) (interface{}, error)
+}
+
+// KeyedFunc implements KeyedOperation as type func(context.Context, State, interface{})
+type KeyedFunc func(context.Context, State, interface{}) (interface{}, error)
+
+// Apply implements KeyedOperation.Apply
+func (f KeyedFunc) Apply(ctx context.Context, state State, data interface{}) (interface{}, error) {
+	return f(ctx, state, data)
+}
+
 // Batch Operation types
 
 // BatchTrigger interface provides logic to trigger when batch is done.
@@ -42,3 +59,51 @@ type BatchTriggerFunc func(context.Context, interface{}, int64) bool
 func (f BatchTriggerFunc) Done(ctx context.Context, item interface{}, index int64) bool {
 	return f(ctx, item, index)
 }
+
+// Event time types
+
+// TimestampExtractor interface provides the event time of a streamed item.
+type TimestampExtractor interface {
+	Timestamp(item interface{}) (time.Time, error)
+}
+
+// TimestampFunc a function type adapter that implements TimestampExtractor
+type TimestampFunc func(interface{}) (time.Time, error)
+
+// Timestamp implements TimestampExtractor.Timestamp
+func (f TimestampFunc) Timestamp(item interface{}) (time.Time, error) {
+	return f(item)
+}
+
+// LateItem is sent to the auxiliary channel of the stream for each item
+// that arrives after the watermark has passed the end of its window or
+// batch, which was already emitted.
+type LateItem struct {
+	Item      interface{}
+	Timestamp time.Time
+	Watermark time.Time
+}
+
+// Watermark estimates the progress of event time in a stream.  It trails
+// the greatest observed timestamp by MaxOutOfOrder so that items arriving
+// out of order, within that bound, are not considered late.
+type Watermark struct {
+	MaxOutOfOrder time.Duration
+	max           time.Time
+}
+
+// Observe advances the watermark with the timestamp of a new item
+func (w *Watermark) Observe(t time.Time) {
+	if t.After(w.max) {
+		w.max = t
+	}
+}
+
+// Current returns the current watermark.  All items with an event time
+// before the watermark are expected to have been observed.
+func (w *Watermark) Current() time.Time {
+	if w.max.IsZero() {
+		return w.max
+	}
+	return w.max.Add(-w.MaxOutOfOrder)
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	autoctx "github.com/gofunky/automi/api/context"
+)
+
+// ErrorAction selects what an operator does with an item
+// whose processing failed.
+type ErrorAction int
+
+const (
+	// FailAction stops the operator and reports the error, failing the stream
+	FailAction ErrorAction = iota
+	// SkipAction drops the failed item and continues with the next one
+	SkipAction
+	// DeadLetterAction sends the failed item, as a ProcError, to a
+	// dead-letter channel and continues with the next one
+	DeadLetterAction
+)
+
+// ErrorPolicy configures how an operator handles an item whose
+// operation returned an error, or returned an error value as result.
+// Failed items are first retried, Retries times, waiting Backoff
+// before the first retry and doubling the wait for each following one.
+// Once retries are exhausted, Action is applied.
+//
+// Dead-lettered items are sent to DeadLetter or, when it is nil, to the
+// auxiliary channel of the operator context.  Without either channel
+// dead-lettered items are dropped.
+//
+// The zero value fails on the first error.
+type ErrorPolicy struct {
+	Action     ErrorAction
+	Retries    int
+	Backoff    time.Duration
+	DeadLetter chan<- interface{}
+}
+
+// FailOnError returns a policy that fails the stream on the first error
+func FailOnError() ErrorPolicy {
+	return ErrorPolicy{Action: FailAction}
+}
+
+// SkipOnError returns a policy that drops failed items
+func SkipOnError() ErrorPolicy {
+	return ErrorPolicy{Action: SkipAction}
+}
+
+// DeadLetterOnError returns a policy that sends failed items to ch.
+// If ch is nil, failed items are sent to the auxiliary channel
+// of the operator context.
+func DeadLetterOnError(ch chan<- interface{}) ErrorPolicy {
+	return ErrorPolicy{Action: DeadLetterAction, DeadLetter: ch}
+}
+
+// RetryOnError returns a policy that retries failed items and
+// fails the stream when all retries failed.
+func RetryOnError(retries int, backoff time.Duration) ErrorPolicy {
+	return FailOnError().WithRetry(retries, backoff)
+}
+
+// WithRetry returns a copy of the policy that retries
+// failed items before applying its action.
+func (p ErrorPolicy) WithRetry(retries int, backoff time.Duration) ErrorPolicy {
+	p.Retries = retries
+	p.Backoff = backoff
+	return p
+}
+
+// Do calls f and retries it, as configured by the policy, while it
+// returns an error.  It returns the error of the last attempt, or the
+// context error if ctx is done while waiting for the next attempt.
+func (p ErrorPolicy) Do(ctx context.Context, f func() error) error {
+	err := f()
+	wait := p.Backoff
+	for attempt := 0; err != nil && attempt < p.Retries; attempt++ {
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+			wait *= 2
+		}
+		err = f()
+	}
+	return err
+}
+
+// Reject applies the policy action to a failed item.  It returns
+// the error when the policy fails, or nil when the item is skipped
+// or dead-lettered.
+func (p ErrorPolicy) Reject(ctx context.Context, perr ProcError) error {
+	switch p.Action {
+	case SkipAction:
+		return nil
+	case DeadLetterAction:
+		ch := p.DeadLetter
+		if ch == nil {
+			aux, ok := autoctx.GetAuxChan(ctx)
+			if !ok {
+				return nil
+			}
+			ch = aux
+		}
+		select {
+		case ch <- perr:
+		case <-ctx.Done():
+		}
+		return nil
+	default:
+		return perr
+	}
+}
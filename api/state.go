@@ -0,0 +1,41 @@
+package api
+
+// State is the state of a key, as seen by the keyed operation applied to
+// an item of that key.  The state is only accessed by the worker that
+// processes the items of the key, it is not safe for concurrent use.
+type State interface {
+	// Key returns the key of the state
+	Key() interface{}
+	// Get returns the value of the state, if any
+	Get() (interface{}, bool)
+	// Put sets the value of the state, which expires
+	// after the time to live set on the operator
+	Put(value interface{})
+	// Delete removes the value of the state
+	Delete()
+}
+
+// StateStore stores the encoded states of operators by key, so that they
+// can survive a restart of the stream.  Implementations must be safe for
+// concurrent use.
+//
+// See Also
+//
+//	"github.com/gofunky/automi/state"
+type StateStore interface {
+	// Get returns the value stored for key, if any
+	Get(key string) ([]byte, bool, error)
+	// Put stores value for key
+	Put(key string, value []byte) error
+	// Delete removes the value stored for key
+	Delete(key string) error
+	// Range calls f for the keys starting with prefix, and their
+	// values, until f returns false
+	Range(prefix string, f func(key string, value []byte) bool) error
+}
+
+// Codec encodes state values for a StateStore, and decodes them back
+type Codec interface {
+	Encode(value interface{}) ([]byte, error)
+	Decode(data []byte) (interface{}, error)
+}
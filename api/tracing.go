@@ -0,0 +1,16 @@
+package api
+
+// Tracer starts spans around the processing of stream items.
+// Implementations must be safe for concurrent use.
+type Tracer interface {
+	// Start starts a span named name.  The span is a child of parent,
+	// the span of the node that handled the item before, or a root
+	// span if parent is nil.
+	Start(parent Span, name string) Span
+}
+
+// Span is the processing of an item by a node of a stream
+type Span interface {
+	// End ends the span, err is the processing error, if any
+	End(err error)
+}
@@ -2,3 +2,7 @@ package tuple
 
 type Pair [2]interface{}
 type KV [2]interface{}
+
+// Tagged holds a streamed item along with a tag that identifies
+// its origin, as in Tagged{origin, item}.
+type Tagged [2]interface{}
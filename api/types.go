@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"fmt"
+	"time"
 )
 
 type Emitter interface {
@@ -52,9 +53,13 @@ type Operator interface {
 	Exec(drain chan<- error)
 }
 
+// ProcError reports an error that occurred while processing an item.
+// ProcName names the processing node and Item, when set, holds
+// the item that failed.
 type ProcError struct {
 	Err      error
 	ProcName string
+	Item     interface{}
 }
 
 func (e ProcError) Error() string {
@@ -63,3 +68,17 @@ func (e ProcError) Error() string {
 	}
 	return e.Err.Error()
 }
+
+// Unwrap returns the underlying error
+func (e ProcError) Unwrap() error {
+	return e.Err
+}
+
+// Window is a batch of streamed items collected during the
+// time interval [Start, End).  Data holds the batched items
+// or, once processed, the result of a batch operation.
+type Window struct {
+	Start time.Time
+	End   time.Time
+	Data  interface{}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofunky/automi/plan"
+)
+
+// fromFlags returns the plan of the operator flags of opts,
+// the operations of the aggregation flags are registered in reg.
+func fromFlags(opts options, reg *plan.Registry) (*plan.Plan, error) {
+	p := new(plan.Plan)
+	switch opts.in {
+	case "csv":
+		p.Source = plan.Endpoint{Type: "csv", Params: plan.Params{
+			"file":      opts.file,
+			"header":    opts.header,
+			"delimiter": opts.delim,
+		}}
+	case "lines", "words", "runes", "bytes":
+		p.Source = plan.Endpoint{Type: "scanner", Params: plan.Params{
+			"file":  opts.file,
+			"split": opts.in,
+		}}
+	default:
+		return nil, fmt.Errorf("unknown input format %q", opts.in)
+	}
+
+	p.Operators = append(p.Operators, plan.Step{Type: "map", Op: "parse"})
+	for _, expr := range opts.filters {
+		p.Operators = append(p.Operators, plan.Step{Type: "filter", Params: plan.Params{"expr": expr}})
+	}
+
+	rows, batches, err := aggregation(opts, reg)
+	if err != nil {
+		return nil, err
+	}
+	if len(batches) > 0 {
+		p.Operators = append(p.Operators, rows...)
+		p.Operators = append(p.Operators, plan.Step{Type: "batch", Params: plan.Params{"size": opts.batch}})
+		p.Operators = append(p.Operators, batches...)
+	} else if opts.batch > 0 {
+		return nil, errors.New("-batch requires -sort, -group or -sum")
+	}
+	p.Operators = append(p.Operators, plan.Step{Type: "flatMap", Op: "format"})
+
+	switch opts.format {
+	case "csv":
+		p.Sink = plan.Endpoint{Type: "csv", Params: plan.Params{"file": opts.out, "delimiter": opts.delim}}
+	case "text":
+		p.Operators = append(p.Operators, plan.Step{Type: "map", Op: "text"})
+		p.Sink = plan.Endpoint{Type: "writer", Params: plan.Params{"file": opts.out}}
+	default:
+		return nil, fmt.Errorf("unknown output format %q", opts.format)
+	}
+	return p, nil
+}
+
+// aggregation returns the steps of the sort, group and sum flags, the
+// steps applied to the rows before they are batched and those applied
+// to the batches
+func aggregation(opts options, reg *plan.Registry) (rows, batches []plan.Step, err error) {
+	sort, group, sum := opts.sort >= 0, opts.group >= 0, opts.sum >= 0
+	switch {
+	case sort && (group || sum):
+		return nil, nil, errors.New("-sort cannot be combined with -group or -sum")
+	case sort:
+		batches = []plan.Step{{Type: "sortByPos", Params: plan.Params{"pos": opts.sort}}}
+	case group && sum:
+		// keep the group and summed fields, then sum the groups
+		if err := reg.RegisterUnary("select", selectFields(opts.group, opts.sum)); err != nil {
+			return nil, nil, err
+		}
+		rows = []plan.Step{{Type: "map", Op: "select"}}
+		batches = []plan.Step{
+			{Type: "groupByPos", Params: plan.Params{"pos": 0}, Name: "group"},
+			{Type: "sumAllKeys", Name: "sum"},
+		}
+	case group:
+		batches = []plan.Step{{Type: "groupByPos", Params: plan.Params{"pos": opts.group}}}
+	case sum:
+		batches = []plan.Step{{Type: "sumByPos", Params: plan.Params{"pos": opts.sum}}}
+	}
+	return rows, batches, nil
+}
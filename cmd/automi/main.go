@@ -0,0 +1,130 @@
+// Command automi runs stream pipelines over files and the standard input.
+//
+//	automi [flags] [file]
+//
+// The records of file, or of the standard input if file is omitted or "-",
+// are parsed into rows of fields.  Fields that hold numbers are parsed as
+// numbers.  Rows go through the operators given as flags, in this order:
+//
+//	-filter EXPR  keep the rows that match EXPR, i.e. '$2 >= 10' (repeatable)
+//	-sort POS     sort the rows by the field at POS
+//	-group POS    group the rows by the field at POS
+//	-sum POS      sum the field at POS, per group if -group is set
+//
+// Positions start at 0.  Filters are expressions of package expr, the field
+// at position N of a row is $N.  The result is written as CSV, or as tab separated
+// text, to the standard output unless -out is set.  Alternatively, a pipeline
+// file written in JSON or YAML is run with -plan, see package plan.  Pipeline
+// files may refer to the operations parse, format and text of the command.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/gofunky/automi/plan"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stderr))
+}
+
+// options are the command line flags
+type options struct {
+	plan    string
+	in      string
+	header  bool
+	delim   string
+	filters filters
+	sort    int
+	group   int
+	sum     int
+	batch   int
+	out     string
+	format  string
+	file    string
+}
+
+type filters []string
+
+func (f *filters) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *filters) Set(expr string) error {
+	*f = append(*f, expr)
+	return nil
+}
+
+// run runs the command with args and returns its exit code
+func run(args []string, stderr io.Writer) int {
+	opts := options{}
+	fs := flag.NewFlagSet("automi", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: automi [flags] [file]")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&opts.plan, "plan", "", "run the pipeline `file` written in JSON or YAML")
+	fs.StringVar(&opts.in, "in", "csv", "input `format`: csv, lines, words, runes or bytes")
+	fs.BoolVar(&opts.header, "header", false, "skip the header row of the csv input")
+	fs.StringVar(&opts.delim, "delim", ",", "csv field delimiter")
+	fs.Var(&opts.filters, "filter", "keep the rows matching `expr`, the field at position N is $N, i.e. '$2 >= 5 && $1 != \"west\"'")
+	fs.IntVar(&opts.sort, "sort", -1, "sort the rows by the field at `pos`")
+	fs.IntVar(&opts.group, "group", -1, "group the rows by the field at `pos`")
+	fs.IntVar(&opts.sum, "sum", -1, "sum the field at `pos`")
+	fs.IntVar(&opts.batch, "batch", 0, "sort, group or sum batches of `n` rows instead of all rows")
+	fs.StringVar(&opts.out, "out", "-", "output `file`")
+	fs.StringVar(&opts.format, "format", "csv", "output `format`: csv or text")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	switch fs.NArg() {
+	case 0:
+		opts.file = "-"
+	case 1:
+		opts.file = fs.Arg(0)
+	default:
+		fs.Usage()
+		return 2
+	}
+
+	if err := execute(opts); err != nil {
+		fmt.Fprintln(stderr, "automi:", err)
+		return 1
+	}
+	return 0
+}
+
+// execute builds the pipeline of opts and runs it until it is
+// done or the process is interrupted
+func execute(opts options) error {
+	reg := plan.NewRegistry()
+	if err := register(reg); err != nil {
+		return err
+	}
+
+	var p *plan.Plan
+	var err error
+	if opts.plan != "" {
+		p, err = plan.Load(opts.plan)
+	} else {
+		p, err = fromFlags(opts, reg)
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	strm, err := p.Build(ctx, reg)
+	if err != nil {
+		return err
+	}
+	return <-strm.Open()
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const sales = "name,region,amount\nalpha,east,10\nbeta,west,5\ngamma,east,7.5\ndelta,north,1\n"
+
+func runFile(t *testing.T, input string, args ...string) (string, int) {
+	t.Helper()
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in.csv"), filepath.Join(dir, "out")
+	if err := os.WriteFile(in, []byte(input), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var stderr bytes.Buffer
+	code := run(append(args, "-out", out, in), &stderr)
+	if code != 0 {
+		return stderr.String(), code
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data), code
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-header"}, "alpha,east,10\nbeta,west,5\ngamma,east,7.5\ndelta,north,1\n"},
+		{[]string{"-header", "-filter", "$2 >= 5", "-filter", `$1 != "west"`}, "alpha,east,10\ngamma,east,7.5\n"},
+		{[]string{"-header", "-filter", `contains($0, "ta") || $2 > 9`}, "alpha,east,10\nbeta,west,5\ndelta,north,1\n"},
+		{[]string{"-header", "-sort", "2"}, "delta,north,1\nbeta,west,5\ngamma,east,7.5\nalpha,east,10\n"},
+		{[]string{"-header", "-group", "1"}, "east,alpha,10,gamma,7.5\nnorth,delta,1\nwest,beta,5\n"},
+		{[]string{"-header", "-sum", "2"}, "2,23.5\n"},
+		{[]string{"-header", "-group", "1", "-sum", "2", "-format", "text"}, "east\t17.5\nnorth\t1\nwest\t5\n"},
+		{[]string{"-header", "-sort", "0", "-batch", "2"}, "alpha,east,10\nbeta,west,5\ndelta,north,1\ngamma,east,7.5\n"},
+		{[]string{"-in", "lines", "-format", "text"}, "name,region,amount\nalpha,east,10\nbeta,west,5\ngamma,east,7.5\ndelta,north,1\n"},
+	}
+	for _, test := range tests {
+		output, code := runFile(t, sales, test.args...)
+		if code != 0 {
+			t.Fatal(test.args, output)
+		}
+		if output != test.expected {
+			t.Errorf("%v: expecting %q, got %q", test.args, test.expected, output)
+		}
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	tests := [][]string{
+		{"-filter", "$2 >"},
+		{"-filter", "$2 + 1"},
+		{"-sort", "0", "-sum", "1"},
+		{"-batch", "2"},
+		{"-in", "xml"},
+		{"-format", "xml"},
+		{"-unknown"},
+	}
+	for _, args := range tests {
+		if _, code := runFile(t, sales, args...); code == 0 {
+			t.Error(args, ": expecting failure")
+		}
+	}
+}
+
+func TestRun_Plan(t *testing.T) {
+	dir := t.TempDir()
+	in, out, pipeline := filepath.Join(dir, "in.txt"), filepath.Join(dir, "out.csv"), filepath.Join(dir, "plan.yaml")
+	if err := os.WriteFile(in, []byte("3 1 2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pipeline, []byte(`
+source: {type: scanner, params: {file: `+in+`, split: words}}
+operators:
+  - {type: map, op: parse}
+  - {type: batch}
+  - {type: sortByPos, params: {pos: 0}}
+  - {type: flatMap, op: format}
+sink: {type: csv, params: {file: `+out+`}}
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stderr bytes.Buffer
+	if code := run([]string{"-plan", pipeline}, &stderr); code != 0 {
+		t.Fatal(stderr.String())
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "1\n2\n3\n" {
+		t.Fatalf("unexpected output %q", data)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	rows, _ := format(context.Background(), []map[interface{}]float64{{"b": 2, "a": 1.5}})
+	expected := [][]string{{"a", "1.5"}, {"b", "2"}}
+	got := rows.([][]string)
+	if len(got) != 2 || got[0][0] != expected[0][0] || got[0][1] != expected[0][1] || got[1][1] != expected[1][1] {
+		t.Fatal("unexpected rows ", got)
+	}
+}
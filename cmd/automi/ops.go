@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/plan"
+)
+
+// register registers the operations of the command
+func register(reg *plan.Registry) error {
+	if err := reg.RegisterUnary("parse", api.UnFunc(parse)); err != nil {
+		return err
+	}
+	if err := reg.RegisterUnary("format", api.UnFunc(format)); err != nil {
+		return err
+	}
+	return reg.RegisterUnary("text", api.UnFunc(text))
+}
+
+// parse turns a record, a csv row or a scanned token, into a row
+// of fields where the fields that hold numbers are float64 values
+func parse(ctx context.Context, item interface{}) (interface{}, error) {
+	var fields []string
+	switch record := item.(type) {
+	case []string:
+		fields = record
+	case string:
+		fields = []string{record}
+	case []byte:
+		fields = []string{string(record)}
+	default:
+		return nil, fmt.Errorf("parse: unexpected record type %T", item)
+	}
+	row := make([]interface{}, len(fields))
+	for i, field := range fields {
+		row[i] = parseField(field)
+	}
+	return row, nil
+}
+
+func parseField(field string) interface{} {
+	if num, err := strconv.ParseFloat(strings.TrimSpace(field), 64); err == nil {
+		return num
+	}
+	return field
+}
+
+// format turns rows, groups and sums into a list of rows of strings.
+// Groups and sums are written as rows starting with their key, in key order.
+func format(ctx context.Context, item interface{}) (interface{}, error) {
+	var rows [][]string
+	formatValue(reflect.ValueOf(item), &rows)
+	return rows, nil
+}
+
+func formatValue(val reflect.Value, rows *[][]string) {
+	if val.Kind() == reflect.Interface {
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.Slice, reflect.Array:
+		if val.Type().Elem().Kind() == reflect.Uint8 {
+			*rows = append(*rows, []string{fmt.Sprint(val.Interface())})
+			return
+		}
+		// a list of rows, or a single row
+		if val.Len() > 0 && isComposite(val.Index(0)) {
+			for i := 0; i < val.Len(); i++ {
+				formatValue(val.Index(i), rows)
+			}
+			return
+		}
+		*rows = append(*rows, flatten(nil, val))
+	case reflect.Map:
+		keys := val.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return less(keys[i].Interface(), keys[j].Interface())
+		})
+		for _, key := range keys {
+			row := []string{formatField(key.Interface())}
+			*rows = append(*rows, flatten(row, val.MapIndex(key)))
+		}
+	default:
+		*rows = append(*rows, []string{formatField(val.Interface())})
+	}
+}
+
+// flatten appends the fields of val to row
+func flatten(row []string, val reflect.Value) []string {
+	if val.Kind() == reflect.Interface {
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			row = flatten(row, val.Index(i))
+		}
+		return row
+	}
+	return append(row, formatField(val.Interface()))
+}
+
+func isComposite(val reflect.Value) bool {
+	if val.Kind() == reflect.Interface {
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+	return false
+}
+
+func formatField(field interface{}) string {
+	switch val := field.(type) {
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case string:
+		return val
+	}
+	return fmt.Sprint(field)
+}
+
+// less orders numbers before strings
+func less(a, b interface{}) bool {
+	x, xok := a.(float64)
+	y, yok := b.(float64)
+	switch {
+	case xok && yok:
+		return x < y
+	case xok != yok:
+		return xok
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+// text turns a row of strings into a tab separated line
+func text(ctx context.Context, item interface{}) (interface{}, error) {
+	row, ok := item.([]string)
+	if !ok {
+		return nil, fmt.Errorf("text: unexpected row type %T", item)
+	}
+	return strings.Join(row, "\t") + "\n", nil
+}
+
+// selectFields returns an operation that keeps the fields at positions
+func selectFields(positions ...int) api.UnOperation {
+	return api.UnFunc(func(ctx context.Context, item interface{}) (interface{}, error) {
+		row := item.([]interface{})
+		selected := make([]interface{}, len(positions))
+		for i, pos := range positions {
+			if pos >= len(row) {
+				return nil, fmt.Errorf("row has no field at position %d", pos)
+			}
+			selected[i] = row[pos]
+		}
+		return selected, nil
+	})
+}
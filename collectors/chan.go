@@ -0,0 +1,116 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/go-faces/logger"
+	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/tracing"
+	"github.com/gofunky/automi/util"
+)
+
+// ChanCollector is a collector that sends the collected
+// items to a channel of any element type.
+type ChanCollector struct {
+	chanParam interface{}
+	channel   reflect.Value
+	close     bool
+	input     <-chan interface{}
+	log       logger.Interface
+}
+
+// Chan creates a new *ChanCollector that sends the collected items to
+// channel, which must be a bidirectional or send-only channel.  Items are
+// converted to the element type of the channel, see util.ConvertTo.
+func Chan(channel interface{}) *ChanCollector {
+	return &ChanCollector{chanParam: channel}
+}
+
+// CloseOnFinish closes the channel once all items are collected
+func (c *ChanCollector) CloseOnFinish() *ChanCollector {
+	c.close = true
+	return c
+}
+
+// SetInput sets the channel input
+func (c *ChanCollector) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// Open is the starting point that starts the collector.  Items that cannot
+// be converted to the element type are skipped and the first one is
+// reported as an error.  Sending blocks until the channel receives the item
+// or the context is done, in which case the remaining items are discarded.
+func (c *ChanCollector) Open(ctx context.Context) <-chan error {
+	c.log = autoctx.GetLogger(ctx)
+	util.Log(c.log, "opening channel collector")
+	trace := tracing.NewRecorder(ctx, "channel collector")
+	result := make(chan error)
+
+	if err := c.setupChan(); err != nil {
+		go func() { result <- err }()
+		return result
+	}
+
+	go func() {
+		var failure error
+		defer func() {
+			if c.close {
+				c.channel.Close()
+			}
+			if failure != nil {
+				result <- failure
+			}
+			close(result)
+			util.Log(c.log, "closing channel collector")
+		}()
+
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectSend, Chan: c.channel},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		}
+		for item := range c.input {
+			itemCtx, val := trace.Start(ctx, item)
+			value, err := util.ConvertTo(val, c.channel.Type().Elem())
+			if err != nil {
+				trace.End(itemCtx, err)
+				util.Log(c.log, err)
+				if failure == nil {
+					failure = err
+				}
+				continue
+			}
+
+			cases[0].Send = value
+			chosen, _, _ := reflect.Select(cases)
+			if chosen == 1 {
+				trace.End(itemCtx, ctx.Err())
+				return
+			}
+			trace.End(itemCtx, nil)
+		}
+	}()
+
+	return result
+}
+
+func (c *ChanCollector) setupChan() error {
+	if c.input == nil {
+		return errors.New("channel collector missing input")
+	}
+	if c.chanParam == nil {
+		return errors.New("channel collector missing channel")
+	}
+	channel := reflect.ValueOf(c.chanParam)
+	if channel.Kind() != reflect.Chan || channel.Type().ChanDir()&reflect.SendDir == 0 {
+		return fmt.Errorf("channel collector cannot send to %T", c.chanParam)
+	}
+	if channel.IsNil() {
+		return errors.New("channel collector missing channel")
+	}
+	c.channel = channel
+	return nil
+}
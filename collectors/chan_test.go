@@ -0,0 +1,75 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestChanCollector(t *testing.T) {
+	type celsius float64
+	in := make(chan interface{}, 4)
+	in <- 1
+	in <- 2.5
+	in <- "three"
+	in <- celsius(4)
+	close(in)
+
+	out := make(chan float64, 4)
+	snk := Chan(out).CloseOnFinish()
+	snk.SetInput(in)
+
+	select {
+	case err := <-snk.Open(context.Background()):
+		if err == nil {
+			t.Fatal("expecting conversion error")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("collector took too long")
+	}
+
+	var items []float64
+	for item := range out {
+		items = append(items, item)
+	}
+	if len(items) != 3 || items[0] != 1 || items[1] != 2.5 || items[2] != 4 {
+		t.Fatal("unexpected items ", items)
+	}
+}
+
+func TestChanCollector_Cancel(t *testing.T) {
+	in := make(chan interface{}, 2)
+	in <- "a"
+	in <- "b"
+	close(in)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan string)
+	snk := Chan(out)
+	snk.SetInput(in)
+	result := snk.Open(ctx)
+	if item := <-out; item != "a" {
+		t.Fatal("unexpected item ", item)
+	}
+	cancel()
+
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("collector not cancelled")
+	}
+}
+
+func TestChanCollector_Invalid(t *testing.T) {
+	in := make(chan interface{})
+	for _, param := range []interface{}{nil, 12, make(<-chan int), (chan int)(nil)} {
+		snk := Chan(param)
+		snk.SetInput(in)
+		if err := <-snk.Open(context.Background()); err == nil {
+			t.Errorf("%T: expecting error", param)
+		}
+	}
+}
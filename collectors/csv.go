@@ -7,19 +7,31 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"reflect"
+	"sort"
+	"time"
 
 	"github.com/go-faces/logger"
+	"github.com/gofunky/automi/api"
 	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/tracing"
 	"github.com/gofunky/automi/util"
 )
 
 // CsvCollector represents a node that can collect items streamed as
 // type []string and write them as comma-separated values to the specified
-// io.Writer or file.
+// io.Writer or file.  Structs, maps, slices and arrays, such as tuple.KV
+// or []float64, are also written as records, see Open.
 type CsvCollector struct {
-	filepath  string   // path for the file
-	delimChar rune     // delimiter character
-	headers   []string // optional csv headers
+	filepath   string   // path for the file
+	delimChar  rune     // delimiter character
+	headers    []string // optional csv headers
+	noHeaders  bool     // do not generate headers
+	timeLayout string   // layout of time.Time values
+	policy     api.ErrorPolicy
+
+	columns      map[reflect.Type][]util.StructField // struct fields by column
+	writeHeaders bool                                // headers are pending
 
 	snkParam  interface{}
 	file      *os.File
@@ -48,6 +60,30 @@ func (c *CsvCollector) Headers(headers []string) *CsvCollector {
 	return c
 }
 
+// NoHeaders disables the headers generated from the
+// first struct or map collected, see Open.
+func (c *CsvCollector) NoHeaders() *CsvCollector {
+	c.noHeaders = true
+	return c
+}
+
+// TimeLayout sets the layout used to format time.Time
+// values, time.RFC3339 by default.
+func (c *CsvCollector) TimeLayout(layout string) *CsvCollector {
+	c.timeLayout = layout
+	return c
+}
+
+// OnError sets the policy applied to the items that cannot be written
+// as records, as api.ProcError values.  Items that fail are dead-lettered,
+// skipped, or fail the collector, which discards the remaining items.
+// Retries do not apply.  Without a policy, the first failure fails the
+// collector.
+func (c *CsvCollector) OnError(policy api.ErrorPolicy) *CsvCollector {
+	c.policy = policy
+	return c
+}
+
 // SetInput sets the channel input
 func (c *CsvCollector) SetInput(in <-chan interface{}) {
 	c.input = in
@@ -68,6 +104,10 @@ func (c *CsvCollector) init(ctx context.Context) error {
 	if c.delimChar == 0 {
 		c.delimChar = ','
 	}
+	if c.timeLayout == "" {
+		c.timeLayout = time.RFC3339
+	}
+	c.columns = make(map[reflect.Type][]util.StructField)
 
 	if err := c.setupSink(); err != nil {
 		return err
@@ -89,15 +129,24 @@ func (c *CsvCollector) init(ctx context.Context) error {
 	return nil
 }
 
-// Open is the starting point that opens the sink for data to start flowing
+// Open is the starting point that opens the sink for data to start flowing.
+//
+// Items of type []string are written as they are.  The exported fields of
+// structs are written in order, or matched to the headers by their csv
+// tag or name, as for the CSV emitter.  The values of maps are written in
+// the order of the headers or, without headers, of their sorted keys.  The
+// elements of slices and arrays are written in order.  Unless headers are
+// set, or NoHeaders is, the first struct or map collected generates them.
 func (c *CsvCollector) Open(ctx context.Context) <-chan error {
 	result := make(chan error)
 	if err := c.init(ctx); err != nil {
 		go func() { result <- err }()
 		return result
 	}
+	trace := tracing.NewRecorder(ctx, "csv collector")
 
 	go func() {
+		var err error
 		defer func() {
 			util.Log(c.log, "closing csv collector")
 			// flush remaining bits
@@ -114,22 +163,40 @@ func (c *CsvCollector) Open(ctx context.Context) <-chan error {
 					return
 				}
 			}
+			if err != nil {
+				go func() { result <- err }()
+				return
+			}
 			close(result)
 		}()
 
-		for item := range c.input {
-			data, ok := item.([]string)
+		for val := range c.input {
+			// after a failure, remaining items are discarded
+			if err != nil {
+				continue
+			}
+			itemCtx, item := trace.Start(ctx, val)
 
-			if !ok { // bad situation, fail fast
-				msg := fmt.Sprintf("expecting []string, got unexpected type %T", data)
-				util.Log(c.log, msg)
-				panic(msg)
+			data, e := c.record(item)
+			if e != nil {
+				perr := api.ProcError{Err: e, ProcName: "csv collector", Item: item}
+				util.Log(c.log, perr)
+				trace.End(itemCtx, perr)
+				err = c.policy.Reject(ctx, perr)
+				continue
 			}
 
+			if c.writeHeaders {
+				c.writeHeaders = false
+				if e := c.csvWriter.Write(c.headers); e != nil {
+					util.Log(c.log, fmt.Errorf("Unable to write headers to file: %s ", e))
+				}
+			}
 			if e := c.csvWriter.Write(data); e != nil {
 				//TODO distinguish error values for better handling
 				perr := fmt.Errorf("Unable to write record to file: %s ", e)
 				util.Log(c.log, perr)
+				trace.End(itemCtx, perr)
 				continue
 			}
 
@@ -138,7 +205,9 @@ func (c *CsvCollector) Open(ctx context.Context) <-chan error {
 			if e := c.csvWriter.Error(); e != nil {
 				perr := fmt.Errorf("IO flush error: %s", e)
 				util.Log(c.log, perr)
+				trace.End(itemCtx, perr)
 			}
+			trace.End(itemCtx, nil)
 
 			select {
 			case <-ctx.Done():
@@ -151,6 +220,111 @@ func (c *CsvCollector) Open(ctx context.Context) <-chan error {
 	return result
 }
 
+// record returns the fields of the CSV record of item
+func (c *CsvCollector) record(item interface{}) ([]string, error) {
+	if data, ok := item.([]string); ok {
+		return data, nil
+	}
+
+	val := reflect.ValueOf(item)
+	for val.Kind() == reflect.Ptr && !val.IsNil() {
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.Struct:
+		return c.structRecord(val)
+	case reflect.Map:
+		return c.mapRecord(val)
+	case reflect.Slice, reflect.Array:
+		data := make([]string, val.Len())
+		for i := range data {
+			field, err := util.FormatCSVValue(val.Index(i), c.timeLayout)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			data[i] = field
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("unsupported type %T", item)
+}
+
+// structRecord returns the fields of the record of struct val
+func (c *CsvCollector) structRecord(val reflect.Value) ([]string, error) {
+	columns, ok := c.columns[val.Type()]
+	if !ok {
+		fields := util.StructFields(val.Type(), "csv")
+		if c.headers == nil && !c.noHeaders {
+			headers := make([]string, len(fields))
+			for i, field := range fields {
+				headers[i] = field.Name
+			}
+			c.setHeaders(headers)
+		}
+		columns = fields
+		if c.headers != nil {
+			columns = util.MatchFields(c.headers, fields)
+		}
+		c.columns[val.Type()] = columns
+	}
+
+	data := make([]string, len(columns))
+	for i, column := range columns {
+		if column.Index == nil {
+			continue
+		}
+		field, err := util.FormatCSVValue(val.FieldByIndex(column.Index), c.timeLayout)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", column.Name, err)
+		}
+		data[i] = field
+	}
+	return data, nil
+}
+
+// mapRecord returns the fields of the record of map val,
+// whose keys must be strings
+func (c *CsvCollector) mapRecord(val reflect.Value) ([]string, error) {
+	keyType := val.Type().Key()
+	if keyType.Kind() != reflect.String {
+		return nil, fmt.Errorf("unsupported map key type %s", keyType)
+	}
+
+	headers := c.headers
+	if headers == nil {
+		headers = make([]string, 0, val.Len())
+		for _, key := range val.MapKeys() {
+			headers = append(headers, key.String())
+		}
+		sort.Strings(headers)
+		if !c.noHeaders {
+			c.setHeaders(headers)
+		}
+	}
+
+	data := make([]string, len(headers))
+	for i, header := range headers {
+		value := val.MapIndex(reflect.ValueOf(header).Convert(keyType))
+		if !value.IsValid() {
+			continue
+		}
+		field, err := util.FormatCSVValue(value, c.timeLayout)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", header, err)
+		}
+		data[i] = field
+	}
+	return data, nil
+}
+
+// setHeaders sets the headers generated from the first
+// record, they are written before the record
+func (c *CsvCollector) setHeaders(headers []string) {
+	c.headers = headers
+	c.writeHeaders = true
+	util.Log(c.log, "generated headers [", headers, "]")
+}
+
 func (c *CsvCollector) setupSink() error {
 	if c.snkParam == nil {
 		return errors.New("missing CSV sink")
@@ -3,12 +3,15 @@ package collectors
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io/ioutil"
 	"os"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/api/tuple"
 	"github.com/gofunky/automi/testutil"
 )
 
@@ -54,6 +57,33 @@ func TestCsvCollector_IO(t *testing.T) {
 	}
 }
 
+func TestCsvCollector_UnexpectedType(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		in <- []string{"Christophe", "Petion", "Dessaline"}
+		in <- 12
+		in <- []string{"Toussaint", "Guerrier", "Caiman"}
+		close(in)
+	}()
+	data := bytes.NewBufferString("")
+	csv := CSV(data)
+	csv.SetInput(in)
+
+	select {
+	case err := <-csv.Open(context.Background()):
+		var perr api.ProcError
+		if !errors.As(err, &perr) || perr.Item != 12 {
+			t.Fatal("expecting error for unexpected type, got ", err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("collector took too long to open")
+	}
+
+	if actual := strings.TrimSpace(data.String()); actual != "Christophe,Petion,Dessaline" {
+		t.Fatal("collector did not get expected data, got: ", actual)
+	}
+}
+
 func TestCsvCollector_File(t *testing.T) {
 	in := make(chan interface{})
 	go func() {
@@ -94,6 +124,138 @@ func TestCsvCollector_File(t *testing.T) {
 	}
 }
 
+func collectCSV(t *testing.T, c *CsvCollector, items ...interface{}) error {
+	t.Helper()
+	in := make(chan interface{}, len(items))
+	for _, item := range items {
+		in <- item
+	}
+	close(in)
+	c.SetInput(in)
+
+	select {
+	case err := <-c.Open(context.Background()):
+		return err
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("collector took too long to open")
+	}
+	return nil
+}
+
+type planet struct {
+	Name     string  `csv:"name"`
+	Diameter float64 `csv:"diam"`
+	Moons    *int
+	Found    time.Time `csv:"found"`
+	Notes    string    `csv:"-"`
+}
+
+func TestCsvCollector_Structs(t *testing.T) {
+	moons := 2
+	found := time.Date(1846, 9, 23, 0, 0, 0, 0, time.UTC)
+	data := new(bytes.Buffer)
+	err := collectCSV(t, CSV(data).TimeLayout("2006-01-02"),
+		planet{Name: "Mars", Diameter: 6779.5, Moons: &moons},
+		&planet{Name: "Neptune", Diameter: 49244, Found: found, Notes: "x"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "name,diam,Moons,found\nMars,6779.5,2,0001-01-01\nNeptune,49244,,1846-09-23\n"
+	if data.String() != expected {
+		t.Fatalf("expecting %q, got %q", expected, data.String())
+	}
+
+	data.Reset()
+	err = collectCSV(t, CSV(data).Headers([]string{"Found", "Name", "radius"}).TimeLayout("2006"),
+		planet{Name: "Neptune", Found: found},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.String() != "Found,Name,radius\n1846,Neptune,\n" {
+		t.Fatalf("expecting fields ordered by headers, got %q", data.String())
+	}
+
+	data.Reset()
+	if err := collectCSV(t, CSV(data).NoHeaders(), planet{Name: "Mars"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(data.String(), "Mars,0,,") {
+		t.Fatalf("expecting no headers, got %q", data.String())
+	}
+}
+
+func TestCsvCollector_Maps(t *testing.T) {
+	data := new(bytes.Buffer)
+	err := collectCSV(t, CSV(data),
+		map[string]interface{}{"name": "Mars", "diam": 6779.5},
+		map[string]interface{}{"name": "Venus", "moons": 0},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.String() != "diam,name\n6779.5,Mars\n,Venus\n" {
+		t.Fatalf("expecting columns of first map, got %q", data.String())
+	}
+
+	data.Reset()
+	err = collectCSV(t, CSV(data).Headers([]string{"name", "moons"}),
+		map[string]int{"moons": 2},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.String() != "name,moons\n,2\n" {
+		t.Fatalf("expecting columns of headers, got %q", data.String())
+	}
+}
+
+func TestCsvCollector_Slices(t *testing.T) {
+	data := new(bytes.Buffer)
+	err := collectCSV(t, CSV(data),
+		tuple.KV{"Mars", 6779.5},
+		tuple.Pair{"moons", 2},
+		[]float64{1.5, 2},
+		[3]bool{true, false, true},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "Mars,6779.5\nmoons,2\n1.5,2\ntrue,false,true\n"
+	if data.String() != expected {
+		t.Fatalf("expecting %q, got %q", expected, data.String())
+	}
+}
+
+func TestCsvCollector_ErrorPolicy(t *testing.T) {
+	items := []interface{}{[]string{"a"}, 12, map[int]string{1: "b"}, []string{"c"}}
+
+	data := new(bytes.Buffer)
+	if err := collectCSV(t, CSV(data).OnError(api.SkipOnError()), items...); err != nil {
+		t.Fatal(err)
+	}
+	if data.String() != "a\nc\n" {
+		t.Fatalf("expecting failed items to be skipped, got %q", data.String())
+	}
+
+	deadLetter := make(chan interface{}, 2)
+	if err := collectCSV(t, CSV(new(bytes.Buffer)).OnError(api.DeadLetterOnError(deadLetter)), items...); err != nil {
+		t.Fatal(err)
+	}
+	if len(deadLetter) != 2 {
+		t.Fatal("expecting 2 dead-lettered items, got ", len(deadLetter))
+	}
+	if perr := (<-deadLetter).(api.ProcError); perr.Item != 12 {
+		t.Fatal("unexpected dead-lettered item ", perr)
+	}
+
+	err := collectCSV(t, CSV(new(bytes.Buffer)), []interface{}{"a", []int{1}})
+	if err == nil || !strings.Contains(err.Error(), "element 1") {
+		t.Fatal("expecting error for nested slice, got ", err)
+	}
+}
+
 func BenchmarkCsvCollector(b *testing.B) {
 	N := b.N
 	b.Logf("N = %d", N)
@@ -6,6 +6,7 @@ import (
 
 	"github.com/go-faces/logger"
 	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/tracing"
 	"github.com/gofunky/automi/util"
 )
 
@@ -40,6 +41,7 @@ func (c *FuncCollector) SetInput(in <-chan interface{}) {
 func (c *FuncCollector) Open(ctx context.Context) <-chan error {
 	c.log = autoctx.GetLogger(ctx)
 	util.Log(c.log, "opening func collector")
+	trace := tracing.NewRecorder(ctx, "func collector")
 	result := make(chan error)
 
 	if c.input == nil {
@@ -58,8 +60,11 @@ func (c *FuncCollector) Open(ctx context.Context) <-chan error {
 			close(result)
 		}()
 
-		for val := range c.input {
-			if err := c.f(val); err != nil {
+		for item := range c.input {
+			itemCtx, val := trace.Start(ctx, item)
+			err := c.f(val)
+			trace.End(itemCtx, err)
+			if err != nil {
 				util.Log(c.log, err)
 				result <- err
 			}
@@ -68,3 +73,69 @@ func (c *FuncCollector) Open(ctx context.Context) <-chan error {
 
 	return result
 }
+
+// TypedFuncCollector is a type-safe collector that
+// uses a function of type func(T) error to collect data.
+type TypedFuncCollector[T any] struct {
+	input <-chan interface{}
+	log   logger.Interface
+	f     func(T) error
+}
+
+// FuncOf creates a new value *TypedFuncCollector that
+// will use the specified function to collect streaming
+// items of type T.
+func FuncOf[T any](f func(T) error) *TypedFuncCollector[T] {
+	return &TypedFuncCollector[T]{f: f}
+}
+
+// SetInput sets the channel input
+func (c *TypedFuncCollector[T]) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// Open is the starting point that starts the collector
+func (c *TypedFuncCollector[T]) Open(ctx context.Context) <-chan error {
+	c.log = autoctx.GetLogger(ctx)
+	util.Log(c.log, "opening typed func collector")
+	trace := tracing.NewRecorder(ctx, "func collector")
+	result := make(chan error)
+
+	if c.input == nil {
+		go func() { result <- errors.New("func collector missing input") }()
+		return result
+	}
+
+	if c.f == nil {
+		go func() { result <- errors.New("func collector missing function") }()
+		return result
+	}
+
+	go func() {
+		var failure error
+		defer func() {
+			if failure != nil {
+				result <- failure
+			}
+			util.Log(c.log, "closing typed func collector")
+			close(result)
+		}()
+
+		for val := range c.input {
+			itemCtx, val := trace.Start(ctx, val)
+			item, err := util.Cast[T](val)
+			if err == nil {
+				err = c.f(item)
+			}
+			trace.End(itemCtx, err)
+			if err != nil {
+				util.Log(c.log, err)
+				if failure == nil {
+					failure = err
+				}
+			}
+		}
+	}()
+
+	return result
+}
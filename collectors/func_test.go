@@ -46,3 +46,31 @@ func TestCollector_FuncErr(t *testing.T) {
 		t.Fatal("Waited too long ...")
 	}
 }
+
+func TestCollector_FuncOf(t *testing.T) {
+	total := 0
+	f := FuncOf(func(val int) error {
+		total += val
+		return nil
+	})
+	in := make(chan interface{})
+	go func() {
+		in <- 1
+		in <- 2
+		in <- 3
+		close(in)
+	}()
+	f.SetInput(in)
+
+	select {
+	case err := <-f.Open(context.TODO()):
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != 6 {
+			t.Fatal("expecting total 6, got ", total)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
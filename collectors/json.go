@@ -0,0 +1,174 @@
+package collectors
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-faces/logger"
+	"github.com/gofunky/automi/api"
+	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/tracing"
+	"github.com/gofunky/automi/util"
+)
+
+// JSONCollector represents a node that encodes each collected item as
+// JSON and writes it to the specified io.Writer or file.  Items are
+// written as newline-delimited JSON (NDJSON), or as the elements of
+// a single JSON array if Array is set.
+type JSONCollector struct {
+	array bool
+
+	snkParam interface{}
+	file     *os.File
+	input    <-chan interface{}
+	writer   *bufio.Writer
+	log      logger.Interface
+}
+
+// JSON creates a *JSONCollector value.  If the sink parameter is
+// a string, it creates a file with that name.  If sink is an
+// io.Writer, it writes to it directly.
+func JSON(sink interface{}) *JSONCollector {
+	return &JSONCollector{snkParam: sink}
+}
+
+// Array writes the items as the elements of a JSON array,
+// which is streamed as items are collected.
+func (c *JSONCollector) Array() *JSONCollector {
+	c.array = true
+	return c
+}
+
+// SetInput sets the channel input
+func (c *JSONCollector) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// Open is the starting point that opens the sink for data to start flowing
+func (c *JSONCollector) Open(ctx context.Context) <-chan error {
+	c.log = autoctx.GetLogger(ctx)
+	util.Log(c.log, "opening json collector node")
+	trace := tracing.NewRecorder(ctx, "json collector")
+	result := make(chan error)
+
+	if c.input == nil {
+		go func() { result <- errors.New("Input attribute not set") }()
+		return result
+	}
+	if err := c.setupSink(); err != nil {
+		go func() { result <- err }()
+		return result
+	}
+
+	go func() {
+		var err error
+		count := 0
+		defer func() {
+			util.Log(c.log, "closing json collector")
+			if c.array {
+				c.endArray(count)
+			}
+			if e := c.writer.Flush(); e != nil && err == nil {
+				err = e
+			}
+			if c.file != nil {
+				if e := c.file.Close(); e != nil && err == nil {
+					err = e
+				}
+			}
+			if err != nil {
+				go func() { result <- err }()
+				return
+			}
+			close(result)
+		}()
+
+		for val := range c.input {
+			// after a failure, remaining items are discarded
+			if err != nil {
+				continue
+			}
+			itemCtx, item := trace.Start(ctx, val)
+
+			data, e := json.Marshal(item)
+			if e != nil { // fail fast, as the output would be incomplete
+				err = api.ProcError{
+					Err:      fmt.Errorf("unable to encode item: %w", e),
+					ProcName: "json collector",
+					Item:     item,
+				}
+				util.Log(c.log, err)
+				trace.End(itemCtx, err)
+				continue
+			}
+
+			c.write(data, count)
+			count++
+			if e := c.writer.Flush(); e != nil {
+				err = fmt.Errorf("IO flush error: %w", e)
+				util.Log(c.log, err)
+				trace.End(itemCtx, err)
+				continue
+			}
+			trace.End(itemCtx, nil)
+
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+		}
+	}()
+
+	return result
+}
+
+// write writes the encoded item following count items
+func (c *JSONCollector) write(data []byte, count int) {
+	if c.array {
+		if count == 0 {
+			c.writer.WriteString("[\n")
+		} else {
+			c.writer.WriteString(",\n")
+		}
+	}
+	c.writer.Write(data)
+	if !c.array {
+		c.writer.WriteByte('\n')
+	}
+}
+
+// endArray closes the array of count items
+func (c *JSONCollector) endArray(count int) {
+	if count == 0 {
+		c.writer.WriteString("[]\n")
+		return
+	}
+	c.writer.WriteString("\n]\n")
+}
+
+func (c *JSONCollector) setupSink() error {
+	switch snk := c.snkParam.(type) {
+	case nil:
+		return errors.New("missing JSON sink")
+	case string:
+		f, err := os.Create(snk)
+		if err != nil {
+			return err
+		}
+		util.Log(c.log, "setting up file", f.Name(), "as json sink")
+		c.writer = bufio.NewWriter(f)
+		c.file = f // so we can close it
+	case io.Writer:
+		util.Log(c.log, "using io.Writer as json sink")
+		c.writer = bufio.NewWriter(snk)
+	default:
+		return errors.New("invalid JSON sink")
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package collectors
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofunky/automi/api"
+)
+
+func collectJSON(t *testing.T, c *JSONCollector, items ...interface{}) error {
+	t.Helper()
+	in := make(chan interface{}, len(items))
+	for _, item := range items {
+		in <- item
+	}
+	close(in)
+	c.SetInput(in)
+
+	select {
+	case err := <-c.Open(context.Background()):
+		return err
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("collector took too long to open")
+	}
+	return nil
+}
+
+func TestJSONCollector_NDJSON(t *testing.T) {
+	data := new(bytes.Buffer)
+	type record struct {
+		Name string `json:"name"`
+		Qty  int    `json:"qty"`
+	}
+	err := collectJSON(t, JSON(data), record{"alpha", 1}, map[string]int{"qty": 2}, "beta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\"name\":\"alpha\",\"qty\":1}\n{\"qty\":2}\n\"beta\"\n"
+	if data.String() != expected {
+		t.Fatalf("expecting %q, got %q", expected, data.String())
+	}
+}
+
+func TestJSONCollector_Array(t *testing.T) {
+	data := new(bytes.Buffer)
+	if err := collectJSON(t, JSON(data).Array(), 1, []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+	if data.String() != "[\n1,\n[\"a\"]\n]\n" {
+		t.Fatalf("unexpected array %q", data.String())
+	}
+
+	data.Reset()
+	if err := collectJSON(t, JSON(data).Array()); err != nil {
+		t.Fatal(err)
+	}
+	if data.String() != "[]\n" {
+		t.Fatalf("expecting empty array, got %q", data.String())
+	}
+}
+
+func TestJSONCollector_UnsupportedType(t *testing.T) {
+	data := new(bytes.Buffer)
+	ch := make(chan int)
+	err := collectJSON(t, JSON(data).Array(), 1, ch, 3)
+	var perr api.ProcError
+	if !errors.As(err, &perr) || perr.Item != ch {
+		t.Fatal("expecting error for unsupported type, got ", err)
+	}
+	if data.String() != "[\n1\n]\n" {
+		t.Fatalf("expecting array to be closed, got %q", data.String())
+	}
+}
+
+func TestJSONCollector_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.ndjson")
+	if err := collectJSON(t, JSON(path), 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "1\n2\n" {
+		t.Fatalf("unexpected file content %q", data)
+	}
+
+	if err := collectJSON(t, JSON(nil)); err == nil {
+		t.Fatal("expecting error for missing sink")
+	}
+}
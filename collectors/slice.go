@@ -2,9 +2,12 @@ package collectors
 
 import (
 	"context"
+	"fmt"
+	"reflect"
 
 	"github.com/go-faces/logger"
 	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/tracing"
 	"github.com/gofunky/automi/util"
 )
 
@@ -29,6 +32,7 @@ func (s *SliceCollector) Get() []interface{} {
 func (s *SliceCollector) Open(ctx context.Context) <-chan error {
 	s.log = autoctx.GetLogger(ctx)
 	util.Log(s.log, "opening slice collector")
+	trace := tracing.NewRecorder(ctx, "slice collector")
 	result := make(chan error)
 
 	go func() {
@@ -36,8 +40,134 @@ func (s *SliceCollector) Open(ctx context.Context) <-chan error {
 			close(result)
 			util.Log(s.log, "closing slice collector")
 		}()
-		for val := range s.input {
+		for item := range s.input {
+			itemCtx, val := trace.Start(ctx, item)
 			s.slice = append(s.slice, val)
+			trace.End(itemCtx, nil)
+		}
+	}()
+
+	return result
+}
+
+// TypedSliceCollector is a type-safe collector that
+// collects streamed items into a slice []T.
+type TypedSliceCollector[T any] struct {
+	slice []T
+	input <-chan interface{}
+	log   logger.Interface
+}
+
+// SliceOf creates a new type-safe slice collector
+func SliceOf[T any]() *TypedSliceCollector[T] {
+	return new(TypedSliceCollector[T])
+}
+
+// SetInput sets the channel input
+func (s *TypedSliceCollector[T]) SetInput(in <-chan interface{}) {
+	s.input = in
+}
+
+// Get returns the collected items
+func (s *TypedSliceCollector[T]) Get() []T {
+	return s.slice
+}
+
+// Open opens the node to start collecting.  Items that are not
+// of type T are skipped and the first mismatch is reported as an error
+// once the input is drained.
+func (s *TypedSliceCollector[T]) Open(ctx context.Context) <-chan error {
+	s.log = autoctx.GetLogger(ctx)
+	util.Log(s.log, "opening typed slice collector")
+	trace := tracing.NewRecorder(ctx, "slice collector")
+	result := make(chan error)
+
+	go func() {
+		var failure error
+		defer func() {
+			if failure != nil {
+				result <- failure
+			}
+			close(result)
+			util.Log(s.log, "closing typed slice collector")
+		}()
+		for val := range s.input {
+			itemCtx, val := trace.Start(ctx, val)
+			item, err := util.Cast[T](val)
+			trace.End(itemCtx, err)
+			if err != nil {
+				util.Log(s.log, err)
+				if failure == nil {
+					failure = err
+				}
+				continue
+			}
+			s.slice = append(s.slice, item)
+		}
+	}()
+
+	return result
+}
+
+// AppendCollector is a collector that appends the collected
+// items to a slice of any element type, through a pointer.
+type AppendCollector struct {
+	ptrParam interface{}
+	slice    reflect.Value
+	input    <-chan interface{}
+	log      logger.Interface
+}
+
+// Append creates a new *AppendCollector that appends the collected
+// items to the slice pointed to by ptr, i.e. a *[]int.  Items are
+// converted to the element type of the slice, see util.ConvertTo.
+func Append(ptr interface{}) *AppendCollector {
+	return &AppendCollector{ptrParam: ptr}
+}
+
+// SetInput sets the channel input
+func (s *AppendCollector) SetInput(in <-chan interface{}) {
+	s.input = in
+}
+
+// Open opens the node to start collecting.  Items that cannot be converted
+// to the element type are skipped and the first one is reported as an
+// error once the input is drained.  The slice is updated as items are
+// collected, it should not be read before the stream is done.
+func (s *AppendCollector) Open(ctx context.Context) <-chan error {
+	s.log = autoctx.GetLogger(ctx)
+	util.Log(s.log, "opening append collector")
+	trace := tracing.NewRecorder(ctx, "slice collector")
+	result := make(chan error)
+
+	ptr := reflect.ValueOf(s.ptrParam)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Slice {
+		go func() { result <- fmt.Errorf("append collector expects a pointer to a slice, got %T", s.ptrParam) }()
+		return result
+	}
+	s.slice = ptr.Elem()
+
+	go func() {
+		var failure error
+		defer func() {
+			if failure != nil {
+				result <- failure
+			}
+			close(result)
+			util.Log(s.log, "closing append collector")
+		}()
+		for val := range s.input {
+			itemCtx, val := trace.Start(ctx, val)
+			item, err := util.ConvertTo(val, s.slice.Type().Elem())
+			trace.End(itemCtx, err)
+			if err != nil {
+				util.Log(s.log, err)
+				if failure == nil {
+					failure = err
+				}
+				continue
+			}
+			s.slice.Set(reflect.Append(s.slice, item))
 		}
 	}()
 
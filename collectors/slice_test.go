@@ -33,3 +33,62 @@ func TestCollector_Slice(t *testing.T) {
 		t.Fatal("Waited too long ...")
 	}
 }
+
+func TestCollector_SliceOf(t *testing.T) {
+	sc := SliceOf[string]()
+	in := make(chan interface{})
+	go func() {
+		in <- "A"
+		in <- "B"
+		in <- 3
+		in <- "D"
+		close(in)
+	}()
+	sc.SetInput(in)
+
+	select {
+	case err := <-sc.Open(context.TODO()):
+		if err == nil {
+			t.Fatal("expecting type mismatch error")
+		}
+		result := sc.Get()
+		if len(result) != 3 || result[2] != "D" {
+			t.Fatal("unexpected slice ", result)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
+
+func TestAppendCollector(t *testing.T) {
+	type word string
+	in := make(chan interface{}, 4)
+	in <- "a"
+	in <- word("b")
+	in <- 3
+	in <- "d"
+	close(in)
+
+	words := []word{"z"}
+	snk := Append(&words)
+	snk.SetInput(in)
+	select {
+	case err := <-snk.Open(context.Background()):
+		if err == nil {
+			t.Fatal("expecting conversion error")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("collector took too long")
+	}
+	if len(words) != 4 || words[1] != "a" || words[3] != "d" {
+		t.Fatal("unexpected items ", words)
+	}
+
+	for _, param := range []interface{}{nil, words, new(int), (*[]int)(nil)} {
+		snk := Append(param)
+		snk.SetInput(in)
+		if err := <-snk.Open(context.Background()); err == nil {
+			t.Errorf("%T: expecting error", param)
+		}
+	}
+}
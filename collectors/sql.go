@@ -0,0 +1,288 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-faces/logger"
+	"github.com/gofunky/automi/api"
+	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/tracing"
+	"github.com/gofunky/automi/util"
+)
+
+// TxBeginner starts transactions, it is implemented by *sql.DB and *sql.Conn
+type TxBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// SQLCollector represents a node that executes an insert statement for
+// each collected item.  Items are inserted in batches, each batch is
+// inserted in its own transaction.
+type SQLCollector struct {
+	db        TxBeginner
+	stmt      string
+	batchSize int
+	interval  time.Duration
+	columns   []string
+
+	fields map[reflect.Type][]util.StructField // struct fields by column
+	input  <-chan interface{}
+	log    logger.Interface
+}
+
+// SQL creates a new *SQLCollector that executes the insert
+// statement stmt on db, i.e. "INSERT INTO logs VALUES (?, ?)".
+func SQL(db TxBeginner, stmt string) *SQLCollector {
+	return &SQLCollector{
+		db:        db,
+		stmt:      stmt,
+		batchSize: 100,
+	}
+}
+
+// BatchSize sets the number of items inserted per
+// transaction, 100 by default.
+func (c *SQLCollector) BatchSize(size int) *SQLCollector {
+	c.batchSize = size
+	return c
+}
+
+// CommitInterval commits the pending items once interval elapsed since
+// the first of them was collected, even if the batch is not full.  By
+// default, batches are only committed when full or when the input is
+// drained.
+func (c *SQLCollector) CommitInterval(interval time.Duration) *SQLCollector {
+	c.interval = interval
+	return c
+}
+
+// Columns sets the names of the struct fields, or of the map keys,
+// that provide the arguments of the statement, see Open.
+func (c *SQLCollector) Columns(columns ...string) *SQLCollector {
+	c.columns = columns
+	return c
+}
+
+// SetInput sets the channel input
+func (c *SQLCollector) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// Open is the starting point that opens the sink for data to start flowing.
+//
+// The arguments of the statement are the elements of slices and arrays,
+// such as tuple.KV, or the exported fields of structs, in order or matched
+// to the columns by their db tag or name.  Maps, with string keys, require
+// columns.  Other items are the single argument of the statement.
+//
+// An item that cannot be inserted rolls back its batch and fails the
+// collector, which discards the remaining items.
+func (c *SQLCollector) Open(ctx context.Context) <-chan error {
+	c.log = autoctx.GetLogger(ctx)
+	util.Log(c.log, "opening sql collector node")
+	trace := tracing.NewRecorder(ctx, "sql collector")
+	result := make(chan error)
+
+	if err := c.init(); err != nil {
+		go func() { result <- err }()
+		return result
+	}
+
+	go func() {
+		var err error
+		var batch *sqlBatch
+		var timer *time.Timer
+		var flush <-chan time.Time
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+			if batch != nil {
+				if err == nil && ctx.Err() == nil {
+					err = batch.commit()
+				} else {
+					batch.rollback()
+				}
+			}
+			if err != nil {
+				go func() { result <- err }()
+				return
+			}
+			close(result)
+			util.Log(c.log, "closing sql collector")
+		}()
+
+		for {
+			select {
+			case val, ok := <-c.input:
+				if !ok {
+					return
+				}
+				// after a failure, remaining items are discarded
+				if err != nil {
+					continue
+				}
+				itemCtx, item := trace.Start(ctx, val)
+				if batch == nil {
+					if batch, err = c.begin(ctx); err != nil {
+						util.Log(c.log, err)
+						trace.End(itemCtx, err)
+						continue
+					}
+					if c.interval > 0 {
+						timer = time.NewTimer(c.interval)
+						flush = timer.C
+					}
+				}
+
+				err = c.insert(ctx, batch, item)
+				trace.End(itemCtx, err)
+				if err != nil {
+					util.Log(c.log, err)
+					batch.rollback()
+					batch = nil
+					continue
+				}
+				if batch.count < c.batchSize {
+					continue
+				}
+			case <-flush:
+			case <-ctx.Done():
+				return
+			}
+
+			// commit the full, or expired, batch
+			if timer != nil {
+				timer.Stop()
+				timer, flush = nil, nil
+			}
+			if batch != nil {
+				err = batch.commit()
+				batch = nil
+			}
+		}
+	}()
+
+	return result
+}
+
+func (c *SQLCollector) init() error {
+	if c.input == nil {
+		return errors.New("sql collector missing input")
+	}
+	if c.db == nil {
+		return errors.New("sql collector missing database")
+	}
+	if c.batchSize <= 0 {
+		c.batchSize = 100
+	}
+	c.fields = make(map[reflect.Type][]util.StructField)
+	return nil
+}
+
+// sqlBatch is a transaction inserting a batch of items
+type sqlBatch struct {
+	tx    *sql.Tx
+	stmt  *sql.Stmt
+	count int
+}
+
+// begin starts the transaction of a new batch
+func (c *SQLCollector) begin(ctx context.Context) (*sqlBatch, error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	stmt, err := tx.PrepareContext(ctx, c.stmt)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("unable to prepare statement: %w", err)
+	}
+	return &sqlBatch{tx: tx, stmt: stmt}, nil
+}
+
+func (b *sqlBatch) commit() error {
+	if err := b.tx.Commit(); err != nil {
+		return fmt.Errorf("unable to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (b *sqlBatch) rollback() {
+	b.tx.Rollback()
+}
+
+// insert executes the statement of batch for item
+func (c *SQLCollector) insert(ctx context.Context, batch *sqlBatch, item interface{}) error {
+	args, err := c.args(item)
+	if err == nil {
+		_, err = batch.stmt.ExecContext(ctx, args...)
+	}
+	if err != nil {
+		return api.ProcError{Err: err, ProcName: "sql collector", Item: item}
+	}
+	batch.count++
+	return nil
+}
+
+// args returns the statement arguments of item
+func (c *SQLCollector) args(item interface{}) ([]interface{}, error) {
+	if args, ok := item.([]interface{}); ok {
+		return args, nil
+	}
+
+	val := reflect.ValueOf(item)
+	for val.Kind() == reflect.Ptr && !val.IsNil() {
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.Slice, reflect.Array:
+		if val.Type().Elem().Kind() == reflect.Uint8 {
+			break // []byte is a single value
+		}
+		args := make([]interface{}, val.Len())
+		for i := range args {
+			args[i] = val.Index(i).Interface()
+		}
+		return args, nil
+	case reflect.Struct:
+		if _, ok := item.(time.Time); ok {
+			break
+		}
+		fields, ok := c.fields[val.Type()]
+		if !ok {
+			fields = util.StructFields(val.Type(), "db")
+			if c.columns != nil {
+				fields = util.MatchFields(c.columns, fields)
+			}
+			c.fields[val.Type()] = fields
+		}
+		args := make([]interface{}, len(fields))
+		for i, field := range fields {
+			if field.Index == nil {
+				return nil, fmt.Errorf("no field for column %s", c.columns[i])
+			}
+			args[i] = val.FieldByIndex(field.Index).Interface()
+		}
+		return args, nil
+	case reflect.Map:
+		if val.Type().Key().Kind() != reflect.String || c.columns == nil {
+			return nil, errors.New("maps require columns and string keys")
+		}
+		args := make([]interface{}, len(c.columns))
+		for i, column := range c.columns {
+			value := val.MapIndex(reflect.ValueOf(column).Convert(val.Type().Key()))
+			if !value.IsValid() {
+				return nil, fmt.Errorf("no value for column %s", column)
+			}
+			args[i] = value.Interface()
+		}
+		return args, nil
+	}
+	return []interface{}{item}, nil
+}
@@ -0,0 +1,116 @@
+package collectors
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/api/tuple"
+	"github.com/gofunky/automi/testutil"
+)
+
+func collectSQL(t *testing.T, c *SQLCollector, in <-chan interface{}) error {
+	t.Helper()
+	c.SetInput(in)
+	select {
+	case err := <-c.Open(context.Background()):
+		return err
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("collector took too long")
+	}
+	return nil
+}
+
+func TestSQLCollector_Batches(t *testing.T) {
+	type planet struct {
+		Moons int    `db:"moons"`
+		Name  string `db:"name"`
+	}
+	fake, db := testutil.NewFakeDB(nil)
+	defer db.Close()
+
+	in := make(chan interface{}, 5)
+	in <- []interface{}{"Mercury", 0}
+	in <- tuple.KV{"Venus", 0}
+	in <- planet{Name: "Earth", Moons: 1}
+	in <- &planet{Name: "Mars", Moons: 2}
+	in <- map[string]interface{}{"name": "Jupiter", "moons": 95}
+	close(in)
+
+	err := collectSQL(t, SQL(db, "INSERT INTO planets VALUES (?, ?)").BatchSize(2).Columns("name", "moons"), in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := fake.Table("planets").Rows
+	if len(rows) != 5 || rows[2][0] != "Earth" || rows[2][1] != int64(1) || rows[4][0] != "Jupiter" {
+		t.Fatal("unexpected rows ", rows)
+	}
+	if fake.Commits() != 3 {
+		t.Fatal("expecting 3 transactions, got ", fake.Commits())
+	}
+}
+
+func TestSQLCollector_CommitInterval(t *testing.T) {
+	fake, db := testutil.NewFakeDB(nil)
+	defer db.Close()
+
+	in := make(chan interface{})
+	snk := SQL(db, "INSERT INTO words VALUES (?)").CommitInterval(5 * time.Millisecond)
+	snk.SetInput(in)
+	result := snk.Open(context.Background())
+
+	in <- "hello"
+	in <- "world"
+	time.Sleep(20 * time.Millisecond)
+	if rows := fake.Table("words").Rows; len(rows) != 2 {
+		t.Fatal("expecting pending items to be committed, got ", rows)
+	}
+
+	in <- "!"
+	close(in)
+	if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+	if fake.Commits() != 2 || len(fake.Table("words").Rows) != 3 {
+		t.Fatal("unexpected commits ", fake.Commits(), fake.Table("words").Rows)
+	}
+}
+
+func TestSQLCollector_Failure(t *testing.T) {
+	fake, db := testutil.NewFakeDB(nil)
+	defer db.Close()
+	fake.FailExec = func(args []driver.Value) error {
+		if args[0] == "c" {
+			return errors.New("duplicate key")
+		}
+		return nil
+	}
+
+	in := make(chan interface{}, 5)
+	for _, item := range []string{"a", "b", "c", "d", "e"} {
+		in <- item
+	}
+	close(in)
+
+	err := collectSQL(t, SQL(db, "INSERT INTO letters VALUES (?)").BatchSize(2), in)
+	var perr api.ProcError
+	if !errors.As(err, &perr) || perr.Item != "c" {
+		t.Fatal("expecting insert error, got ", err)
+	}
+	if rows := fake.Table("letters").Rows; len(rows) != 2 {
+		t.Fatal("expecting failed batch to be rolled back, got ", rows)
+	}
+
+	in = make(chan interface{}, 1)
+	in <- map[string]int{"a": 1}
+	close(in)
+	if err := collectSQL(t, SQL(db, "INSERT INTO letters VALUES (?)"), in); err == nil {
+		t.Fatal("expecting error for map without columns")
+	}
+	if err := collectSQL(t, SQL(nil, "INSERT INTO letters VALUES (?)"), in); err == nil {
+		t.Fatal("expecting error for missing database")
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/go-faces/logger"
 	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/tracing"
 	"github.com/gofunky/automi/util"
 )
 
@@ -32,6 +33,7 @@ func (c *WriterCollector) SetInput(in <-chan interface{}) {
 func (c *WriterCollector) Open(ctx context.Context) <-chan error {
 	c.log = autoctx.GetLogger(ctx)
 	util.Log(c.log, "opening io.Writer collector")
+	trace := tracing.NewRecorder(ctx, "writer collector")
 	result := make(chan error)
 
 	if err := c.setupWriter(); err != nil {
@@ -49,13 +51,15 @@ func (c *WriterCollector) Open(ctx context.Context) <-chan error {
 			util.Log(c.log, "closing io.Writer collector")
 		}()
 
-		for val := range c.input {
+		for item := range c.input {
+			itemCtx, val := trace.Start(ctx, item)
 			switch data := val.(type) {
 			case string:
 				fmt.Fprint(c.writer, data)
 			case []byte:
 				if _, err := c.writer.Write(data); err != nil {
 					util.Log(c.log, err)
+					trace.End(itemCtx, err)
 					//TODO runtime error handling
 					continue
 				}
@@ -64,6 +68,7 @@ func (c *WriterCollector) Open(ctx context.Context) <-chan error {
 				// extracted by fmt
 				fmt.Fprintf(c.writer, "%v", data)
 			}
+			trace.End(itemCtx, nil)
 		}
 	}()
 
@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-faces/logger"
 	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/tracing"
 	"github.com/gofunky/automi/util"
 )
 
@@ -45,6 +46,7 @@ func (c *ChanEmitter) Open(ctx context.Context) error {
 	if !chanVal.IsValid() {
 		return errors.New("invalid channel for ChanEmitter")
 	}
+	trace := tracing.NewRecorder(ctx, "channel emitter")
 
 	go func() {
 		defer func() {
@@ -52,12 +54,76 @@ func (c *ChanEmitter) Open(ctx context.Context) error {
 			util.Log(c.log, "closing slice emitter")
 		}()
 
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: chanVal},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		}
 		for {
-			val, open := chanVal.Recv()
+			chosen, val, open := reflect.Select(cases)
+			if chosen == 1 {
+				util.Log(c.log, "channel emitter cancelled")
+				return
+			}
 			if !open {
 				return
 			}
-			c.output <- val.Interface()
+			if !trace.Emit(ctx, c.output, val.Interface()) {
+				util.Log(c.log, "channel emitter cancelled")
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// TypedChanEmitter is a type-safe emitter that streams
+// the items received from a channel of type <-chan T.
+type TypedChanEmitter[T any] struct {
+	channel <-chan T
+	output  chan interface{}
+	log     logger.Interface
+}
+
+// ChanOf creates a new type-safe channel source
+func ChanOf[T any](channel <-chan T) *TypedChanEmitter[T] {
+	return &TypedChanEmitter[T]{
+		channel: channel,
+		output:  make(chan interface{}, 1024),
+	}
+}
+
+// GetOutput returns the output channel of this source node
+func (c *TypedChanEmitter[T]) GetOutput() <-chan interface{} {
+	return c.output
+}
+
+// Open opens the source node to start streaming data on its channel
+func (c *TypedChanEmitter[T]) Open(ctx context.Context) error {
+	if c.channel == nil {
+		return errors.New("invalid channel for TypedChanEmitter")
+	}
+	c.log = autoctx.GetLogger(ctx)
+	util.Log(c.log, "opening typed channel emitter")
+	trace := tracing.NewRecorder(ctx, "channel emitter")
+
+	go func() {
+		defer func() {
+			close(c.output)
+			util.Log(c.log, "closing typed channel emitter")
+		}()
+
+		for {
+			select {
+			case item, open := <-c.channel:
+				if !open {
+					return
+				}
+				if !trace.Emit(ctx, c.output, item) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 	return nil
@@ -59,3 +59,59 @@ func TestEmitter_Chan(t *testing.T) {
 	}
 
 }
+
+func TestEmitter_ChanOf(t *testing.T) {
+	ch := make(chan string)
+	go func() {
+		for _, val := range []string{"A", "B", "C"} {
+			ch <- val
+		}
+		close(ch)
+	}()
+
+	e := ChanOf(ch)
+	var m sync.Mutex
+	var result []string
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for item := range e.GetOutput() {
+			m.Lock()
+			result = append(result, item.(string))
+			m.Unlock()
+		}
+	}()
+
+	if err := e.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-wait:
+	case <-time.After(500 * time.Microsecond):
+		t.Fatal("waited too long")
+	}
+	m.Lock()
+	if len(result) != 3 || result[2] != "C" {
+		t.Fatal("unexpected result ", result)
+	}
+	m.Unlock()
+}
+
+func TestEmitter_Chan_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := Chan(make(chan string))
+	if err := ch.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	select {
+	case _, opened := <-ch.GetOutput():
+		if opened {
+			t.Fatal("unexpected item")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("expecting emitter to close once cancelled")
+	}
+}
@@ -7,14 +7,21 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"reflect"
+	"sync"
+	"time"
 
 	"github.com/go-faces/logger"
+	"github.com/gofunky/automi/api"
 	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/tracing"
 	"github.com/gofunky/automi/util"
 )
 
 // CsvEmitter implements an Emitter node that gets its content from the
-// specified io.Reader and emits each record as []string.
+// specified io.Reader and emits each record as []string.  Records can
+// instead be emitted as map[string]string, see AsMaps, or decoded into
+// structs, see As, using the column headers.
 type CsvEmitter struct {
 	filepath    string   // path for the file
 	delimChar   rune     // Delimiter charater, defaults to comma
@@ -23,6 +30,14 @@ type CsvEmitter struct {
 	hasHeaders  bool     // indicates first row is for headers (default false).
 	fieldCount  int      // if greater than zero is used to validate field count
 
+	asMaps     bool               // emit records as map[string]string
+	itemType   reflect.Type       // struct type records are decoded into
+	timeLayout string             // layout of time.Time fields
+	policy     *api.ErrorPolicy   // handling of rows that cannot be read
+	columns    []util.StructField // struct fields by column, resolved on init
+	errMutex   sync.Mutex
+	err        error
+
 	srcParam  interface{}
 	file      *os.File
 	srcReader io.Reader
@@ -63,6 +78,64 @@ func (c *CsvEmitter) HasHeaders() *CsvEmitter {
 	return c
 }
 
+// Headers sets the column header names.  They take
+// precedence over the header record of the source.
+func (c *CsvEmitter) Headers(headers []string) *CsvEmitter {
+	c.headers = headers
+	return c
+}
+
+// FieldCount sets the number of fields expected in each record.  If n is
+// zero, the default, records must have as many fields as the first one.
+// If n is negative, records may have a variable number of fields.
+// Records with unexpected field counts are handled as error rows.
+func (c *CsvEmitter) FieldCount(n int) *CsvEmitter {
+	c.fieldCount = n
+	return c
+}
+
+// AsMaps emits each record as a map[string]string that maps
+// the column headers to the fields of the record.
+func (c *CsvEmitter) AsMaps() *CsvEmitter {
+	c.asMaps = true
+	return c
+}
+
+// As decodes each record into a value of the struct type of prototype.
+// Fields are matched to columns by the name in their csv tag, i.e.
+// `csv:"born"`, or by their name, ignoring case.  Columns are converted
+// to the type of their field, see TimeLayout for time.Time fields.
+// For instance, As(Person{}) emits Person values and As(&Person{})
+// emits *Person values.
+func (c *CsvEmitter) As(prototype interface{}) *CsvEmitter {
+	c.itemType = reflect.TypeOf(prototype)
+	return c
+}
+
+// TimeLayout sets the layout used to parse time.Time
+// fields, time.RFC3339 by default.
+func (c *CsvEmitter) TimeLayout(layout string) *CsvEmitter {
+	c.timeLayout = layout
+	return c
+}
+
+// OnErrorRow sets the policy applied to the rows that cannot be read or
+// decoded, as api.ProcError values carrying the fields of the row.  Rows
+// that fail are dead-lettered, skipped, or stop the emitter, in which
+// case the stream reports the error.  Retries do not apply.  Without a
+// policy, rows that fail are logged and skipped.
+func (c *CsvEmitter) OnErrorRow(policy api.ErrorPolicy) *CsvEmitter {
+	c.policy = &policy
+	return c
+}
+
+// Err returns the error that stopped the emitter, if any
+func (c *CsvEmitter) Err() error {
+	c.errMutex.Lock()
+	defer c.errMutex.Unlock()
+	return c.err
+}
+
 // init internal initialization method
 func (c *CsvEmitter) init(ctx context.Context) error {
 	// extract logger
@@ -88,20 +161,22 @@ func (c *CsvEmitter) init(ctx context.Context) error {
 	c.csvReader.Comma = c.delimChar
 	c.csvReader.TrimLeadingSpace = true
 	c.csvReader.LazyQuotes = true
+	c.csvReader.FieldsPerRecord = c.fieldCount
 
-	// resolve header and field count
+	// resolve headers, explicit headers take precedence
 	if c.hasHeaders {
-		if headers, err := c.csvReader.Read(); err == nil {
-			c.fieldCount = len(headers)
-			c.headers = headers
-		} else {
+		headers, err := c.csvReader.Read()
+		if err != nil {
 			return fmt.Errorf("Unable to read header row: %s", err)
 		}
-	} else {
-		if c.headers != nil {
-			c.fieldCount = len(c.headers)
+		if c.headers == nil {
+			c.headers = headers
 		}
 	}
+
+	if err := c.setupDecoding(); err != nil {
+		return err
+	}
 	util.Log(c.log, "csv source initialized")
 
 	return nil
@@ -117,13 +192,13 @@ func (c *CsvEmitter) Open(ctx context.Context) (err error) {
 	if err = c.init(ctx); err != nil {
 		return
 	}
+	trace := tracing.NewRecorder(ctx, "csv emitter")
 
 	go func() {
 		defer func() {
 			close(c.output)
 			if c.file != nil {
-				err = c.file.Close()
-				if err != nil {
+				if err := c.file.Close(); err != nil {
 					util.Log(c.log, err)
 				}
 			}
@@ -136,14 +211,28 @@ func (c *CsvEmitter) Open(ctx context.Context) (err error) {
 				if err == io.EOF {
 					return
 				}
-				//TODO route error
-				util.Log(c.log, fmt.Errorf("Error reading row: %s", err))
+				var parseErr *csv.ParseError
+				if !errors.As(err, &parseErr) {
+					// the source failed, later reads would fail too
+					c.setErr(fmt.Errorf("Error reading row: %w", err))
+					util.Log(c.log, c.Err())
+					return
+				}
+				if c.rejectRow(ctx, fmt.Errorf("Error reading row: %w", err), row) {
+					return
+				}
 				continue
 			}
 
-			select {
-			case c.output <- row:
-			case <-ctx.Done():
+			item, err := c.decode(row)
+			if err != nil {
+				if c.rejectRow(ctx, err, row) {
+					return
+				}
+				continue
+			}
+
+			if !trace.Emit(ctx, c.output, item) {
 				return
 			}
 		}
@@ -152,6 +241,88 @@ func (c *CsvEmitter) Open(ctx context.Context) (err error) {
 	return nil
 }
 
+// setupDecoding validates the decoding mode and
+// maps the columns to the fields of the item type
+func (c *CsvEmitter) setupDecoding() error {
+	if !c.asMaps && c.itemType == nil {
+		return nil
+	}
+	if c.asMaps && c.itemType != nil {
+		return errors.New("csv emitter cannot emit both maps and structs")
+	}
+	if len(c.headers) == 0 {
+		return errors.New("csv emitter requires headers to decode records")
+	}
+	if c.asMaps {
+		return nil
+	}
+
+	structType := c.itemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("csv emitter cannot decode records into %s", c.itemType)
+	}
+	if c.timeLayout == "" {
+		c.timeLayout = time.RFC3339
+	}
+
+	c.columns = util.MatchFields(c.headers, util.StructFields(structType, "csv"))
+	return nil
+}
+
+// decode returns the item emitted for the fields of row
+func (c *CsvEmitter) decode(row []string) (interface{}, error) {
+	switch {
+	case c.asMaps:
+		item := make(map[string]string, len(c.headers))
+		for i, header := range c.headers {
+			if i < len(row) {
+				item[header] = row[i]
+			}
+		}
+		return item, nil
+	case c.itemType != nil:
+		ptr := reflect.New(c.itemType)
+		val := ptr.Elem()
+		if c.itemType.Kind() == reflect.Ptr {
+			val.Set(reflect.New(c.itemType.Elem()))
+			val = val.Elem()
+		}
+		for i, column := range c.columns {
+			if column.Index == nil || i >= len(row) {
+				continue
+			}
+			if err := util.ParseCSVValue(val.FieldByIndex(column.Index), row[i], c.timeLayout); err != nil {
+				return nil, fmt.Errorf("Error decoding column %s: %w", c.headers[i], err)
+			}
+		}
+		return ptr.Elem().Interface(), nil
+	}
+	return row, nil
+}
+
+// rejectRow applies the error row policy to row, it
+// returns true if the emitter must stop emitting
+func (c *CsvEmitter) rejectRow(ctx context.Context, err error, row []string) bool {
+	perr := api.ProcError{Err: err, ProcName: "csv emitter", Item: row}
+	util.Log(c.log, perr)
+	if c.policy != nil {
+		if err := c.policy.Reject(ctx, perr); err != nil {
+			c.setErr(err)
+			return true
+		}
+	}
+	return ctx.Err() != nil
+}
+
+func (c *CsvEmitter) setErr(err error) {
+	c.errMutex.Lock()
+	defer c.errMutex.Unlock()
+	c.err = err
+}
+
 func (c *CsvEmitter) setupSource() error {
 	if c.srcParam == nil {
 		return errors.New("missing CSV source")
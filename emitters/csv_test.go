@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gofunky/automi/api"
 	"github.com/gofunky/automi/testutil"
 )
 
@@ -124,6 +125,125 @@ func TestEmitter_CSV_FileName(t *testing.T) {
 	m.RUnlock()
 }
 
+func collectCSV(t *testing.T, c *CsvEmitter, ctx context.Context) []interface{} {
+	t.Helper()
+	if err := c.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var items []interface{}
+	timeout := time.After(50 * time.Millisecond)
+	for {
+		select {
+		case item, ok := <-c.GetOutput():
+			if !ok {
+				return items
+			}
+			items = append(items, item)
+		case <-timeout:
+			t.Fatal("emitter took too long")
+		}
+	}
+}
+
+func TestEmitter_CSV_AsMaps(t *testing.T) {
+	data := "name,region\nalpha,east\nbeta,west"
+	items := collectCSV(t, CSV(strings.NewReader(data)).HasHeaders().AsMaps(), context.Background())
+	if len(items) != 2 {
+		t.Fatal("expecting 2 items, got ", items)
+	}
+	item, ok := items[1].(map[string]string)
+	if !ok || item["name"] != "beta" || item["region"] != "west" {
+		t.Fatal("unexpected item ", items[1])
+	}
+
+	items = collectCSV(t, CSV(strings.NewReader(data)).HasHeaders().Headers([]string{"n", "r"}).AsMaps(), context.Background())
+	if item := items[0].(map[string]string); item["n"] != "alpha" {
+		t.Fatal("expecting explicit headers to be used, got ", item)
+	}
+
+	if err := CSV(strings.NewReader(data)).AsMaps().Open(context.Background()); err == nil {
+		t.Fatal("expecting error for missing headers")
+	}
+}
+
+type scientist struct {
+	Name    string
+	Born    time.Time `csv:"born_on"`
+	Age     *int      `csv:"age"`
+	Score   float64
+	Active  bool
+	Ignored string `csv:"-"`
+}
+
+func TestEmitter_CSV_AsStruct(t *testing.T) {
+	data := "NAME,born_on,age,score,active,ignored\n" +
+		"Ada,1815-12-10,36,9.5,false,x\n" +
+		"Grace,1906-12-09,,8,true,y\n"
+	items := collectCSV(t, CSV(strings.NewReader(data)).HasHeaders().As(scientist{}).TimeLayout("2006-01-02"), context.Background())
+	if len(items) != 2 {
+		t.Fatal("expecting 2 items, got ", items)
+	}
+	ada := items[0].(scientist)
+	if ada.Name != "Ada" || ada.Born.Year() != 1815 || ada.Age == nil || *ada.Age != 36 || ada.Score != 9.5 || ada.Active || ada.Ignored != "" {
+		t.Fatalf("unexpected item %+v", ada)
+	}
+	grace := items[1].(scientist)
+	if grace.Age != nil || !grace.Active {
+		t.Fatalf("unexpected item %+v", grace)
+	}
+
+	items = collectCSV(t, CSV(strings.NewReader(data)).HasHeaders().As(&scientist{}).TimeLayout("2006-01-02"), context.Background())
+	if s, ok := items[1].(*scientist); !ok || s.Name != "Grace" {
+		t.Fatal("expecting *scientist items, got ", items)
+	}
+
+	if err := CSV(strings.NewReader(data)).HasHeaders().As(12).Open(context.Background()); err == nil {
+		t.Fatal("expecting error for non struct type")
+	}
+}
+
+func TestEmitter_CSV_ErrorRows(t *testing.T) {
+	data := "name,age\nalpha,1\nbeta,two\ngamma\ndelta,4\n"
+	newEmitter := func() *CsvEmitter {
+		return CSV(strings.NewReader(data)).HasHeaders().As(struct {
+			Name string
+			Age  int
+		}{})
+	}
+
+	if items := collectCSV(t, newEmitter(), context.Background()); len(items) != 2 {
+		t.Fatal("expecting error rows to be skipped, got ", items)
+	}
+
+	deadLetter := make(chan interface{}, 2)
+	items := collectCSV(t, newEmitter().OnErrorRow(api.DeadLetterOnError(deadLetter)), context.Background())
+	if len(items) != 2 || len(deadLetter) != 2 {
+		t.Fatal("expecting 2 items and 2 error rows, got ", items, len(deadLetter))
+	}
+	perr := (<-deadLetter).(api.ProcError)
+	if row, ok := perr.Item.([]string); !ok || row[0] != "beta" {
+		t.Fatal("expecting error row to carry its fields, got ", perr)
+	}
+
+	emitter := newEmitter().OnErrorRow(api.FailOnError())
+	if items := collectCSV(t, emitter, context.Background()); len(items) != 1 {
+		t.Fatal("expecting emitter to stop at first error row, got ", items)
+	}
+	if err := emitter.Err(); err == nil || !strings.Contains(err.Error(), "column age") {
+		t.Fatal("expecting decoding error, got ", err)
+	}
+
+	// variable field counts are accepted, missing fields are left empty
+	items = collectCSV(t, newEmitter().FieldCount(-1).OnErrorRow(api.FailOnError()), context.Background())
+	if len(items) != 1 {
+		t.Fatal("expecting emitter to stop at mistyped row, got ", items)
+	}
+	items = collectCSV(t, CSV(strings.NewReader(data)).HasHeaders().FieldCount(-1).AsMaps(), context.Background())
+	if len(items) != 4 || items[2].(map[string]string)["name"] != "gamma" {
+		t.Fatal("expecting rows with missing fields, got ", items)
+	}
+}
+
 func Benchmark_CSV(b *testing.B) {
 	N := b.N
 	b.Logf("N = %d", N)
@@ -0,0 +1,192 @@
+package emitters
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/go-faces/logger"
+	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/tracing"
+	"github.com/gofunky/automi/util"
+)
+
+// JSONEmitter implements an Emitter node that decodes the JSON values of
+// its source and emits them one at a time.  The source is either a JSON
+// array, whose elements are emitted, or a sequence of JSON values such as
+// newline-delimited JSON (NDJSON).  Values are decoded as
+// map[string]interface{} unless a type is specified with As.
+type JSONEmitter struct {
+	srcParam interface{}
+	itemType reflect.Type
+	errMutex sync.Mutex
+	err      error
+
+	file      *os.File
+	srcReader io.Reader
+	log       logger.Interface
+	output    chan interface{}
+}
+
+// JSON creates a new *JSONEmitter.  If the source parameter is a string,
+// it attempts to open a file with that name.  If source is an io.Reader,
+// it sources from the reader directly.  Any other source type will cause
+// an error.
+func JSON(source interface{}) *JSONEmitter {
+	return &JSONEmitter{
+		srcParam: source,
+		output:   make(chan interface{}, 1024),
+	}
+}
+
+// As sets the type of the emitted items to the type of prototype.
+// For instance, As(Order{}) emits Order values and As(&Order{})
+// emits *Order values.
+func (e *JSONEmitter) As(prototype interface{}) *JSONEmitter {
+	e.itemType = reflect.TypeOf(prototype)
+	return e
+}
+
+// Err returns the error that stopped the emitter, if any
+func (e *JSONEmitter) Err() error {
+	e.errMutex.Lock()
+	defer e.errMutex.Unlock()
+	return e.err
+}
+
+// GetOutput returns the output channel of this source node
+func (e *JSONEmitter) GetOutput() <-chan interface{} {
+	return e.output
+}
+
+// Open opens the source to start emitting the decoded values.  Values
+// that do not match the item type are skipped, decoding stops at the
+// first malformed value, or read error, which is then returned by Err.
+func (e *JSONEmitter) Open(ctx context.Context) error {
+	e.log = autoctx.GetLogger(ctx)
+	util.Log(e.log, "opening json emitter node")
+	if err := e.setupSource(); err != nil {
+		return err
+	}
+	trace := tracing.NewRecorder(ctx, "json emitter")
+
+	go func() {
+		defer func() {
+			close(e.output)
+			if e.file != nil {
+				if err := e.file.Close(); err != nil {
+					util.Log(e.log, err)
+				}
+			}
+			util.Log(e.log, "json emitter closed")
+		}()
+
+		reader := bufio.NewReader(e.srcReader)
+		array, err := startsArray(reader)
+		if err != nil {
+			if err != io.EOF {
+				e.setErr(fmt.Errorf("Error reading json source: %w", err))
+				util.Log(e.log, e.Err())
+			}
+			return
+		}
+		decoder := json.NewDecoder(reader)
+		if array {
+			if _, err := decoder.Token(); err != nil {
+				e.setErr(fmt.Errorf("Error reading json array: %w", err))
+				util.Log(e.log, e.Err())
+				return
+			}
+		}
+
+		for !array || decoder.More() {
+			item, err := e.decode(decoder)
+			if err != nil {
+				if err == io.EOF {
+					return
+				}
+				var typeErr *json.UnmarshalTypeError
+				if errors.As(err, &typeErr) {
+					// the value was consumed, skip it
+					util.Log(e.log, fmt.Errorf("Error decoding value: %s", err))
+					continue
+				}
+				e.setErr(fmt.Errorf("Error reading json value: %w", err))
+				util.Log(e.log, e.Err())
+				return
+			}
+
+			if !trace.Emit(ctx, e.output, item) {
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// decode decodes the next value of decoder into a new item
+func (e *JSONEmitter) decode(decoder *json.Decoder) (interface{}, error) {
+	if e.itemType == nil {
+		var item map[string]interface{}
+		err := decoder.Decode(&item)
+		return item, err
+	}
+	ptr := reflect.New(e.itemType)
+	if err := decoder.Decode(ptr.Interface()); err != nil {
+		return nil, err
+	}
+	return ptr.Elem().Interface(), nil
+}
+
+func (e *JSONEmitter) setErr(err error) {
+	e.errMutex.Lock()
+	defer e.errMutex.Unlock()
+	e.err = err
+}
+
+// startsArray reports whether the first value of reader is a JSON array,
+// leading white spaces are discarded
+func startsArray(reader *bufio.Reader) (bool, error) {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return false, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b == '[', reader.UnreadByte()
+	}
+}
+
+func (e *JSONEmitter) setupSource() error {
+	switch src := e.srcParam.(type) {
+	case nil:
+		return errors.New("missing JSON source")
+	case string:
+		f, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		util.Log(e.log, "setting up file", f.Name(), "as json source")
+		e.srcReader = f
+		e.file = f // so we can close it
+	case *os.File:
+		util.Log(e.log, "using file", src.Name(), "as json source")
+		e.srcReader = src
+	case io.Reader:
+		util.Log(e.log, "using raw io.Reader as json source")
+		e.srcReader = src
+	default:
+		return errors.New("invalid JSON source")
+	}
+	return nil
+}
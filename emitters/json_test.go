@@ -0,0 +1,110 @@
+package emitters
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func collectJSON(t *testing.T, e *JSONEmitter) []interface{} {
+	t.Helper()
+	if err := e.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var items []interface{}
+	timeout := time.After(50 * time.Millisecond)
+	for {
+		select {
+		case item, ok := <-e.GetOutput():
+			if !ok {
+				return items
+			}
+			items = append(items, item)
+		case <-timeout:
+			t.Fatal("emitter took too long")
+		}
+	}
+}
+
+func TestJSONEmitter_NDJSON(t *testing.T) {
+	data := `{"name": "alpha", "qty": 1}
+{"name": "beta", "qty": 2}
+
+{"name": "gamma", "qty": 3}
+`
+	items := collectJSON(t, JSON(strings.NewReader(data)))
+	if len(items) != 3 {
+		t.Fatal("expecting 3 items, got ", items)
+	}
+	item, ok := items[1].(map[string]interface{})
+	if !ok || item["name"] != "beta" || item["qty"] != 2.0 {
+		t.Fatal("unexpected item ", items[1])
+	}
+}
+
+func TestJSONEmitter_Array(t *testing.T) {
+	type record struct {
+		Name string `json:"name"`
+		Qty  int    `json:"qty"`
+	}
+	data := ` [{"name": "alpha", "qty": 1}, {"name": "beta", "qty": "two"}, {"name": "gamma", "qty": 3}]`
+	items := collectJSON(t, JSON(strings.NewReader(data)).As(record{}))
+	if len(items) != 2 {
+		t.Fatal("expecting mistyped value to be skipped, got ", items)
+	}
+	if items[1] != (record{Name: "gamma", Qty: 3}) {
+		t.Fatal("unexpected item ", items[1])
+	}
+
+	items = collectJSON(t, JSON(strings.NewReader(data)).As(&record{}))
+	if r, ok := items[0].(*record); !ok || r.Name != "alpha" {
+		t.Fatal("expecting *record items, got ", items)
+	}
+}
+
+func TestJSONEmitter_Malformed(t *testing.T) {
+	e := JSON(strings.NewReader("{\"qty\": 1}\n{\"qty\": \n{\"qty\": 3}"))
+	items := collectJSON(t, e)
+	if len(items) != 1 {
+		t.Fatal("expecting decoding to stop at malformed value, got ", items)
+	}
+	if !errors.Is(e.Err(), io.ErrUnexpectedEOF) {
+		t.Fatal("expecting the malformed value to be reported, got ", e.Err())
+	}
+
+	e = JSON(strings.NewReader("[{\"qty\": 1}, {\"qty\" 2}]"))
+	if items := collectJSON(t, e); len(items) != 1 || e.Err() == nil {
+		t.Fatal("expecting decoding to stop at malformed element, got ", items, e.Err())
+	}
+
+	e = JSON(strings.NewReader(" \n"))
+	if items := collectJSON(t, e); len(items) != 0 || e.Err() != nil {
+		t.Fatal("expecting no items from empty source, got ", items, e.Err())
+	}
+}
+
+func TestJSONEmitter_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "items.ndjson")
+	if err := os.WriteFile(path, []byte("{\"qty\": 1}\n{\"qty\": 2}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := JSON(path)
+	if items := collectJSON(t, e); len(items) != 2 {
+		t.Fatal("expecting 2 items, got ", items)
+	}
+	if e.file == nil {
+		t.Fatal("expecting file to be opened")
+	}
+
+	if err := JSON(nil).Open(context.Background()); err == nil {
+		t.Fatal("expecting error for missing source")
+	}
+	if err := JSON(12).Open(context.Background()); err == nil {
+		t.Fatal("expecting error for invalid source")
+	}
+}
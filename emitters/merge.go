@@ -0,0 +1,104 @@
+package emitters
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/go-faces/logger"
+	"github.com/gofunky/automi/api"
+	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/api/tuple"
+	"github.com/gofunky/automi/tracing"
+	"github.com/gofunky/automi/util"
+)
+
+// MergeEmitter is an emitter that multiplexes the items of several
+// sources into a single stream.  Its output is closed once all of
+// its sources are done.
+type MergeEmitter struct {
+	sources []api.Source
+	tagged  bool
+	output  chan interface{}
+	log     logger.Interface
+}
+
+// Merge creates a new *MergeEmitter for the specified sources
+func Merge(sources ...api.Source) *MergeEmitter {
+	return &MergeEmitter{
+		sources: sources,
+		output:  make(chan interface{}, 1024),
+	}
+}
+
+// Tagged causes each item to be emitted as tuple.Tagged{origin, item}
+// where origin is the index of the item's source.
+func (e *MergeEmitter) Tagged() *MergeEmitter {
+	e.tagged = true
+	return e
+}
+
+// GetOutput returns the output channel of this source node
+func (e *MergeEmitter) GetOutput() <-chan interface{} {
+	return e.output
+}
+
+// Open opens all sources and starts emitting their items.  If a source
+// fails to open, the sources already opened are cancelled and drained.
+func (e *MergeEmitter) Open(ctx context.Context) error {
+	if len(e.sources) == 0 {
+		return errors.New("merge emitter missing sources")
+	}
+	for _, src := range e.sources {
+		if src == nil {
+			return errors.New("merge emitter has nil source")
+		}
+	}
+	e.log = autoctx.GetLogger(ctx)
+	util.Log(e.log, "opening merge emitter")
+
+	// the sources share a context that is cancelled once they are done
+	ctx, cancel := context.WithCancel(ctx)
+	for i, src := range e.sources {
+		if err := src.Open(ctx); err != nil {
+			cancel()
+			for _, opened := range e.sources[:i] {
+				go func(input <-chan interface{}) {
+					for range input {
+					}
+				}(opened.GetOutput())
+			}
+			return err
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(e.sources))
+	for i, src := range e.sources {
+		go func(origin int, input <-chan interface{}) {
+			defer wg.Done()
+			for item := range input {
+				if e.tagged {
+					// tag the item, keeping the span of its source
+					span, val := tracing.Unwrap(item)
+					item = tracing.Wrap(span, tuple.Tagged{origin, val})
+				}
+				select {
+				case e.output <- item:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(i, src.GetOutput())
+	}
+
+	go func() {
+		defer func() {
+			util.Log(e.log, "closing merge emitter")
+			close(e.output)
+			cancel()
+		}()
+		wg.Wait()
+	}()
+	return nil
+}
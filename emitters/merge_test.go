@@ -0,0 +1,128 @@
+package emitters
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofunky/automi/api/tuple"
+)
+
+func TestEmitter_Merge(t *testing.T) {
+	ch := make(chan string)
+	go func() {
+		ch <- "D"
+		ch <- "E"
+		close(ch)
+	}()
+	e := Merge(Slice([]string{"A", "B", "C"}), Chan(ch))
+
+	var m sync.Mutex
+	count := 0
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for range e.GetOutput() {
+			m.Lock()
+			count++
+			m.Unlock()
+		}
+	}()
+
+	if err := e.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-wait:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("waited too long")
+	}
+	m.Lock()
+	if count != 5 {
+		t.Fatal("unexpected item count ", count)
+	}
+	m.Unlock()
+}
+
+func TestEmitter_Merge_Tagged(t *testing.T) {
+	e := Merge(Slice([]string{"A", "B"}), Slice([]int{1, 2, 3})).Tagged()
+
+	var m sync.Mutex
+	origins := make(map[interface{}]int)
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for item := range e.GetOutput() {
+			tagged := item.(tuple.Tagged)
+			m.Lock()
+			origins[tagged[0]]++
+			m.Unlock()
+		}
+	}()
+
+	if err := e.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-wait:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("waited too long")
+	}
+	m.Lock()
+	if origins[0] != 2 || origins[1] != 3 {
+		t.Fatal("unexpected origins ", origins)
+	}
+	m.Unlock()
+}
+
+// openSource emits items until its context is done, or fails to open
+type openSource struct {
+	fail   bool
+	output chan interface{}
+	done   chan struct{}
+}
+
+func (s *openSource) GetOutput() <-chan interface{} {
+	return s.output
+}
+
+func (s *openSource) Open(ctx context.Context) error {
+	if s.fail {
+		return errors.New("open failed")
+	}
+	go func() {
+		defer close(s.done)
+		defer close(s.output)
+		for {
+			select {
+			case s.output <- 1:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func TestEmitter_Merge_OpenError(t *testing.T) {
+	opened := &openSource{output: make(chan interface{}), done: make(chan struct{})}
+	failing := &openSource{fail: true}
+	if err := Merge(opened, failing).Open(context.Background()); err == nil {
+		t.Fatal("expecting open error")
+	}
+	select {
+	case <-opened.done:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("expecting the opened source to be cancelled")
+	}
+}
+
+func TestEmitter_Merge_NoSource(t *testing.T) {
+	if err := Merge().Open(context.Background()); err == nil {
+		t.Fatal("expecting error for merge without sources")
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-faces/logger"
 	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/tracing"
 	"github.com/gofunky/automi/util"
 )
 
@@ -48,6 +49,7 @@ func (e *ReaderEmitter) Open(ctx context.Context) error {
 	// grab logger
 	e.log = autoctx.GetLogger(ctx)
 	util.Log(e.log, "opening io.Reader emitter")
+	trace := tracing.NewRecorder(ctx, "reader emitter")
 
 	go func() {
 		defer close(e.output)
@@ -57,9 +59,7 @@ func (e *ReaderEmitter) Open(ctx context.Context) error {
 			bytesRead, err := e.reader.Read(buf)
 
 			if bytesRead > 0 {
-				select {
-				case e.output <- buf[0:bytesRead]:
-				case <-ctx.Done():
+				if !trace.Emit(ctx, e.output, buf[0:bytesRead]) {
 					return
 				}
 			}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/go-faces/logger"
 	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/tracing"
 	"github.com/gofunky/automi/util"
 )
 
@@ -47,6 +48,7 @@ func (e *ScannerEmitter) Open(ctx context.Context) error {
 	}
 	e.log = autoctx.GetLogger(ctx)
 	util.Log(e.log, "opening Reader emitter")
+	trace := tracing.NewRecorder(ctx, "scanner emitter")
 
 	// use scanner to tokenize reader stream
 	// the text value of token is sent downstream
@@ -59,9 +61,7 @@ func (e *ScannerEmitter) Open(ctx context.Context) error {
 		for e.scanner.Scan() {
 			//TODO: handle scanner errors
 
-			select {
-			case e.output <- e.scanner.Text():
-			case <-ctx.Done():
+			if !trace.Emit(ctx, e.output, e.scanner.Text()) {
 				return
 			}
 		}
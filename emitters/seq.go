@@ -0,0 +1,107 @@
+package emitters
+
+import (
+	"context"
+	"errors"
+	"iter"
+
+	"github.com/go-faces/logger"
+	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/api/tuple"
+	"github.com/gofunky/automi/tracing"
+	"github.com/gofunky/automi/util"
+)
+
+// SeqEmitter is an emitter that emits the values
+// of an iterator, iter.Seq[T], as a stream.
+type SeqEmitter[T any] struct {
+	seq    iter.Seq[T]
+	output chan interface{}
+	log    logger.Interface
+}
+
+// Seq creates a new *SeqEmitter that emits the values of seq,
+// i.e. Seq(slices.Values(words)) or Seq(maps.Keys(index)).
+func Seq[T any](seq iter.Seq[T]) *SeqEmitter[T] {
+	return &SeqEmitter[T]{
+		seq:    seq,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// GetOutput returns the output channel of this source node
+func (s *SeqEmitter[T]) GetOutput() <-chan interface{} {
+	return s.output
+}
+
+// Open opens the source node to start streaming data on its channel.
+// The iteration stops when the context is done.
+func (s *SeqEmitter[T]) Open(ctx context.Context) error {
+	if s.seq == nil {
+		return errors.New("seq emitter missing iterator")
+	}
+	s.log = autoctx.GetLogger(ctx)
+	util.Log(s.log, "opening seq emitter")
+	trace := tracing.NewRecorder(ctx, "seq emitter")
+
+	go func() {
+		defer func() {
+			util.Log(s.log, "closing seq emitter")
+			close(s.output)
+		}()
+		for item := range s.seq {
+			if !trace.Emit(ctx, s.output, item) {
+				util.Log(s.log, "seq emitter cancelled")
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Seq2Emitter is an emitter that emits the pairs of an
+// iterator, iter.Seq2[K, V], as tuple.KV{key, value} items.
+type Seq2Emitter[K, V any] struct {
+	seq    iter.Seq2[K, V]
+	output chan interface{}
+	log    logger.Interface
+}
+
+// Seq2 creates a new *Seq2Emitter that emits the pairs of seq,
+// i.e. Seq2(maps.All(index)) or Seq2(slices.All(words)).
+func Seq2[K, V any](seq iter.Seq2[K, V]) *Seq2Emitter[K, V] {
+	return &Seq2Emitter[K, V]{
+		seq:    seq,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// GetOutput returns the output channel of this source node
+func (s *Seq2Emitter[K, V]) GetOutput() <-chan interface{} {
+	return s.output
+}
+
+// Open opens the source node to start streaming data on its channel.
+// The iteration stops when the context is done.
+func (s *Seq2Emitter[K, V]) Open(ctx context.Context) error {
+	if s.seq == nil {
+		return errors.New("seq2 emitter missing iterator")
+	}
+	s.log = autoctx.GetLogger(ctx)
+	util.Log(s.log, "opening seq2 emitter")
+	trace := tracing.NewRecorder(ctx, "seq2 emitter")
+
+	go func() {
+		defer func() {
+			util.Log(s.log, "closing seq2 emitter")
+			close(s.output)
+		}()
+		for key, value := range s.seq {
+			if !trace.Emit(ctx, s.output, tuple.KV{key, value}) {
+				util.Log(s.log, "seq2 emitter cancelled")
+				return
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,62 @@
+package emitters
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gofunky/automi/api/tuple"
+)
+
+func TestEmitter_Seq(t *testing.T) {
+	e := Seq(slices.Values([]string{"a", "b", "c"}))
+	if err := e.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var items []interface{}
+	for item := range e.GetOutput() {
+		items = append(items, item)
+	}
+	if len(items) != 3 || items[2] != "c" {
+		t.Fatal("unexpected items ", items)
+	}
+
+	if err := Seq[int](nil).Open(context.Background()); err == nil {
+		t.Fatal("expecting error for missing iterator")
+	}
+}
+
+func TestEmitter_Seq2(t *testing.T) {
+	e := Seq2(maps.All(map[string]int{"a": 1}))
+	if err := e.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	item := <-e.GetOutput()
+	if kv, ok := item.(tuple.KV); !ok || kv[0] != "a" || kv[1] != 1 {
+		t.Fatal("unexpected item ", item)
+	}
+	if _, ok := <-e.GetOutput(); ok {
+		t.Fatal("expecting output to be closed")
+	}
+}
+
+func TestEmitter_Seq_Cancel(t *testing.T) {
+	stopped := make(chan struct{})
+	naturals := func(yield func(int) bool) {
+		defer close(stopped)
+		for i := 0; yield(i); i++ {
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := Seq(naturals).Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("iteration not stopped")
+	}
+}
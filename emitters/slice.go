@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-faces/logger"
 	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/tracing"
 	"github.com/gofunky/automi/util"
 )
 
@@ -46,6 +47,8 @@ func (s *SliceEmitter) Open(ctx context.Context) error {
 		return errors.New("invalid slice for SliceEmitter")
 	}
 
+	trace := tracing.NewRecorder(ctx, "slice emitter")
+
 	go func() {
 		defer func() {
 			util.Log(s.log, "closing slice emitter")
@@ -53,7 +56,51 @@ func (s *SliceEmitter) Open(ctx context.Context) error {
 		}()
 		for i := 0; i < sliceVal.Len(); i++ {
 			val := sliceVal.Index(i)
-			s.output <- val.Interface()
+			if !trace.Emit(ctx, s.output, val.Interface()) {
+				util.Log(s.log, "slice emitter cancelled")
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// TypedSliceEmitter is a type-safe emitter that emits
+// the items of a slice []T individually as a stream.
+type TypedSliceEmitter[T any] struct {
+	slice  []T
+	output chan interface{}
+	log    logger.Interface
+}
+
+// SliceOf creates a new type-safe slice source
+func SliceOf[T any](slice []T) *TypedSliceEmitter[T] {
+	return &TypedSliceEmitter[T]{
+		slice:  slice,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// GetOutput returns the output channel of this source node
+func (s *TypedSliceEmitter[T]) GetOutput() <-chan interface{} {
+	return s.output
+}
+
+// Open opens the source node to start streaming data on its channel
+func (s *TypedSliceEmitter[T]) Open(ctx context.Context) error {
+	s.log = autoctx.GetLogger(ctx)
+	util.Log(s.log, "opening typed slice emitter")
+	trace := tracing.NewRecorder(ctx, "slice emitter")
+
+	go func() {
+		defer func() {
+			util.Log(s.log, "closing typed slice emitter")
+			close(s.output)
+		}()
+		for _, item := range s.slice {
+			if !trace.Emit(ctx, s.output, item) {
+				return
+			}
 		}
 	}()
 	return nil
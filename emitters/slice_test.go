@@ -36,3 +36,50 @@ func TestEmitter_Slice(t *testing.T) {
 	}
 	m.Unlock()
 }
+
+func TestEmitter_SliceOf(t *testing.T) {
+	s := SliceOf([]int{1, 2, 3, 4})
+	var m sync.Mutex
+	sum := 0
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for item := range s.GetOutput() {
+			m.Lock()
+			sum += item.(int)
+			m.Unlock()
+		}
+	}()
+
+	if err := s.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-wait:
+	case <-time.After(500 * time.Microsecond):
+		t.Fatal("waited too long")
+	}
+	m.Lock()
+	if sum != 10 {
+		t.Fatal("unexpected sum ", sum)
+	}
+	m.Unlock()
+}
+
+func TestEmitter_Slice_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	slice := Slice(make([]int, 2048))
+	if err := slice.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	count := 0
+	for range slice.GetOutput() {
+		count++
+	}
+	if count >= 2048 {
+		t.Fatal("expecting emitter to stop once cancelled")
+	}
+}
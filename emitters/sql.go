@@ -0,0 +1,186 @@
+package emitters
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/go-faces/logger"
+	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/tracing"
+	"github.com/gofunky/automi/util"
+)
+
+// Querier runs queries, it is implemented by *sql.DB, *sql.Conn and *sql.Tx
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// SQLEmitter implements an Emitter node that runs a query and emits
+// each of the resulting rows as a map[string]interface{}, which maps the
+// column names to the values of the row, or as a struct, see As.
+type SQLEmitter struct {
+	db       Querier
+	query    string
+	args     []interface{}
+	itemType reflect.Type
+
+	errMutex sync.Mutex
+	err      error
+	log      logger.Interface
+	output   chan interface{}
+}
+
+// SQL creates a new *SQLEmitter that runs query, with
+// the specified arguments, on db when it is opened.
+func SQL(db Querier, query string, args ...interface{}) *SQLEmitter {
+	return &SQLEmitter{
+		db:     db,
+		query:  query,
+		args:   args,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// As scans each row into a value of the struct type of prototype.
+// Fields are matched to columns by the name in their db tag, i.e.
+// `db:"born"`, or by their name, ignoring case.  Columns without a
+// field are discarded.  For instance, As(Person{}) emits Person values
+// and As(&Person{}) emits *Person values.
+func (e *SQLEmitter) As(prototype interface{}) *SQLEmitter {
+	e.itemType = reflect.TypeOf(prototype)
+	return e
+}
+
+// Err returns the error that stopped the emitter, if any
+func (e *SQLEmitter) Err() error {
+	e.errMutex.Lock()
+	defer e.errMutex.Unlock()
+	return e.err
+}
+
+// GetOutput returns the output channel of this source node
+func (e *SQLEmitter) GetOutput() <-chan interface{} {
+	return e.output
+}
+
+// Open runs the query and starts emitting the rows.  Query errors are
+// returned, errors raised while reading the rows stop the emitter and
+// are reported by Err.  Values of type []byte are emitted as strings
+// in maps.  The query is cancelled when the context is done.
+func (e *SQLEmitter) Open(ctx context.Context) error {
+	e.log = autoctx.GetLogger(ctx)
+	util.Log(e.log, "opening sql emitter node")
+
+	if e.db == nil {
+		return errors.New("sql emitter missing database")
+	}
+	structType := e.itemType
+	if structType != nil && structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType != nil && structType.Kind() != reflect.Struct {
+		return fmt.Errorf("sql emitter cannot scan rows into %s", e.itemType)
+	}
+
+	rows, err := e.db.QueryContext(ctx, e.query, e.args...)
+	if err != nil {
+		return err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		return err
+	}
+	var fields []util.StructField
+	if structType != nil {
+		fields = util.MatchFields(columns, util.StructFields(structType, "db"))
+	}
+	trace := tracing.NewRecorder(ctx, "sql emitter")
+
+	go func() {
+		defer func() {
+			if err := rows.Close(); err != nil {
+				util.Log(e.log, err)
+			}
+			close(e.output)
+			util.Log(e.log, "sql emitter closed")
+		}()
+
+		for rows.Next() {
+			var item interface{}
+			var err error
+			if fields != nil {
+				item, err = e.scanStruct(rows, fields)
+			} else {
+				item, err = scanMap(rows, columns)
+			}
+			if err != nil {
+				e.setErr(fmt.Errorf("Error scanning row: %w", err))
+				util.Log(e.log, e.Err())
+				return
+			}
+			if !trace.Emit(ctx, e.output, item) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil && ctx.Err() == nil {
+			e.setErr(fmt.Errorf("Error reading rows: %w", err))
+			util.Log(e.log, e.Err())
+		}
+	}()
+
+	return nil
+}
+
+// scanMap scans the current row into a map keyed by column name
+func scanMap(rows *sql.Rows, columns []string) (interface{}, error) {
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	item := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		if b, ok := values[i].([]byte); ok {
+			item[column] = string(b)
+			continue
+		}
+		item[column] = values[i]
+	}
+	return item, nil
+}
+
+// scanStruct scans the current row into the fields of a new item
+func (e *SQLEmitter) scanStruct(rows *sql.Rows, fields []util.StructField) (interface{}, error) {
+	ptr := reflect.New(e.itemType)
+	val := ptr.Elem()
+	if e.itemType.Kind() == reflect.Ptr {
+		val.Set(reflect.New(e.itemType.Elem()))
+		val = val.Elem()
+	}
+	dest := make([]interface{}, len(fields))
+	for i, field := range fields {
+		if field.Index == nil {
+			dest[i] = new(interface{})
+			continue
+		}
+		dest[i] = val.FieldByIndex(field.Index).Addr().Interface()
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return ptr.Elem().Interface(), nil
+}
+
+func (e *SQLEmitter) setErr(err error) {
+	e.errMutex.Lock()
+	defer e.errMutex.Unlock()
+	e.err = err
+}
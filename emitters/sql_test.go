@@ -0,0 +1,105 @@
+package emitters
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofunky/automi/testutil"
+)
+
+func planets() map[string]*testutil.FakeTable {
+	return map[string]*testutil.FakeTable{
+		"planets": {
+			Columns: []string{"name", "diameter", "moons"},
+			Rows: [][]interface{}{
+				{"Mercury", 4879.0, int64(0)},
+				{[]byte("Mars"), 6779.5, int64(2)},
+			},
+		},
+	}
+}
+
+func collectSQL(t *testing.T, e *SQLEmitter) []interface{} {
+	t.Helper()
+	if err := e.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var items []interface{}
+	timeout := time.After(50 * time.Millisecond)
+	for {
+		select {
+		case item, ok := <-e.GetOutput():
+			if !ok {
+				return items
+			}
+			items = append(items, item)
+		case <-timeout:
+			t.Fatal("emitter took too long")
+		}
+	}
+}
+
+func TestSQLEmitter_Maps(t *testing.T) {
+	_, db := testutil.NewFakeDB(planets())
+	defer db.Close()
+
+	e := SQL(db, "SELECT * FROM planets")
+	items := collectSQL(t, e)
+	if len(items) != 2 {
+		t.Fatal("expecting 2 items, got ", items)
+	}
+	mars, ok := items[1].(map[string]interface{})
+	if !ok || mars["name"] != "Mars" || mars["diameter"] != 6779.5 || mars["moons"] != int64(2) {
+		t.Fatal("unexpected item ", items[1])
+	}
+	if e.Err() != nil {
+		t.Fatal(e.Err())
+	}
+}
+
+func TestSQLEmitter_Structs(t *testing.T) {
+	type planet struct {
+		Name  string
+		Moons int    `db:"moons"`
+		Notes string `db:"-"`
+	}
+	_, db := testutil.NewFakeDB(planets())
+	defer db.Close()
+
+	items := collectSQL(t, SQL(db, "SELECT * FROM planets").As(planet{}))
+	if len(items) != 2 || items[1] != (planet{Name: "Mars", Moons: 2}) {
+		t.Fatal("unexpected items ", items)
+	}
+	items = collectSQL(t, SQL(db, "SELECT * FROM planets").As(&planet{}))
+	if p, ok := items[0].(*planet); !ok || p.Name != "Mercury" {
+		t.Fatal("expecting *planet items, got ", items)
+	}
+
+	if err := SQL(db, "SELECT * FROM planets").As(12).Open(context.Background()); err == nil {
+		t.Fatal("expecting error for non struct type")
+	}
+}
+
+func TestSQLEmitter_Errors(t *testing.T) {
+	tables := planets()
+	tables["planets"].Rows = append(tables["planets"].Rows, []interface{}{"Pluto", errors.New("demoted"), nil})
+	_, db := testutil.NewFakeDB(tables)
+	defer db.Close()
+
+	if err := SQL(db, "SELECT * FROM moons").Open(context.Background()); err == nil {
+		t.Fatal("expecting query error")
+	}
+	if err := SQL(nil, "SELECT * FROM planets").Open(context.Background()); err == nil {
+		t.Fatal("expecting error for missing database")
+	}
+
+	e := SQL(db, "SELECT * FROM planets")
+	if items := collectSQL(t, e); len(items) != 2 {
+		t.Fatal("expecting rows before the failed row, got ", items)
+	}
+	if err := e.Err(); err == nil {
+		t.Fatal("expecting row error")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
 
 	"github.com/gofunky/automi/collectors"
 	"github.com/gofunky/automi/stream"
@@ -50,10 +51,11 @@ func main() {
 		return val
 	})
 
+	// hash the files concurrently, in the order of their paths
 	stream.Map(func(filePath string) [3]interface{} {
 		data, err := ioutil.ReadFile(filePath)
 		return [3]interface{}{filePath, md5.Sum(data), err}
-	})
+	}).Parallel(runtime.NumCPU())
 
 	// sink the result
 	stream.Into(collectors.Func(func(items interface{}) error {
@@ -0,0 +1,314 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/gofunky/automi/util"
+)
+
+// node is a node of the syntax tree of an expression
+type node interface {
+	eval(item interface{}) (interface{}, error)
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (n *literal) eval(interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+// item is the evaluated item itself
+type item struct{}
+
+func (n *item) eval(it interface{}) (interface{}, error) {
+	return it, nil
+}
+
+// field selects a struct field, or a map key, by name
+type field struct {
+	target node
+	name   string
+}
+
+func (n *field) eval(it interface{}) (interface{}, error) {
+	target, err := n.target.eval(it)
+	if err != nil {
+		return nil, err
+	}
+	val := reflect.ValueOf(target)
+	for val.IsValid() && (val.Kind() == reflect.Pointer || val.Kind() == reflect.Interface) && !val.IsNil() {
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.Map:
+		return value(util.ValueAtKey(val, n.name)), nil
+	case reflect.Struct:
+		if f := util.FieldByName(val, n.name); f.IsValid() {
+			return value(f), nil
+		}
+		return nil, fmt.Errorf("%s has no field %s", val.Type(), n.name)
+	}
+	return nil, fmt.Errorf("cannot select field %s of %T", n.name, target)
+}
+
+// lookup selects an item of a slice, array or string by position,
+// or a map value by key
+type lookup struct {
+	target node
+	index  node
+}
+
+func (n *lookup) eval(it interface{}) (interface{}, error) {
+	target, err := n.target.eval(it)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.eval(it)
+	if err != nil {
+		return nil, err
+	}
+	val := reflect.ValueOf(target)
+	for val.IsValid() && (val.Kind() == reflect.Pointer || val.Kind() == reflect.Interface) && !val.IsNil() {
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.Map:
+		return value(util.ValueAtKey(val, index)), nil
+	case reflect.Struct:
+		if name, ok := index.(string); ok {
+			return (&field{target: &literal{value: target}, name: name}).eval(it)
+		}
+	case reflect.Slice, reflect.Array, reflect.String:
+		pos, ok := toNumber(index)
+		if !ok || pos != math.Trunc(pos) {
+			return nil, fmt.Errorf("invalid position %v", index)
+		}
+		elem := util.ValueAtPos(val, int(pos))
+		if !elem.IsValid() {
+			return nil, fmt.Errorf("position %d out of range [0:%d]", int(pos), val.Len())
+		}
+		if val.Kind() == reflect.String {
+			return string(rune(elem.Uint())), nil
+		}
+		return value(elem), nil
+	}
+	return nil, fmt.Errorf("cannot index %T with %v", target, index)
+}
+
+type negation struct {
+	op      string
+	operand node
+}
+
+func (n *negation) eval(it interface{}) (interface{}, error) {
+	operand, err := n.operand.eval(it)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		b, ok := operand.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator ! requires a bool, got %T", operand)
+		}
+		return !b, nil
+	}
+	num, ok := toNumber(operand)
+	if !ok {
+		return nil, fmt.Errorf("operator - requires a number, got %T", operand)
+	}
+	return -num, nil
+}
+
+// logical evaluates && and || lazily
+type logical struct {
+	op          string
+	left, right node
+}
+
+func (n *logical) eval(it interface{}) (interface{}, error) {
+	left, err := evalBool(n.left, it, n.op)
+	if err != nil {
+		return nil, err
+	}
+	if (n.op == "&&" && !left) || (n.op == "||" && left) {
+		return left, nil
+	}
+	return evalBool(n.right, it, n.op)
+}
+
+func evalBool(n node, it interface{}, op string) (bool, error) {
+	val, err := n.eval(it)
+	if err != nil {
+		return false, err
+	}
+	b, ok := val.(bool)
+	if !ok {
+		return false, fmt.Errorf("operator %s requires bools, got %T", op, val)
+	}
+	return b, nil
+}
+
+type comparison struct {
+	op          string
+	left, right node
+}
+
+func (n *comparison) eval(it interface{}) (interface{}, error) {
+	left, err := n.left.eval(it)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(it)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	}
+
+	var cmp int
+	x, xok := toNumber(left)
+	y, yok := toNumber(right)
+	sx, sxok := left.(string)
+	sy, syok := right.(string)
+	switch {
+	case xok && yok:
+		cmp = compareNumbers(x, y)
+	case sxok && syok:
+		cmp = strings.Compare(sx, sy)
+	default:
+		return nil, fmt.Errorf("cannot compare %T and %T", left, right)
+	}
+	switch n.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	}
+	return cmp >= 0, nil
+}
+
+type arithmetic struct {
+	op          string
+	left, right node
+}
+
+func (n *arithmetic) eval(it interface{}) (interface{}, error) {
+	left, err := n.left.eval(it)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(it)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "+" {
+		sx, sxok := left.(string)
+		sy, syok := right.(string)
+		if sxok && syok {
+			return sx + sy, nil
+		}
+	}
+	x, xok := toNumber(left)
+	y, yok := toNumber(right)
+	if !xok || !yok {
+		return nil, fmt.Errorf("operator %s cannot be applied to %T and %T", n.op, left, right)
+	}
+	switch n.op {
+	case "+":
+		return x + y, nil
+	case "-":
+		return x - y, nil
+	case "*":
+		return x * y, nil
+	case "/":
+		if y == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return x / y, nil
+	}
+	if y == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+	return math.Mod(x, y), nil
+}
+
+type call struct {
+	name string
+	fn   func(args []interface{}) (interface{}, error)
+	args []node
+}
+
+func (n *call) eval(it interface{}) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		val, err := arg.eval(it)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = val
+	}
+	result, err := n.fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return result, nil
+}
+
+// value returns the interface held by val, or nil if val is invalid
+func value(val reflect.Value) interface{} {
+	if !val.IsValid() || !val.CanInterface() {
+		return nil
+	}
+	return val.Interface()
+}
+
+// toNumber returns num as a float64 if it is a number
+func toNumber(num interface{}) (float64, bool) {
+	switch n := num.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case nil, bool, string:
+		return 0, false
+	}
+	val := reflect.ValueOf(num)
+	if util.IsNumericValue(val) {
+		return util.ValueAsFloat(val), true
+	}
+	return 0, false
+}
+
+// equal compares numbers by value, whatever their types
+func equal(x, y interface{}) bool {
+	nx, xok := toNumber(x)
+	ny, yok := toNumber(y)
+	if xok && yok {
+		return nx == ny
+	}
+	if xok != yok {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+func compareNumbers(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
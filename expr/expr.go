@@ -0,0 +1,112 @@
+// Package expr compiles small expressions, evaluated against stream
+// items, into operations for the Filter and Map steps of a stream:
+//
+//	op, err := expr.Filter(`Amount >= 100 && hasPrefix(upper(Region), "EU")`)
+//	...
+//	strm.Transform(op)
+//
+// Expressions address the item as follows:
+//
+//	$          the item itself
+//	$N         the item at position N of a slice or an array, i.e. a CSV row
+//	name       the field name of a struct, or the value of key name of a map
+//	x.name     the field name, or key name, of x
+//	x[i]       the item at position i of x, or the value of key i of map x
+//
+// As the batch functions do, struct fields are selected by their exported
+// name, so that amount selects the field Amount.  Expressions support the
+// literals true, false, nil, numbers and quoted strings, the operators
+// ||, &&, !, ==, !=, <, <=, >, >=, +, -, *, /, % and the functions:
+//
+//	len(x), upper(s), lower(s), trim(s), contains(s, sub), hasPrefix(s, prefix),
+//	hasSuffix(s, suffix), matches(s, regexp), replace(s, old, new), split(s, sep),
+//	substr(s, start[, end]), concat(x...), string(x), number(x)
+//
+// Numbers are evaluated as float64 values, + also concatenates strings.
+// Expressions have no side effects, the errors of an evaluation, such as a
+// missing field, are returned by the operations.
+package expr
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofunky/automi/api"
+)
+
+// Expr is a compiled expression, it is safe for concurrent use
+type Expr struct {
+	src  string
+	root node
+}
+
+// Compile compiles the expression src
+func Compile(src string) (*Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, fmt.Errorf("expr %q: %w", src, err)
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("expr %q: %w", src, err)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// MustCompile is like Compile but panics if src cannot be compiled
+func MustCompile(src string) *Expr {
+	e, err := Compile(src)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// String returns the source of the expression
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval evaluates the expression against item
+func (e *Expr) Eval(item interface{}) (interface{}, error) {
+	result, err := e.root.eval(item)
+	if err != nil {
+		return nil, fmt.Errorf("expr %q: %w", e.src, err)
+	}
+	return result, nil
+}
+
+// Apply implements api.UnOperation, it returns the value of the expression
+func (e *Expr) Apply(ctx context.Context, item interface{}) (interface{}, error) {
+	return e.Eval(item)
+}
+
+// Map compiles src into an api.UnOperation that maps
+// each item to the value of the expression
+func Map(src string) (api.UnOperation, error) {
+	return Compile(src)
+}
+
+// Filter compiles src into an api.UnOperation that keeps the items for
+// which the expression is true.  The expression must evaluate to a bool.
+func Filter(src string) (api.UnOperation, error) {
+	e, err := Compile(src)
+	if err != nil {
+		return nil, err
+	}
+	return api.UnFunc(func(ctx context.Context, item interface{}) (interface{}, error) {
+		result, err := e.Eval(item)
+		if err != nil {
+			return nil, err
+		}
+		keep, ok := result.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expr %q: filter must evaluate to a bool, got %T", e.src, result)
+		}
+		if !keep {
+			return nil, nil
+		}
+		return item, nil
+	}), nil
+}
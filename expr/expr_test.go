@@ -0,0 +1,136 @@
+package expr
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type order struct {
+	ID     int
+	Region string
+	Amount float64
+	Tags   []string
+	Meta   map[string]interface{}
+}
+
+func TestExpr_Eval(t *testing.T) {
+	o := order{ID: 7, Region: "eu-west", Amount: 120.5, Tags: []string{"a", "b"}, Meta: map[string]interface{}{"prio": 2}}
+	row := []string{"alpha", "east", "10"}
+	m := map[string]interface{}{"name": "beta", "qty": 3, "nested": map[string]int{"x": 1}}
+	intKeys := map[int]string{1: "one"}
+
+	tests := []struct {
+		src      string
+		item     interface{}
+		expected interface{}
+	}{
+		{"1 + 2 * 3", nil, 7.0},
+		{"(1 + 2) * 3", nil, 9.0},
+		{"7 % 4 - -1", nil, 4.0},
+		{"'a' + \"b\"", nil, "ab"},
+		{"!true || false && true", nil, false},
+		{"$", 5, 5},
+		{"$1", row, "east"},
+		{"number($2) * 2", row, 20.0},
+		{"$0 == 'alpha' && $2 != '11'", row, true},
+		{"amount > 100", o, true},
+		{"Amount >= 120.5 && ID == 7", o, true},
+		{"hasPrefix(upper(region), 'EU')", o, true},
+		{"tags[1]", o, "b"},
+		{"len(tags)", o, 2.0},
+		{"meta.prio == 2", o, true},
+		{"meta.missing == nil", o, true},
+		{"name", m, "beta"},
+		{"qty + 1", m, 4.0},
+		{"nested.x", m, 1},
+		{"$['name']", m, "beta"},
+		{"$[1]", intKeys, "one"},
+		{"$['Region']", o, "eu-west"},
+		{"contains(trim('  abc '), 'b')", nil, true},
+		{"matches($0, '^a.+a$')", row, true},
+		{"replace('a-b-c', '-', '+')", nil, "a+b+c"},
+		{"split('a,b', ',')[1]", nil, "b"},
+		{"substr('hello', 1, 3)", nil, "el"},
+		{"concat($0, ':', 1.5)", row, "alpha:1.5"},
+		{"string(2) + lower('X')", nil, "2x"},
+		{"'b' > 'a'", nil, true},
+		{"'abc'[1]", nil, "b"},
+	}
+	for _, test := range tests {
+		e, err := Compile(test.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := e.Eval(test.item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != test.expected {
+			t.Errorf("%s: expecting %v (%T), got %v (%T)", test.src, test.expected, test.expected, result, result)
+		}
+	}
+}
+
+func TestExpr_CompileErrors(t *testing.T) {
+	for _, src := range []string{"", "1 +", "(1", "a.", "foo(1)", "len()", "'abc", "1 # 2", "a b", "$[1"} {
+		if _, err := Compile(src); err == nil {
+			t.Errorf("%q: expecting compile error", src)
+		}
+	}
+}
+
+func TestExpr_EvalErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		item interface{}
+	}{
+		{"missing", order{}},
+		{"$5", []string{"a"}},
+		{"1 / 0", nil},
+		{"'a' - 1", nil},
+		{"!1", nil},
+		{"1 && true", nil},
+		{"'a' < 1", nil},
+		{"upper(1)", nil},
+		{"name", 1},
+		{"number('x')", nil},
+	}
+	for _, test := range tests {
+		_, err := MustCompile(test.src).Eval(test.item)
+		if err == nil {
+			t.Errorf("%q: expecting eval error", test.src)
+		} else if !strings.Contains(err.Error(), test.src) {
+			t.Errorf("%q: expecting source in error, got %v", test.src, err)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	op, err := Filter("$1 > 5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, err := op.Apply(context.TODO(), []int{1, 10}); err != nil || result == nil {
+		t.Fatal("expecting item to be kept, got ", result, err)
+	}
+	if result, err := op.Apply(context.TODO(), []int{1, 2}); err != nil || result != nil {
+		t.Fatal("expecting item to be filtered out, got ", result, err)
+	}
+
+	op, _ = Filter("$1")
+	if _, err := op.Apply(context.TODO(), []int{1, 2}); err == nil {
+		t.Fatal("expecting error for non bool filter")
+	}
+}
+
+func TestMap(t *testing.T) {
+	op, err := Map("upper($0) + '!'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := op.Apply(context.TODO(), []string{"go"})
+	if err != nil || result != "GO!" {
+		t.Fatal("unexpected result ", result, err)
+	}
+}
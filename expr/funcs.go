@@ -0,0 +1,165 @@
+package expr
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type function struct {
+	minArgs, maxArgs int // maxArgs is -1 for variadic functions
+	call             func(args []interface{}) (interface{}, error)
+}
+
+// functions are the functions that expressions may call
+var functions = map[string]function{
+	"len": {1, 1, func(args []interface{}) (interface{}, error) {
+		val := reflect.ValueOf(args[0])
+		switch val.Kind() {
+		case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+			return float64(val.Len()), nil
+		}
+		return nil, fmt.Errorf("unexpected type %T", args[0])
+	}},
+	"upper":     stringFunc(strings.ToUpper),
+	"lower":     stringFunc(strings.ToLower),
+	"trim":      stringFunc(strings.TrimSpace),
+	"contains":  stringPredicate(strings.Contains),
+	"hasPrefix": stringPredicate(strings.HasPrefix),
+	"hasSuffix": stringPredicate(strings.HasSuffix),
+	"matches": {2, 2, func(args []interface{}) (interface{}, error) {
+		s, pattern, err := twoStrings(args)
+		if err != nil {
+			return nil, err
+		}
+		re, err := compileRegexp(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString(s), nil
+	}},
+	"replace": {3, 3, func(args []interface{}) (interface{}, error) {
+		strs, err := stringArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return strings.ReplaceAll(strs[0], strs[1], strs[2]), nil
+	}},
+	"split": {2, 2, func(args []interface{}) (interface{}, error) {
+		s, sep, err := twoStrings(args)
+		if err != nil {
+			return nil, err
+		}
+		return strings.Split(s, sep), nil
+	}},
+	"substr": {2, 3, func(args []interface{}) (interface{}, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("expecting a string, got %T", args[0])
+		}
+		runes := []rune(s)
+		start, ok := toNumber(args[1])
+		end := float64(len(runes))
+		if len(args) > 2 {
+			var endOk bool
+			end, endOk = toNumber(args[2])
+			ok = ok && endOk
+		}
+		if !ok || start < 0 || end > float64(len(runes)) || start > end {
+			return nil, fmt.Errorf("invalid range for string of length %d", len(runes))
+		}
+		return string(runes[int(start):int(end)]), nil
+	}},
+	"concat": {0, -1, func(args []interface{}) (interface{}, error) {
+		var sb strings.Builder
+		for _, arg := range args {
+			sb.WriteString(toString(arg))
+		}
+		return sb.String(), nil
+	}},
+	"string": {1, 1, func(args []interface{}) (interface{}, error) {
+		return toString(args[0]), nil
+	}},
+	"number": {1, 1, func(args []interface{}) (interface{}, error) {
+		if num, ok := toNumber(args[0]); ok {
+			return num, nil
+		}
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot convert %T to a number", args[0])
+		}
+		return strconv.ParseFloat(strings.TrimSpace(s), 64)
+	}},
+}
+
+func stringFunc(f func(string) string) function {
+	return function{1, 1, func(args []interface{}) (interface{}, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("expecting a string, got %T", args[0])
+		}
+		return f(s), nil
+	}}
+}
+
+func stringPredicate(f func(s, sub string) bool) function {
+	return function{2, 2, func(args []interface{}) (interface{}, error) {
+		s, sub, err := twoStrings(args)
+		if err != nil {
+			return nil, err
+		}
+		return f(s, sub), nil
+	}}
+}
+
+func twoStrings(args []interface{}) (string, string, error) {
+	strs, err := stringArgs(args)
+	if err != nil {
+		return "", "", err
+	}
+	return strs[0], strs[1], nil
+}
+
+// stringArgs asserts that args are strings
+func stringArgs(args []interface{}) ([]string, error) {
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		s, ok := arg.(string)
+		if !ok {
+			return nil, fmt.Errorf("expecting a string, got %T", arg)
+		}
+		strs[i] = s
+	}
+	return strs, nil
+}
+
+// toString formats numbers without trailing zeros
+func toString(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(val)
+}
+
+// regexps caches the compiled patterns of matches
+var regexps sync.Map
+
+func compileRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexps.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexps.Store(pattern, re)
+	return re, nil
+}
@@ -0,0 +1,283 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// parser is a recursive descent parser, from the lowest precedence:
+//
+//	or      = and { "||" and }
+//	and     = compare { "&&" compare }
+//	compare = sum [ ("==" | "!=" | "<" | "<=" | ">" | ">=") sum ]
+//	sum     = product { ("+" | "-") product }
+//	product = unary { ("*" | "/" | "%") unary }
+//	unary   = ("!" | "-") unary | postfix
+//	postfix = primary { "." ident | "[" or "]" }
+//	primary = number | string | true | false | nil | "$" | "$N"
+//	        | ident "(" [ or { "," or } ] ")" | ident | "(" or ")"
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// back steps back over tok, returned by next
+func (p *parser) back(tok token) {
+	if tok.kind != tokEOF {
+		p.pos--
+	}
+}
+
+// accept consumes the next token if it is one of the operators ops
+func (p *parser) accept(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		return p.errorf("expecting %q", op)
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	tok := p.peek()
+	found := tok.text
+	if tok.kind == tokEOF {
+		found = "end of expression"
+	}
+	return fmt.Errorf("%s at %d, found %s", fmt.Sprintf(format, args...), tok.pos, found)
+}
+
+func (p *parser) parse() (node, error) {
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, p.errorf("unexpected token")
+	}
+	return n, nil
+}
+
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("||"); !ok {
+			return left, nil
+		}
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = &logical{op: "||", left: left, right: right}
+	}
+}
+
+func (p *parser) and() (node, error) {
+	left, err := p.compare()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("&&"); !ok {
+			return left, nil
+		}
+		right, err := p.compare()
+		if err != nil {
+			return nil, err
+		}
+		left = &logical{op: "&&", left: left, right: right}
+	}
+}
+
+func (p *parser) compare() (node, error) {
+	left, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("==", "!=", "<", "<=", ">", ">=")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	return &comparison{op: op, left: left, right: right}, nil
+}
+
+func (p *parser) sum() (node, error) {
+	left, err := p.product()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.product()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmetic{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) product() (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("*", "/", "%")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmetic{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	if op, ok := p.accept("!", "-"); ok {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &negation{op: op, operand: operand}, nil
+	}
+	return p.postfix()
+}
+
+func (p *parser) postfix() (node, error) {
+	n, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("."); ok {
+			tok := p.next()
+			if tok.kind != tokIdent {
+				p.back(tok)
+				return nil, p.errorf("expecting field name")
+			}
+			n = &field{target: n, name: tok.text}
+			continue
+		}
+		if _, ok := p.accept("["); ok {
+			index, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			n = &lookup{target: n, index: index}
+			continue
+		}
+		return n, nil
+	}
+}
+
+func (p *parser) primary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		return &literal{value: tok.num}, nil
+	case tokString:
+		return &literal{value: tok.text}, nil
+	case tokItem:
+		if tok.text == "$" {
+			return &item{}, nil
+		}
+		pos, err := strconv.Atoi(tok.text[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid position %s at %d", tok.text, tok.pos)
+		}
+		return &lookup{target: &item{}, index: &literal{value: float64(pos)}}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return &literal{value: true}, nil
+		case "false":
+			return &literal{value: false}, nil
+		case "nil":
+			return &literal{value: nil}, nil
+		}
+		if _, ok := p.accept("("); ok {
+			return p.call(tok)
+		}
+		return &field{target: &item{}, name: tok.text}, nil
+	case tokOp:
+		if tok.text == "(" {
+			n, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return n, nil
+		}
+	}
+	p.back(tok)
+	return nil, p.errorf("unexpected token")
+}
+
+func (p *parser) call(name token) (node, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s at %d", name.text, name.pos)
+	}
+	var args []node
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if _, ok := p.accept(","); ok {
+				continue
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, fmt.Errorf("wrong number of arguments for %s at %d", name.text, name.pos)
+	}
+	return &call{name: name.text, fn: fn.call, args: args}, nil
+}
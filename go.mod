@@ -7,6 +7,7 @@ require (
 	github.com/go-faces/logger v0.0.0-20180617163310-c221c1151623
 	github.com/gofunky/pyraset v0.0.0-20190201174058-c5e2af1b9163
 	github.com/gofunky/pyraset/v2 v2.0.3
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds of the latency
+// histogram buckets used by NewMemory by default.
+var DefaultBuckets = []time.Duration{
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+}
+
+// Histogram counts latencies into buckets.  Counts[i] is the number of
+// latencies lower or equal to Bounds[i], the counts are cumulative.
+type Histogram struct {
+	Bounds []time.Duration
+	Counts []uint64
+	Count  uint64
+	Sum    time.Duration
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	for i, bound := range h.Bounds {
+		if d <= bound {
+			h.Counts[i]++
+		}
+	}
+	h.Count++
+	h.Sum += d
+}
+
+// NodeStats holds the metrics recorded for a node
+type NodeStats struct {
+	In        uint64
+	Out       uint64
+	Errors    uint64
+	Latency   Histogram
+	Occupancy int
+	Capacity  int
+}
+
+// Memory is an in-memory api.Metrics.  It is safe for concurrent use.
+type Memory struct {
+	mutex   sync.Mutex
+	buckets []time.Duration
+	nodes   map[string]*NodeStats
+}
+
+// NewMemory creates a new *Memory value that counts latencies using
+// the specified histogram bucket bounds, or DefaultBuckets if none.
+func NewMemory(buckets ...time.Duration) *Memory {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	bounds := append([]time.Duration(nil), buckets...)
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+	return &Memory{buckets: bounds, nodes: make(map[string]*NodeStats)}
+}
+
+// node returns the stats of the named node, the mutex must be held
+func (m *Memory) node(name string) *NodeStats {
+	stats, ok := m.nodes[name]
+	if !ok {
+		stats = &NodeStats{Latency: Histogram{
+			Bounds: m.buckets,
+			Counts: make([]uint64, len(m.buckets)),
+		}}
+		m.nodes[name] = stats
+	}
+	return stats
+}
+
+// ItemIn records an item received by the node
+func (m *Memory) ItemIn(node string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.node(node).In++
+}
+
+// ItemOut records an item sent downstream by the node
+func (m *Memory) ItemOut(node string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.node(node).Out++
+}
+
+// Error records an item whose processing failed
+func (m *Memory) Error(node string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.node(node).Errors++
+}
+
+// Latency records the time taken to process an item
+func (m *Memory) Latency(node string, d time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.node(node).Latency.observe(d)
+}
+
+// Occupancy records the number of items buffered in the output channel
+func (m *Memory) Occupancy(node string, length, capacity int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	stats := m.node(node)
+	stats.Occupancy = length
+	stats.Capacity = capacity
+}
+
+// Get returns a copy of the stats recorded for node
+func (m *Memory) Get(node string) NodeStats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	stats, ok := m.nodes[node]
+	if !ok {
+		return NodeStats{}
+	}
+	return stats.copy()
+}
+
+// Snapshot returns a copy of the stats of all nodes keyed by node name
+func (m *Memory) Snapshot() map[string]NodeStats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	snapshot := make(map[string]NodeStats, len(m.nodes))
+	for name, stats := range m.nodes {
+		snapshot[name] = stats.copy()
+	}
+	return snapshot
+}
+
+func (s *NodeStats) copy() NodeStats {
+	c := *s
+	c.Latency.Counts = append([]uint64(nil), s.Latency.Counts...)
+	return c
+}
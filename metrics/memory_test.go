@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemory_Counters(t *testing.T) {
+	m := NewMemory()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.ItemIn("map")
+			m.ItemOut("map")
+		}()
+	}
+	wg.Wait()
+	m.Error("map")
+	m.Occupancy("map", 3, 1024)
+
+	stats := m.Get("map")
+	if stats.In != 10 || stats.Out != 10 || stats.Errors != 1 {
+		t.Fatal("unexpected counters ", stats)
+	}
+	if stats.Occupancy != 3 || stats.Capacity != 1024 {
+		t.Fatal("unexpected occupancy ", stats.Occupancy, stats.Capacity)
+	}
+	if stats := m.Get("filter"); stats.In != 0 {
+		t.Fatal("expecting empty stats for unknown node, got ", stats)
+	}
+}
+
+func TestMemory_Latency(t *testing.T) {
+	m := NewMemory(time.Second, time.Millisecond)
+	m.Latency("map", 500*time.Microsecond)
+	m.Latency("map", 5*time.Millisecond)
+	m.Latency("map", 2*time.Second)
+
+	h := m.Get("map").Latency
+	if h.Bounds[0] != time.Millisecond || h.Bounds[1] != time.Second {
+		t.Fatal("expecting sorted bounds, got ", h.Bounds)
+	}
+	if h.Counts[0] != 1 || h.Counts[1] != 2 {
+		t.Fatal("expecting cumulative counts, got ", h.Counts)
+	}
+	if h.Count != 3 || h.Sum != 2*time.Second+5500*time.Microsecond {
+		t.Fatal("unexpected count or sum ", h.Count, h.Sum)
+	}
+}
+
+func TestMemory_Snapshot(t *testing.T) {
+	m := NewMemory()
+	m.Latency("map", time.Millisecond)
+	m.ItemIn("filter")
+
+	snapshot := m.Snapshot()
+	if len(snapshot) != 2 {
+		t.Fatal("expecting 2 nodes, got ", len(snapshot))
+	}
+	m.Latency("map", time.Millisecond)
+	if snapshot["map"].Latency.Counts[2] != 1 {
+		t.Fatal("expecting snapshot to be a copy")
+	}
+}
+
+func TestRecorder_Nil(t *testing.T) {
+	var r *Recorder
+	r.In()
+	r.Out(make(chan interface{}, 1))
+	r.Error()
+	r.Since(r.Now())
+	if !r.Now().IsZero() {
+		t.Fatal("expecting zero time from nil recorder")
+	}
+}
@@ -0,0 +1,97 @@
+// Package metrics records the activity of stream operators.  Metrics are
+// turned on by providing an api.Metrics in the stream context:
+//
+//	m := metrics.NewMemory()
+//	strm := stream.New(src).WithContext(metrics.WithMetrics(ctx, m))
+//	http.Handle("/metrics", metrics.Handler(m))
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofunky/automi/api"
+	autoctx "github.com/gofunky/automi/api/context"
+)
+
+type ctxKey int
+
+const metricsKey ctxKey = 1
+
+// WithMetrics sets the api.Metrics used by the nodes created with ctx
+func WithMetrics(ctx context.Context, m api.Metrics) context.Context {
+	return context.WithValue(ctx, metricsKey, m)
+}
+
+// GetMetrics returns the api.Metrics of the context, if any
+func GetMetrics(ctx context.Context) api.Metrics {
+	m, _ := ctx.Value(metricsKey).(api.Metrics)
+	return m
+}
+
+// Recorder records the metrics of a single node.  A nil *Recorder,
+// returned when the context has no metrics, records nothing.
+type Recorder struct {
+	metrics api.Metrics
+	ctx     context.Context
+	def     string
+}
+
+// NewRecorder returns a *Recorder for the node of ctx, or for a node
+// named def when ctx does not belong to a node.  It returns nil
+// if ctx has no metrics.
+func NewRecorder(ctx context.Context, def string) *Recorder {
+	m := GetMetrics(ctx)
+	if m == nil {
+		return nil
+	}
+	return &Recorder{metrics: m, ctx: ctx, def: def}
+}
+
+// node returns the name of the node, it is resolved on each
+// call since nodes may be renamed after they are created
+func (r *Recorder) node() string {
+	return autoctx.GetNodeName(r.ctx, r.def)
+}
+
+// In records an item received by the node
+func (r *Recorder) In() {
+	if r == nil {
+		return
+	}
+	r.metrics.ItemIn(r.node())
+}
+
+// Out records an item sent downstream on the output channel of the node
+func (r *Recorder) Out(output chan interface{}) {
+	if r == nil {
+		return
+	}
+	r.metrics.ItemOut(r.node())
+	r.metrics.Occupancy(r.node(), len(output), cap(output))
+}
+
+// Error records an item whose processing failed
+func (r *Recorder) Error() {
+	if r == nil {
+		return
+	}
+	r.metrics.Error(r.node())
+}
+
+// Since records the latency of an item processed since start
+func (r *Recorder) Since(start time.Time) {
+	if r == nil {
+		return
+	}
+	r.metrics.Latency(r.node(), time.Since(start))
+}
+
+// Now returns the current time if metrics are recorded, the zero time
+// otherwise, so that unrecorded nodes do not read the clock.
+func (r *Recorder) Now() time.Time {
+	if r == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WritePrometheus writes the metrics of all nodes to w using
+// the Prometheus text exposition format.
+func (m *Memory) WritePrometheus(w io.Writer) error {
+	snapshot := m.Snapshot()
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := bufio.NewWriter(w)
+	counters := []struct {
+		name, help string
+		value      func(NodeStats) uint64
+	}{
+		{"automi_items_in_total", "Items received by a node.", func(s NodeStats) uint64 { return s.In }},
+		{"automi_items_out_total", "Items sent downstream by a node.", func(s NodeStats) uint64 { return s.Out }},
+		{"automi_errors_total", "Items whose processing failed.", func(s NodeStats) uint64 { return s.Errors }},
+	}
+	for _, counter := range counters {
+		writeHeader(buf, counter.name, counter.help, "counter")
+		for _, name := range names {
+			fmt.Fprintf(buf, "%s{node=%s} %d\n", counter.name, label(name), counter.value(snapshot[name]))
+		}
+	}
+
+	writeHeader(buf, "automi_latency_seconds", "Time taken to process an item.", "histogram")
+	for _, name := range names {
+		h := snapshot[name].Latency
+		for i, bound := range h.Bounds {
+			le := strconv.FormatFloat(bound.Seconds(), 'g', -1, 64)
+			fmt.Fprintf(buf, "automi_latency_seconds_bucket{node=%s,le=\"%s\"} %d\n", label(name), le, h.Counts[i])
+		}
+		fmt.Fprintf(buf, "automi_latency_seconds_bucket{node=%s,le=\"+Inf\"} %d\n", label(name), h.Count)
+		fmt.Fprintf(buf, "automi_latency_seconds_sum{node=%s} %s\n", label(name), strconv.FormatFloat(h.Sum.Seconds(), 'g', -1, 64))
+		fmt.Fprintf(buf, "automi_latency_seconds_count{node=%s} %d\n", label(name), h.Count)
+	}
+
+	writeHeader(buf, "automi_channel_occupancy", "Items buffered in the output channel of a node.", "gauge")
+	for _, name := range names {
+		fmt.Fprintf(buf, "automi_channel_occupancy{node=%s} %d\n", label(name), snapshot[name].Occupancy)
+	}
+	writeHeader(buf, "automi_channel_capacity", "Capacity of the output channel of a node.", "gauge")
+	for _, name := range names {
+		fmt.Fprintf(buf, "automi_channel_capacity{node=%s} %d\n", label(name), snapshot[name].Capacity)
+	}
+	return buf.Flush()
+}
+
+// Handler returns an http.Handler that serves the metrics
+// of m using the Prometheus text exposition format.
+func Handler(m *Memory) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := m.WritePrometheus(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func writeHeader(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// label quotes a label value, escaping backslashes, quotes and new lines
+func label(value string) string {
+	return `"` + labelEscaper.Replace(value) + `"`
+}
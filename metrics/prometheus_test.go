@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	m := NewMemory(time.Millisecond)
+	m.ItemIn("map")
+	m.ItemOut("map")
+	m.Error(`a"b`)
+	m.Latency("map", 100*time.Microsecond)
+	m.Occupancy("map", 2, 1024)
+
+	srv := httptest.NewServer(Handler(m))
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatal("unexpected content type ", ct)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		"# TYPE automi_items_in_total counter",
+		`automi_items_in_total{node="map"} 1`,
+		`automi_items_out_total{node="map"} 1`,
+		`automi_errors_total{node="a\"b"} 1`,
+		"# TYPE automi_latency_seconds histogram",
+		`automi_latency_seconds_bucket{node="map",le="0.001"} 1`,
+		`automi_latency_seconds_bucket{node="map",le="+Inf"} 1`,
+		`automi_latency_seconds_sum{node="map"} 0.0001`,
+		`automi_latency_seconds_count{node="map"} 1`,
+		`automi_channel_occupancy{node="map"} 2`,
+		`automi_channel_capacity{node="map"} 1024`,
+	} {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Errorf("missing line %q in:\n%s", line, body)
+		}
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
+	"time"
 
 	"github.com/go-faces/logger"
 	"github.com/gofunky/automi/api"
 	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/metrics"
+	"github.com/gofunky/automi/tracing"
 	"github.com/gofunky/automi/util"
 )
 
@@ -19,7 +23,17 @@ type BatchOperator struct {
 	input   <-chan interface{}
 	output  chan interface{}
 	log     logger.Interface
+	metrics *metrics.Recorder
 	trigger api.BatchTrigger
+	store   api.StateStore
+	codec   api.Codec
+	panes   []*pane
+}
+
+// pane is the batch of the items of an interval
+type pane struct {
+	end   time.Time
+	batch reflect.Value
 }
 
 // New returns a new BatchOperator operator
@@ -28,6 +42,7 @@ func New(ctx context.Context) *BatchOperator {
 	op := new(BatchOperator)
 	op.ctx = ctx
 	op.log = log
+	op.metrics = metrics.NewRecorder(ctx, "batch")
 	util.Log(op.log, "starting batch operator")
 	op.output = make(chan interface{}, 1024)
 	return op
@@ -48,6 +63,15 @@ func (op *BatchOperator) SetTrigger(trigger api.BatchTrigger) {
 	op.trigger = trigger
 }
 
+// SetStateStore sets the store where the pending batch is saved, encoded
+// with codec, when the operator is cancelled.  The batch is saved under
+// the name of the operator, and restored when an operator of the same name
+// starts, so that its items are batched with the following ones.
+func (op *BatchOperator) SetStateStore(store api.StateStore, codec api.Codec) {
+	op.store = store
+	op.codec = codec
+}
+
 // Exec is the execution starting point for the operator node.
 // The batch operator batches N size items from upstream into
 // a slice []T.  When the slice reaches size N, the slice is sent
@@ -58,18 +82,25 @@ func (op *BatchOperator) Exec(drain chan<- error) {
 		return
 	}
 
+	batchValue, err := op.restore()
+	if err != nil {
+		util.Log(op.log, err)
+		drain <- err
+		return
+	}
+
 	// The operator dynamically creates the slice of []T.
 	// it does this by creating automatically detecting elem type T
 	// from the first item that shows up in the channel
 
 	go func() {
-		var batchValue reflect.Value
 		defer func() {
 			util.Log(op.log, "closing batch operator")
 			// push any straggler items in batch
 			if batchValue.IsValid() && batchValue.Len() > 0 {
-				op.output <- batchValue.Interface()
+				op.send(batchValue.Interface())
 			}
+			op.flush()
 			close(op.output)
 		}()
 
@@ -78,13 +109,37 @@ func (op *BatchOperator) Exec(drain chan<- error) {
 			op.trigger = TriggerAll()
 		}
 
+		// items of intervals are batched in panes, including restored ones
+		iv, byInterval := op.trigger.(intervals)
+		if byInterval && batchValue.IsValid() {
+			restored := batchValue
+			batchValue = reflect.Value{}
+			for i := 0; i < restored.Len(); i++ {
+				if !op.addToPane(iv, restored.Index(i).Interface()) {
+					return
+				}
+			}
+		}
+
 		var index int64 = 1
+		if batchValue.IsValid() {
+			index += int64(batchValue.Len())
+		}
 		for {
 			select {
 			case item, opened := <-op.input:
 				if !opened {
 					return
 				}
+				op.metrics.In()
+				_, item = tracing.Unwrap(item)
+				if byInterval {
+					if !op.addToPane(iv, item) {
+						return
+					}
+					continue
+				}
+
 				// detect type of first item to create proper
 				// Slice type for batch.
 				if !batchValue.IsValid() {
@@ -92,7 +147,7 @@ func (op *BatchOperator) Exec(drain chan<- error) {
 					batchValue = reflect.MakeSlice(reflect.SliceOf(batchType), 0, 1)
 				}
 
-				batchValue = reflect.Append(batchValue, reflect.ValueOf(item))
+				batchValue = op.appendItem(batchValue, item)
 				done := op.trigger.Done(op.ctx, item, index)
 				if !done {
 					index++
@@ -100,16 +155,192 @@ func (op *BatchOperator) Exec(drain chan<- error) {
 				}
 
 				// done
-				op.output <- batchValue.Interface()
+				if !op.send(batchValue.Interface()) {
+					return
+				}
 				index = 1
 				batchType := op.makeBatchType(item)
 				batchValue = reflect.MakeSlice(reflect.SliceOf(batchType), 0, 1)
 
+			case <-op.ctx.Done():
+				util.Log(op.log, "batch operator cancelled")
+				if err := op.save(op.pending(batchValue)); err != nil {
+					util.Log(op.log, err)
+					drain <- err
+				}
+				batchValue = reflect.Value{}
+				op.panes = nil
+				return
 			}
 		}
 	}()
 }
 
+// intervals is implemented by triggers that batch items by interval
+type intervals interface {
+	assign(ctx context.Context, item interface{}) (time.Time, *api.LateItem, error)
+	ended(end time.Time) bool
+}
+
+// addToPane adds item to the pane of its interval, unless it is late,
+// and emits the panes whose interval ended.  It returns false if the
+// context is done.
+func (op *BatchOperator) addToPane(iv intervals, item interface{}) bool {
+	end, late, err := iv.assign(op.ctx, item)
+	if err != nil {
+		util.Log(op.log, err)
+		op.metrics.Error()
+		return true
+	}
+	if late != nil {
+		op.sendLate(*late)
+		return true
+	}
+
+	var p *pane
+	for _, candidate := range op.panes {
+		if candidate.end.Equal(end) {
+			p = candidate
+			break
+		}
+	}
+	if p == nil {
+		p = &pane{end: end, batch: reflect.MakeSlice(reflect.SliceOf(op.makeBatchType(item)), 0, 1)}
+		op.panes = append(op.panes, p)
+	}
+	p.batch = op.appendItem(p.batch, item)
+
+	return op.fire(iv.ended)
+}
+
+// fire emits, in end time order, the panes whose interval ended
+func (op *BatchOperator) fire(ended func(time.Time) bool) bool {
+	sort.SliceStable(op.panes, func(i, j int) bool {
+		return op.panes[i].end.Before(op.panes[j].end)
+	})
+	for len(op.panes) > 0 && ended(op.panes[0].end) {
+		if !op.send(op.panes[0].batch.Interface()) {
+			return false
+		}
+		op.panes = op.panes[1:]
+	}
+	return true
+}
+
+// flush emits all remaining panes
+func (op *BatchOperator) flush() {
+	op.fire(func(time.Time) bool { return true })
+}
+
+// pending returns the items not emitted yet, those of batchValue
+// followed by those of the panes in end time order
+func (op *BatchOperator) pending(batchValue reflect.Value) reflect.Value {
+	sort.SliceStable(op.panes, func(i, j int) bool {
+		return op.panes[i].end.Before(op.panes[j].end)
+	})
+	for _, p := range op.panes {
+		if !batchValue.IsValid() {
+			batchValue = reflect.MakeSlice(p.batch.Type(), 0, p.batch.Len())
+		}
+		for i := 0; i < p.batch.Len(); i++ {
+			batchValue = op.appendItem(batchValue, p.batch.Index(i).Interface())
+		}
+	}
+	return batchValue
+}
+
+// restore returns the saved batch, if any, which is removed from the store
+func (op *BatchOperator) restore() (reflect.Value, error) {
+	if op.store == nil {
+		return reflect.Value{}, nil
+	}
+	if op.codec == nil {
+		return reflect.Value{}, fmt.Errorf("batch operator missing state codec")
+	}
+	key := autoctx.GetNodeName(op.ctx, "batch")
+	data, ok, err := op.store.Get(key)
+	if err != nil || !ok {
+		if err != nil {
+			err = fmt.Errorf("unable to restore batch: %w", err)
+		}
+		return reflect.Value{}, err
+	}
+	batch, err := op.codec.Decode(data)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("unable to decode batch: %w", err)
+	}
+	batchValue := reflect.ValueOf(batch)
+	if batchValue.Kind() != reflect.Slice {
+		return reflect.Value{}, fmt.Errorf("unable to restore batch of type %T", batch)
+	}
+	if err := op.store.Delete(key); err != nil {
+		return reflect.Value{}, fmt.Errorf("unable to restore batch: %w", err)
+	}
+	util.Log(op.log, "batch operator restored pending batch")
+	return batchValue, nil
+}
+
+// save saves the pending batch in the store of the operator, if any
+func (op *BatchOperator) save(batchValue reflect.Value) error {
+	if op.store == nil || !batchValue.IsValid() || batchValue.Len() == 0 {
+		return nil
+	}
+	data, err := op.codec.Encode(batchValue.Interface())
+	if err != nil {
+		return fmt.Errorf("unable to encode batch: %w", err)
+	}
+	if err := op.store.Put(autoctx.GetNodeName(op.ctx, "batch"), data); err != nil {
+		return fmt.Errorf("unable to save batch: %w", err)
+	}
+	return nil
+}
+
+// sendLate routes a late item to the auxiliary channel of the context
+func (op *BatchOperator) sendLate(late api.LateItem) {
+	aux, ok := autoctx.GetAuxChan(op.ctx)
+	if !ok {
+		util.Logf(op.log, "dropping late item %v with timestamp %v", late.Item, late.Timestamp)
+		return
+	}
+	select {
+	case aux <- late:
+	case <-op.ctx.Done():
+	}
+}
+
+// send sends batch downstream unless the context is done
+func (op *BatchOperator) send(batch interface{}) bool {
+	select {
+	case op.output <- batch:
+		op.metrics.Out(op.output)
+		return true
+	case <-op.ctx.Done():
+		return false
+	}
+}
+
+// appendItem appends item to the batch.  If the item's type does not
+// match the element type of the batch, the batch is converted to
+// []interface{} to accommodate mixed item types.
+func (op *BatchOperator) appendItem(batchValue reflect.Value, item interface{}) reflect.Value {
+	itemVal := reflect.ValueOf(item)
+	elemType := batchValue.Type().Elem()
+	if itemVal.IsValid() && itemVal.Type().AssignableTo(elemType) {
+		return reflect.Append(batchValue, itemVal)
+	}
+	if elemType.Kind() != reflect.Interface {
+		mixed := reflect.MakeSlice(reflect.TypeOf([]interface{}{}), 0, batchValue.Len()+1)
+		for i := 0; i < batchValue.Len(); i++ {
+			mixed = reflect.Append(mixed, batchValue.Index(i))
+		}
+		batchValue = mixed
+	}
+	if !itemVal.IsValid() {
+		return reflect.Append(batchValue, reflect.Zero(batchValue.Type().Elem()))
+	}
+	return reflect.Append(batchValue, itemVal)
+}
+
 // makeBatchType detects and return type to be used for the batch based
 // on items in the
 func (op *BatchOperator) makeBatchType(item interface{}) reflect.Type {
@@ -2,10 +2,14 @@ package batch
 
 import (
 	"context"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/gofunky/automi/api"
+	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/state"
 	"github.com/gofunky/automi/testutil"
 )
 
@@ -345,3 +349,197 @@ func BenchmarkBatchOp_Exec(b *testing.B) {
 	}
 	m.RUnlock()
 }
+
+func TestBatchOp_Exec_MixedTypes(t *testing.T) {
+	o := New(context.Background())
+	o.SetTrigger(TriggerAll())
+
+	in := make(chan interface{})
+	go func() {
+		in <- "A"
+		in <- 2
+		in <- 3.0
+		close(in)
+	}()
+	o.SetInput(in)
+
+	var result interface{}
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for batch := range o.GetOutput() {
+			result = batch
+		}
+	}()
+
+	drain := make(chan error)
+	o.Exec(drain)
+
+	select {
+	case <-wait:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	items, ok := result.([]interface{})
+	if !ok {
+		t.Fatalf("expecting type []interface{}, got %T", result)
+	}
+	if len(items) != 3 || items[0] != "A" || items[1] != 2 {
+		t.Fatal("unexpected batch ", items)
+	}
+}
+
+func TestBatchOp_Exec_EventTime(t *testing.T) {
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts := api.TimestampFunc(func(item interface{}) (time.Time, error) {
+		return base.Add(time.Duration(item.(int)) * time.Second), nil
+	})
+	o := New(context.Background())
+	o.SetTrigger(TriggerByEventTime(ts, 10*time.Second, 0))
+
+	in := make(chan interface{})
+	go func() {
+		for _, i := range []int{1, 5, 12, 15, 21} {
+			in <- i
+		}
+		close(in)
+	}()
+	o.SetInput(in)
+
+	var batches [][]int
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for batch := range o.GetOutput() {
+			batches = append(batches, batch.([]int))
+		}
+	}()
+
+	o.Exec(make(chan error))
+
+	select {
+	case <-wait:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if len(batches) != 3 || len(batches[0]) != 2 || len(batches[1]) != 2 || len(batches[2]) != 1 {
+		t.Fatal("unexpected batches ", batches)
+	}
+}
+
+func TestBatchOp_Exec_EventTimeOutOfOrder(t *testing.T) {
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts := api.TimestampFunc(func(item interface{}) (time.Time, error) {
+		return base.Add(time.Duration(item.(int)) * time.Second), nil
+	})
+	o := New(context.Background())
+	o.SetTrigger(TriggerByEventTime(ts, 10*time.Second, 5*time.Second))
+
+	in := make(chan interface{})
+	go func() {
+		for _, i := range []int{1, 12, 8, 16, 25, 22} {
+			in <- i
+		}
+		close(in)
+	}()
+	o.SetInput(in)
+	o.Exec(make(chan error))
+
+	var batches [][]int
+	for batch := range o.GetOutput() {
+		batches = append(batches, batch.([]int))
+	}
+	expected := [][]int{{1, 8}, {12, 16}, {25, 22}}
+	if !reflect.DeepEqual(batches, expected) {
+		t.Fatalf("expecting batches %v, got %v", expected, batches)
+	}
+}
+
+func TestBatchOp_Exec_EventTimeLate(t *testing.T) {
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts := api.TimestampFunc(func(item interface{}) (time.Time, error) {
+		return base.Add(time.Duration(item.(int)) * time.Second), nil
+	})
+	aux := make(chan interface{}, 1)
+	o := New(autoctx.WithAuxChan(context.Background(), aux))
+	o.SetTrigger(TriggerByEventTime(ts, 10*time.Second, 0))
+
+	in := make(chan interface{})
+	go func() {
+		for _, i := range []int{1, 5, 12, 3, 15, 21} {
+			in <- i
+		}
+		close(in)
+	}()
+	o.SetInput(in)
+	o.Exec(make(chan error))
+
+	var batches [][]int
+	for batch := range o.GetOutput() {
+		batches = append(batches, batch.([]int))
+	}
+	if len(batches) != 3 || len(batches[0]) != 2 || len(batches[1]) != 2 || len(batches[2]) != 1 {
+		t.Fatal("expecting the late item not to be batched, got ", batches)
+	}
+	select {
+	case item := <-aux:
+		late := item.(api.LateItem)
+		if late.Item != 3 || !late.Watermark.Equal(base.Add(12*time.Second)) {
+			t.Fatal("unexpected late item ", late)
+		}
+	default:
+		t.Fatal("expecting the late item to be sent to the auxiliary channel")
+	}
+}
+
+func TestBatchOp_StateStore(t *testing.T) {
+	store := state.NewMemory()
+	nodeCtx := autoctx.WithNode(context.Background(), &autoctx.Node{Name: "lines"})
+
+	// the pending batch is saved when the operator is cancelled
+	ctx, cancel := context.WithCancel(nodeCtx)
+	op := New(ctx)
+	op.SetTrigger(TriggerBySize(3))
+	op.SetStateStore(store, state.JSON([]string{}))
+	in := make(chan interface{})
+	op.SetInput(in)
+	op.Exec(make(chan error, 1))
+	in <- "a"
+	in <- "b"
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	for batch := range op.GetOutput() {
+		t.Fatal("unexpected batch ", batch)
+	}
+
+	// and restored when it starts again
+	op = New(nodeCtx)
+	op.SetTrigger(TriggerBySize(3))
+	op.SetStateStore(store, state.JSON([]string{}))
+	in = make(chan interface{})
+	op.SetInput(in)
+	drain := make(chan error, 1)
+	op.Exec(drain)
+	go func() {
+		in <- "c"
+		in <- "d"
+		close(in)
+	}()
+	var batches [][]string
+	for batch := range op.GetOutput() {
+		batches = append(batches, batch.([]string))
+	}
+	select {
+	case err := <-drain:
+		t.Fatal(err)
+	default:
+	}
+	if len(batches) != 2 || len(batches[0]) != 3 || batches[0][2] != "c" || batches[1][0] != "d" {
+		t.Fatal("expecting the pending batch to be restored, got ", batches)
+	}
+	if _, ok, _ := store.Get("lines"); ok {
+		t.Fatal("expecting the restored batch to be removed")
+	}
+}
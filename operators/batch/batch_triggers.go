@@ -2,6 +2,7 @@ package batch
 
 import (
 	"context"
+	"time"
 
 	"github.com/gofunky/automi/api"
 )
@@ -22,3 +23,51 @@ func TriggerBySize(size int64) api.BatchTriggerFunc {
 		return i >= size
 	})
 }
+
+// TriggerByEventTime batches items by their event time, as provided by ts,
+// into consecutive intervals of the specified size.  Each interval is
+// batched separately, its batch is emitted once the watermark passes its
+// end.  The watermark trails the greatest observed timestamp by
+// maxOutOfOrder.  Items arriving after the watermark has passed the end
+// of their interval, whose batch was already emitted, are not batched but
+// sent to the auxiliary channel of the context as api.LateItem values.
+func TriggerByEventTime(ts api.TimestampExtractor, size, maxOutOfOrder time.Duration) api.BatchTrigger {
+	return &eventTimeTrigger{
+		ts:        ts,
+		size:      size,
+		watermark: &api.Watermark{MaxOutOfOrder: maxOutOfOrder},
+	}
+}
+
+type eventTimeTrigger struct {
+	ts        api.TimestampExtractor
+	size      time.Duration
+	watermark *api.Watermark
+}
+
+// Done implements api.BatchTrigger.  Batches are emitted by the operator
+// once their interval ended, see ended.
+func (t *eventTimeTrigger) Done(ctx context.Context, item interface{}, i int64) bool {
+	return false
+}
+
+// assign returns the end of the interval of item, and advances the
+// watermark with its timestamp.  If the watermark has already passed
+// the end of the interval, the item is returned as an api.LateItem.
+func (t *eventTimeTrigger) assign(ctx context.Context, item interface{}) (time.Time, *api.LateItem, error) {
+	at, err := t.ts.Timestamp(item)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	end := at.Truncate(t.size).Add(t.size)
+	if current := t.watermark.Current(); !current.IsZero() && !end.After(current) {
+		return end, &api.LateItem{Item: item, Timestamp: at, Watermark: current}, nil
+	}
+	t.watermark.Observe(at)
+	return end, nil, nil
+}
+
+// ended reports whether the watermark has passed end
+func (t *eventTimeTrigger) ended(end time.Time) bool {
+	return !end.After(t.watermark.Current())
+}
@@ -3,6 +3,9 @@ package batch
 import (
 	"context"
 	"testing"
+	"time"
+
+	"github.com/gofunky/automi/api"
 )
 
 func TestBatchTriggers_All(t *testing.T) {
@@ -44,3 +47,42 @@ func TestBatchTriggers_BySize(t *testing.T) {
 		}
 	}
 }
+
+func TestBatchTriggers_ByEventTime(t *testing.T) {
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts := api.TimestampFunc(func(item interface{}) (time.Time, error) {
+		return base.Add(time.Duration(item.(int)) * time.Second), nil
+	})
+	trigger := TriggerByEventTime(ts, 10*time.Second, 2*time.Second)
+	iv := trigger.(intervals)
+
+	tests := []struct {
+		item  int
+		end   int
+		late  bool
+		ended int
+	}{
+		{item: 1, end: 10}, {item: 9, end: 10}, {item: 11, end: 20}, {item: 8, end: 10},
+		{item: 12, end: 20, ended: 10}, {item: 3, end: 10, late: true, ended: 10}, {item: 23, end: 30, ended: 20},
+	}
+	for i, test := range tests {
+		end, late, err := iv.assign(context.Background(), test.item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !end.Equal(base.Add(time.Duration(test.end) * time.Second)) {
+			t.Fatalf("item %d: expecting interval end %d, got %v", test.item, test.end, end)
+		}
+		if (late != nil) != test.late {
+			t.Fatalf("item %d: expecting late %t, got %v", test.item, test.late, late)
+		}
+		for _, at := range []int{10, 20, 30} {
+			if ended := iv.ended(base.Add(time.Duration(at) * time.Second)); ended != (at <= test.ended) {
+				t.Fatalf("item %d: interval ending at %d ended %t", test.item, at, ended)
+			}
+		}
+		if trigger.Done(context.Background(), test.item, int64(i+1)) {
+			t.Fatal("event time batches should be emitted by interval")
+		}
+	}
+}
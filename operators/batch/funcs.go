@@ -17,7 +17,7 @@ import (
 // The function returns type
 //   []map[interface{}][]interface{}
 func GroupByPosFunc(pos int) api.UnFunc {
-	return api.UnFunc(func(ctx context.Context, param0 interface{}) (interface{}, error) {
+	return windowed(func(ctx context.Context, param0 interface{}) (interface{}, error) {
 		dataType := reflect.TypeOf(param0)
 		dataVal := reflect.ValueOf(param0)
 
@@ -70,7 +70,7 @@ func GroupByPosFunc(pos int) api.UnFunc {
 // More specifically a value:
 //   []map[int]float64{{pos: sum}} where sum is the calculated sum.
 func SumByPosFunc(pos int) api.UnFunc {
-	return api.UnFunc(func(ctx context.Context, param0 interface{}) (interface{}, error) {
+	return windowed(func(ctx context.Context, param0 interface{}) (interface{}, error) {
 		dataType := reflect.TypeOf(param0)
 		dataVal := reflect.ValueOf(param0)
 
@@ -109,7 +109,7 @@ func SumByPosFunc(pos int) api.UnFunc {
 //   []map[interface{}][]interface{}
 // Where the map that uses the field values as key to group the items.
 func GroupByNameFunc(name string) api.UnFunc {
-	return api.UnFunc(func(ctx context.Context, param0 interface{}) (interface{}, error) {
+	return windowed(func(ctx context.Context, param0 interface{}) (interface{}, error) {
 		dataType := reflect.TypeOf(param0)
 		dataVal := reflect.ValueOf(param0)
 
@@ -162,7 +162,7 @@ func GroupByNameFunc(name string) api.UnFunc {
 //   []map[string]float64{{name:sum}}
 // Where sum is the total calculated sum for fields name.
 func SumByNameFunc(name string) api.UnFunc {
-	return api.UnFunc(func(ctx context.Context, param0 interface{}) (interface{}, error) {
+	return windowed(func(ctx context.Context, param0 interface{}) (interface{}, error) {
 		dataType := reflect.TypeOf(param0)
 		dataVal := reflect.ValueOf(param0)
 
@@ -221,7 +221,7 @@ func SumByNameFunc(name string) api.UnFunc {
 //   []map[interface{}][]interface{}
 // Where items with simlar K values are assigned the same key in the result map.
 func GroupByKeyFunc(key interface{}) api.UnFunc {
-	return api.UnFunc(func(ctx context.Context, param0 interface{}) (interface{}, error) {
+	return windowed(func(ctx context.Context, param0 interface{}) (interface{}, error) {
 		dataType := reflect.TypeOf(param0)
 		dataVal := reflect.ValueOf(param0)
 
@@ -275,7 +275,7 @@ func GroupByKeyFunc(key interface{}) api.UnFunc {
 // Where sum is the total calculated sum for a given key.
 // If key == nil, it returns sums for all keys.
 func SumByKeyFunc(key interface{}) api.UnFunc {
-	return api.UnFunc(func(ctx context.Context, param0 interface{}) (interface{}, error) {
+	return windowed(func(ctx context.Context, param0 interface{}) (interface{}, error) {
 		dataType := reflect.TypeOf(param0)
 		dataVal := reflect.ValueOf(param0)
 
@@ -334,7 +334,7 @@ func SumByKeyFunc(key interface{}) api.UnFunc {
 //  [][]floats
 // The function returns the sum as a float64
 func SumFunc() api.UnFunc {
-	return api.UnFunc(func(ctx context.Context, param0 interface{}) (interface{}, error) {
+	return windowed(func(ctx context.Context, param0 interface{}) (interface{}, error) {
 		dataType := reflect.TypeOf(param0)
 		dataVal := reflect.ValueOf(param0)
 
@@ -392,7 +392,7 @@ func SumFunc() api.UnFunc {
 //   - Use package sort and a Less function to compare v[i] and v[i+1]
 // The function returns the sorted slice
 func SortFunc() api.UnFunc {
-	return api.UnFunc(func(ctx context.Context, param0 interface{}) (interface{}, error) {
+	return windowed(func(ctx context.Context, param0 interface{}) (interface{}, error) {
 		dataType := reflect.TypeOf(param0)
 		dataVal := reflect.ValueOf(param0)
 
@@ -421,7 +421,7 @@ func SortFunc() api.UnFunc {
 //   - Use package sort and a Less function to compare v[i][pos] and v[i+1][pos]
 // The function returns the sorted slice
 func SortByPosFunc(pos int) api.UnFunc {
-	return api.UnFunc(func(ctx context.Context, param0 interface{}) (interface{}, error) {
+	return windowed(func(ctx context.Context, param0 interface{}) (interface{}, error) {
 		dataType := reflect.TypeOf(param0)
 		dataVal := reflect.ValueOf(param0)
 
@@ -459,7 +459,7 @@ func SortByPosFunc(pos int) api.UnFunc {
 // For each struct s, field s.name must be of comparable values.
 // The function returns a sorted []T
 func SortByNameFunc(name string) api.UnFunc {
-	return api.UnFunc(func(ctx context.Context, param0 interface{}) (interface{}, error) {
+	return windowed(func(ctx context.Context, param0 interface{}) (interface{}, error) {
 		dataType := reflect.TypeOf(param0)
 		dataVal := reflect.ValueOf(param0)
 
@@ -495,7 +495,7 @@ func SortByNameFunc(name string) api.UnFunc {
 //   []map[K]V - where K is a comparable type
 // The function returns sorted []map[K]
 func SortByKeyFunc(key interface{}) api.UnFunc {
-	return api.UnFunc(func(ctx context.Context, param0 interface{}) (interface{}, error) {
+	return windowed(func(ctx context.Context, param0 interface{}) (interface{}, error) {
 		dataType := reflect.TypeOf(param0)
 		dataVal := reflect.ValueOf(param0)
 
@@ -533,7 +533,7 @@ func SortByKeyFunc(key interface{}) api.UnFunc {
 // The specified function should follow the Less function convention of the
 // sort package when compairing values from rows i, j.
 func SortWithFunc(f func(batch interface{}, i, j int) bool) api.UnFunc {
-	return api.UnFunc(func(ctx context.Context, param0 interface{}) (interface{}, error) {
+	return windowed(func(ctx context.Context, param0 interface{}) (interface{}, error) {
 		dataType := reflect.TypeOf(param0)
 		dataVal := reflect.ValueOf(param0)
 
@@ -551,11 +551,29 @@ func SortWithFunc(f func(batch interface{}, i, j int) bool) api.UnFunc {
 }
 
 func ForAll(f func(ctx context.Context, batch interface{}) map[interface{}][]interface{}) api.UnFunc {
-	return api.UnFunc(func(ctx context.Context, param0 interface{}) (interface{}, error) {
+	return windowed(func(ctx context.Context, param0 interface{}) (interface{}, error) {
 		return f(ctx, param0), nil
 	})
 }
 
+// windowed wraps a batch operation so that it can be applied to the data of
+// an api.Window.  The result is returned as an api.Window with the same bounds
+// so that the window metadata is carried downstream.
+func windowed(f api.UnFunc) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, param0 interface{}) (interface{}, error) {
+		win, ok := param0.(api.Window)
+		if !ok {
+			return f(ctx, param0)
+		}
+		result, err := f(ctx, win.Data)
+		if err != nil {
+			return nil, err
+		}
+		win.Data = result
+		return win, nil
+	})
+}
+
 func sumAll(item reflect.Value) float64 {
 	if !item.IsValid() {
 		return 0.0
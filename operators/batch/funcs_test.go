@@ -3,6 +3,9 @@ package batch
 import (
 	"context"
 	"testing"
+	"time"
+
+	"github.com/gofunky/automi/api"
 )
 
 func TestBatchFuncs_GroupByPos_WithSlice(t *testing.T) {
@@ -260,6 +263,21 @@ func TestBatchFuncs_SortByPos(t *testing.T) {
 	}
 }
 
+func TestBatchFuncs_SortByPos_Interfaces(t *testing.T) {
+	op := SortByPosFunc(1)
+	data := [][]interface{}{
+		{"Spirit", 12.5},
+		{"Voyager", 8},
+		{"BigFoot", 10.0},
+	}
+	val, _ := op.Apply(context.TODO(), data)
+
+	sorted := val.([][]interface{})
+	if sorted[0][0] != "Voyager" || sorted[1][0] != "BigFoot" || sorted[2][0] != "Spirit" {
+		t.Fatal("unexpected sort order for result: ", sorted)
+	}
+}
+
 func TestBatchFuncs_SortByName(t *testing.T) {
 	op := SortByNameFunc("Vehicle")
 	type V struct {
@@ -316,3 +334,20 @@ func TestBatchFuncs_SortWithFunc(t *testing.T) {
 		t.Fatal("Unexpected sort order")
 	}
 }
+
+func TestBatchFunc_Windowed(t *testing.T) {
+	start := time.Now()
+	win := api.Window{Start: start, End: start.Add(time.Second), Data: []int{3, 1, 2}}
+
+	result, err := SumFunc().Apply(context.TODO(), win)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, ok := result.(api.Window)
+	if !ok {
+		t.Fatalf("expecting api.Window, got %T", result)
+	}
+	if sum.Data.(float64) != 6 || !sum.Start.Equal(start) {
+		t.Fatal("unexpected windowed sum ", sum)
+	}
+}
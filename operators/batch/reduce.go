@@ -0,0 +1,83 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/util"
+)
+
+// ReduceFunc generates an api.UnFunc that reduces each incoming batch, of
+// type []T, into a single value using the seed and the reducer, which is
+// applied to the partial result and each item of the batch in turn.  If
+// combiner is not nil, the batch is split into at most parts slices that
+// are reduced concurrently, each of them from the seed, and their partial
+// results are merged in order with the combiner.  The seed must then be
+// neutral to the combiner, such as 0 for a sum.
+func ReduceFunc(seed interface{}, reducer, combiner api.BinOperation, parts int) api.UnFunc {
+	return windowed(func(ctx context.Context, param0 interface{}) (interface{}, error) {
+		dataVal := reflect.ValueOf(param0)
+		if !dataVal.IsValid() || (dataVal.Kind() != reflect.Slice && dataVal.Kind() != reflect.Array) {
+			return nil, fmt.Errorf("%s received an unexpected type: %T", util.TraceFunc(), param0)
+		}
+
+		n := parts
+		if combiner == nil || n < 2 {
+			n = 1
+		}
+		if n > dataVal.Len() {
+			n = dataVal.Len()
+		}
+		if n <= 1 {
+			return reduceRange(ctx, seed, reducer, dataVal, 0, dataVal.Len())
+		}
+
+		results := make([]interface{}, n)
+		errs := make([]error, n)
+		size := (dataVal.Len() + n - 1) / n
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			start, end := i*size, (i+1)*size
+			if end > dataVal.Len() {
+				end = dataVal.Len()
+			}
+			wg.Add(1)
+			go func(i, start, end int) {
+				defer wg.Done()
+				results[i], errs[i] = reduceRange(ctx, seed, reducer, dataVal, start, end)
+			}(i, start, end)
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			if err != nil {
+				return nil, err
+			}
+		}
+		state := results[0]
+		for _, result := range results[1:] {
+			merged, err := combiner.Apply(ctx, state, result)
+			if err != nil {
+				return nil, err
+			}
+			state = merged
+		}
+		return state, nil
+	})
+}
+
+// reduceRange reduces the items of batch in [start, end) from the seed
+func reduceRange(ctx context.Context, seed interface{}, reducer api.BinOperation, batch reflect.Value, start, end int) (interface{}, error) {
+	state := seed
+	for i := start; i < end; i++ {
+		result, err := reducer.Apply(ctx, state, batch.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		state = result
+	}
+	return state, nil
+}
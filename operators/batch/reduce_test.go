@@ -0,0 +1,110 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofunky/automi/api"
+)
+
+func TestBatchFuncs_Reduce(t *testing.T) {
+	concat := api.BinFunc(func(ctx context.Context, state, item interface{}) (interface{}, error) {
+		return state.(string) + item.(string), nil
+	})
+	data := []string{"a", "b", "c", "d", "e", "f", "g"}
+	tests := []struct {
+		name     string
+		combiner api.BinOperation
+		parts    int
+	}{
+		{name: "sequential", parts: 4},
+		{name: "single part", combiner: concat, parts: 1},
+		{name: "parts", combiner: concat, parts: 3},
+		{name: "more parts than items", combiner: concat, parts: 10},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := ReduceFunc("", concat, test.combiner, test.parts).Apply(context.TODO(), data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result != "abcdefg" {
+				t.Fatal("unexpected result ", result)
+			}
+		})
+	}
+}
+
+func TestBatchFuncs_Reduce_Reuse(t *testing.T) {
+	sum := api.BinFunc(func(ctx context.Context, state, item interface{}) (interface{}, error) {
+		return state.(int) + item.(int), nil
+	})
+	var combined int32
+	combiner := api.BinFunc(func(ctx context.Context, left, right interface{}) (interface{}, error) {
+		atomic.AddInt32(&combined, 1)
+		return left.(int) + right.(int), nil
+	})
+	reduce := ReduceFunc(0, sum, combiner, 4)
+
+	// a small batch does not lower the parts of the following ones
+	if result, err := reduce.Apply(context.TODO(), []int{1}); err != nil || result != 1 {
+		t.Fatal("unexpected result ", result, err)
+	}
+	if result, err := reduce.Apply(context.TODO(), []int{1, 2, 3, 4, 5, 6, 7, 8}); err != nil || result != 36 {
+		t.Fatal("unexpected result ", result, err)
+	}
+	if combined != 3 {
+		t.Fatal("expecting 3 combined partial results, got ", combined)
+	}
+
+	// batches are reduced concurrently with the same func
+	var wg sync.WaitGroup
+	for i := 1; i <= 8; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			batch := make([]int, n)
+			for j := range batch {
+				batch[j] = 1
+			}
+			if result, err := reduce.Apply(context.TODO(), batch); err != nil || result != n {
+				t.Error("unexpected result ", result, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestBatchFuncs_Reduce_Window(t *testing.T) {
+	sum := api.BinFunc(func(ctx context.Context, state, item interface{}) (interface{}, error) {
+		return state.(int) + item.(int), nil
+	})
+	win := api.Window{Start: time.Unix(0, 0), End: time.Unix(1, 0), Data: []int{1, 2, 3, 4}}
+	result, err := ReduceFunc(0, sum, sum, 2).Apply(context.TODO(), win)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.(api.Window).Data != 10 || !result.(api.Window).End.Equal(win.End) {
+		t.Fatal("unexpected result ", result)
+	}
+}
+
+func TestBatchFuncs_Reduce_Error(t *testing.T) {
+	errBad := errors.New("bad item")
+	op := api.BinFunc(func(ctx context.Context, state, item interface{}) (interface{}, error) {
+		if item == 3 {
+			return nil, errBad
+		}
+		return state.(int) + item.(int), nil
+	})
+	if _, err := ReduceFunc(0, op, op, 2).Apply(context.TODO(), []int{1, 2, 3, 4}); !errors.Is(err, errBad) {
+		t.Fatal("unexpected error ", err)
+	}
+	if _, err := ReduceFunc(0, op, op, 2).Apply(context.TODO(), 42); err == nil {
+		t.Fatal("expecting error for unbatched item")
+	}
+}
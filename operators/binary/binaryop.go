@@ -5,10 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/go-faces/logger"
 	"github.com/gofunky/automi/api"
 	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/metrics"
+	"github.com/gofunky/automi/tracing"
 	"github.com/gofunky/automi/util"
 )
 
@@ -17,11 +20,23 @@ import (
 type BinaryOperator struct {
 	ctx         context.Context
 	op          api.BinOperation
+	policy      api.ErrorPolicy
+	hasPolicy   bool
+	combiner    api.BinOperation
 	state       interface{}
 	concurrency int
+	interval    time.Duration
+	emitCount   int
+	pending     int
+	partials    []*partial
+	emitting    sync.Mutex
+	store       api.StateStore
+	codec       api.Codec
 	input       <-chan interface{}
 	output      chan interface{}
 	log         logger.Interface
+	metrics     *metrics.Recorder
+	trace       *tracing.Recorder
 	cancelled   bool
 	mutex       sync.RWMutex
 }
@@ -34,6 +49,8 @@ func New(ctx context.Context) *BinaryOperator {
 	o := new(BinaryOperator)
 	o.ctx = ctx
 	o.log = log
+	o.metrics = metrics.NewRecorder(ctx, "binary")
+	o.trace = tracing.NewRecorder(ctx, "binary")
 	o.concurrency = 1
 	o.output = make(chan interface{}, 1024)
 
@@ -51,6 +68,26 @@ func (o *BinaryOperator) SetInitialState(val interface{}) {
 	o.state = val
 }
 
+// SetErrorPolicy sets the policy applied to items whose operation
+// returns an error, or returns an error value as result.  A skipped or
+// dead-lettered item leaves the state unchanged.  By default, the
+// operator fails on the first error returned and emits no state, while
+// error values returned as result are logged and leave the state unchanged.
+func (o *BinaryOperator) SetErrorPolicy(policy api.ErrorPolicy) {
+	o.policy = policy
+	o.hasPolicy = true
+}
+
+// SetCombiner sets the operation that merges two partial states.  With
+// a combiner, each worker reduces its share of the items into its own
+// partial state, starting from the initial state, and the partial states
+// are merged when the state is emitted.  The initial state must then be
+// neutral to the combiner, such as 0 for a sum.  Without a combiner,
+// workers share the state and apply the operation one at a time.
+func (o *BinaryOperator) SetCombiner(op api.BinOperation) {
+	o.combiner = op
+}
+
 // SetConcurrency sets the concurrency level
 func (o *BinaryOperator) SetConcurrency(concurr int) {
 	o.concurrency = concurr
@@ -59,6 +96,36 @@ func (o *BinaryOperator) SetConcurrency(concurr int) {
 	}
 }
 
+// SetEmitInterval sets the interval at which the current state is
+// emitted while items are streamed, for sources that may never end.
+// The final state is still emitted once the input is closed.  Emitted
+// states are copies, which the operation does not update, see
+// SetEmitCount.
+func (o *BinaryOperator) SetEmitInterval(interval time.Duration) {
+	o.interval = interval
+}
+
+// SetEmitCount sets the number of items after which the current state
+// is emitted, so that the state of a scan is emitted after each item
+// with a count of 1.  The final state is emitted once the input is
+// closed, unless it was emitted after the last item.  Emitted states are
+// deep copies of the state, with the exception of the values that cannot
+// be copied, such as channels or the unexported fields of structs, which
+// must not be modified in place by the operation.
+func (o *BinaryOperator) SetEmitCount(count int) {
+	o.emitCount = count
+}
+
+// SetStateStore sets the store where the state is saved, encoded with
+// codec, each time it is emitted and when the operator is cancelled.  The
+// state is saved under the name of the operator, and restored in place of
+// the initial state when an operator of the same name starts, so that the
+// reduction resumes where it stopped.
+func (o *BinaryOperator) SetStateStore(store api.StateStore, codec api.Codec) {
+	o.store = store
+	o.codec = codec
+}
+
 // SetInput sets the input channel for the executor node
 func (o *BinaryOperator) SetInput(in <-chan interface{}) {
 	o.input = in
@@ -81,11 +148,71 @@ func (o *BinaryOperator) Exec(drain chan<- error) {
 		o.concurrency = 1
 	}
 
+	restored, err := o.restore()
+	if err != nil {
+		util.Log(o.log, err)
+		drain <- err
+		return
+	}
+
+	// workers share a context that is cancelled when one of them fails
+	exeCtx, cancel := context.WithCancel(o.ctx)
+
+	// each worker reduces into a partial state, unless
+	// there is no combiner to merge them
+	o.partials = make([]*partial, o.concurrency)
+	for i := range o.partials {
+		if i > 0 && o.combiner == nil {
+			o.partials[i] = o.partials[0]
+			continue
+		}
+		o.partials[i] = &partial{state: o.state}
+	}
+	o.partials[0].state = restored
+
 	go func() {
+		failed := false
+		fail := func(err error) {
+			o.mutex.Lock()
+			failed = true
+			o.mutex.Unlock()
+			cancel()
+			drain <- err
+		}
 		defer func() {
-			o.output <- o.state
+			// a failed or cancelled reduction emits no state, nor
+			// does one whose state was emitted after its last item
+			if !failed && !o.cancelled && (o.emitCount <= 0 || o.pending > 0) {
+				state, err := o.combine(exeCtx)
+				if err == nil {
+					err = o.save(state)
+				}
+				if err != nil {
+					failed = true
+					drain <- err
+				} else {
+					o.output <- state
+					o.metrics.Out(o.output)
+				}
+			}
+			// a cancelled reduction saves its state to resume later
+			if !failed && o.cancelled && o.store != nil {
+				state, err := o.combine(exeCtx)
+				if err == nil {
+					err = o.save(state)
+				}
+				if err != nil {
+					drain <- err
+				}
+			}
 			close(o.output)
+			cancel()
 			util.Log(o.log, "closing binary operator")
+			if failed {
+				// keep upstream flowing, remaining items are discarded
+				for range o.input {
+				}
+			}
 		}()
 
 		var barrier sync.WaitGroup
@@ -93,12 +220,12 @@ func (o *BinaryOperator) Exec(drain chan<- error) {
 		barrier.Add(wgDelta)
 
 		for i := 0; i < o.concurrency; i++ { // workers
-			go func(wg *sync.WaitGroup) {
+			go func(wg *sync.WaitGroup, p *partial) {
 				defer wg.Done()
-				if err := o.doProc(o.ctx); err != nil {
-					drain <- err
+				if err := o.doProc(exeCtx, p); err != nil {
+					fail(err)
 				}
-			}(&barrier)
+			}(&barrier, o.partials[i])
 		}
 
 		wait := make(chan struct{})
@@ -107,21 +234,167 @@ func (o *BinaryOperator) Exec(drain chan<- error) {
 			barrier.Wait()
 		}()
 
-		select {
-		case <-wait:
-			if o.cancelled {
-				util.Log(o.log, "binary operator cancelled")
-				return
+		// workers return once the input is closed or the context
+		// is done, intermediate states are emitted until then
+		var ticks <-chan time.Time
+		if o.interval > 0 {
+			ticker := time.NewTicker(o.interval)
+			defer ticker.Stop()
+			ticks = ticker.C
+		}
+		for done := false; !done; {
+			select {
+			case <-ticks:
+				if err := o.emitCurrent(exeCtx); err != nil {
+					fail(err)
+					ticks = nil
+				}
+			case <-wait:
+				done = true
 			}
-		case <-o.ctx.Done():
-			util.Log(o.log, "binary operator done")
-			return
+		}
+
+		switch {
+		case failed:
+			util.Log(o.log, "binary operator failed")
+		case o.cancelled:
+			util.Log(o.log, "binary operator cancelled")
 		}
 	}()
 }
 
-// doProc is a helper function that executes the operation
-func (o *BinaryOperator) doProc(ctx context.Context) (err error) {
+// counted counts a reduced item and reports
+// whether the state is to be emitted after it
+func (o *BinaryOperator) counted() bool {
+	if o.emitCount <= 0 {
+		return false
+	}
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.pending++
+	if o.pending < o.emitCount {
+		return false
+	}
+	o.pending = 0
+	return true
+}
+
+// emitCurrent emits a copy of the current state, which is
+// merged from the partial states if there is a combiner
+func (o *BinaryOperator) emitCurrent(ctx context.Context) error {
+	if o.combiner == nil {
+		p := o.partials[0]
+		p.mutex.Lock()
+		return o.emitLocked(ctx, p)
+	}
+	state, err := o.combine(ctx)
+	if err != nil {
+		return err
+	}
+	o.emitting.Lock()
+	defer o.emitting.Unlock()
+	return o.emit(ctx, state)
+}
+
+// emitLocked emits a copy of the partial state p, whose lock is held by
+// the caller.  The lock is released once the emission is started, so that
+// the states are emitted in the order of their updates, while a slow
+// downstream only holds back the workers that emit.
+func (o *BinaryOperator) emitLocked(ctx context.Context, p *partial) error {
+	state := util.CopyState(p.state)
+	o.emitting.Lock()
+	defer o.emitting.Unlock()
+	p.mutex.Unlock()
+	return o.emit(ctx, state)
+}
+
+// emit saves an intermediate state and sends it downstream
+func (o *BinaryOperator) emit(ctx context.Context, state interface{}) error {
+	if err := o.save(state); err != nil {
+		return err
+	}
+	select {
+	case o.output <- state:
+		o.metrics.Out(o.output)
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// restore returns the saved state, or the initial state
+// if there is none or if the operator has no store
+func (o *BinaryOperator) restore() (interface{}, error) {
+	if o.store == nil {
+		return o.state, nil
+	}
+	if o.codec == nil {
+		return nil, errors.New("binary operator missing state codec")
+	}
+	data, ok, err := o.store.Get(autoctx.GetNodeName(o.ctx, "binary"))
+	if err != nil || !ok {
+		if err != nil {
+			return nil, fmt.Errorf("unable to restore state: %w", err)
+		}
+		return o.state, nil
+	}
+	state, err := o.codec.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode state: %w", err)
+	}
+	util.Log(o.log, "binary operator state restored")
+	return state, nil
+}
+
+// save saves state in the store of the operator, if any
+func (o *BinaryOperator) save(state interface{}) error {
+	if o.store == nil {
+		return nil
+	}
+	data, err := o.codec.Encode(state)
+	if err != nil {
+		return fmt.Errorf("unable to encode state: %w", err)
+	}
+	if err := o.store.Put(autoctx.GetNodeName(o.ctx, "binary"), data); err != nil {
+		return fmt.Errorf("unable to save state: %w", err)
+	}
+	return nil
+}
+
+// partial is a partial state of the reduction, updated by a worker
+type partial struct {
+	mutex sync.Mutex
+	state interface{}
+}
+
+// combine merges copies of the partial states with the combiner, which
+// may then modify them.  Partial states that are shared by several
+// workers are only merged once.
+func (o *BinaryOperator) combine(ctx context.Context) (interface{}, error) {
+	var state interface{}
+	for i, p := range o.partials {
+		if i > 0 && p == o.partials[i-1] {
+			continue
+		}
+		p.mutex.Lock()
+		current := util.CopyState(p.state)
+		p.mutex.Unlock()
+		if i == 0 {
+			state = current
+			continue
+		}
+		merged, err := o.combiner.Apply(ctx, state, current)
+		if err != nil {
+			util.Log(o.log, err)
+			return nil, fmt.Errorf("unable to combine partial states: %w", err)
+		}
+		state = merged
+	}
+	return state, nil
+}
+
+// doProc is a helper function that executes the operation,
+// reducing the items into the partial state p
+func (o *BinaryOperator) doProc(ctx context.Context, p *partial) (err error) {
 	if o.op == nil {
 		err := errors.New("binary operator missing operation")
 		util.Log(o.log, err)
@@ -137,19 +410,45 @@ func (o *BinaryOperator) doProc(ctx context.Context) (err error) {
 				return nil
 			}
 
-			o.state, err = o.op.Apply(exeCtx, o.state, item)
+			o.metrics.In()
+			itemCtx, item := o.trace.Start(exeCtx, item)
+			start := o.metrics.Now()
+			p.mutex.Lock()
+			state, err := o.apply(itemCtx, p.state, item)
+			o.metrics.Since(start)
+			errValue, _ := state.(error)
+			emit := false
+			if err == nil && errValue == nil {
+				p.state = state
+				emit = o.counted()
+			}
+			var emitErr error
+			if emit && o.combiner == nil {
+				// a shared state is emitted in the order of its updates
+				emitErr = o.emitLocked(exeCtx, p)
+			} else {
+				p.mutex.Unlock()
+			}
+			o.trace.End(itemCtx, err)
+			if emit && o.combiner != nil {
+				emitErr = o.emitCurrent(exeCtx)
+			}
+			if emitErr != nil {
+				return emitErr
+			}
 			if err != nil {
 				util.Log(o.log, err)
-				return err
-			}
-
-			switch val := o.state.(type) {
-			case nil:
-				continue
-			case error, api.ProcError:
-				o.log.Print(val)
+				o.metrics.Error()
+				if err := o.policy.Reject(exeCtx, util.ProcErr(err, item)); err != nil {
+					return err
+				}
 				continue
 			}
+			if errValue != nil {
+				// without a policy, error values are logged and dropped
+				util.Log(o.log, errValue)
+				o.metrics.Error()
+			}
 
 		// is cancelling
 		case <-ctx.Done():
@@ -162,3 +461,21 @@ func (o *BinaryOperator) doProc(ctx context.Context) (err error) {
 		}
 	}
 }
+
+// apply applies the operation to the current state and item, retrying as set
+// by the error policy.  An error value returned as state is treated as a
+// failure if a policy is set.
+func (o *BinaryOperator) apply(ctx context.Context, current, item interface{}) (state interface{}, err error) {
+	err = o.policy.Do(ctx, func() error {
+		var opErr error
+		state, opErr = o.op.Apply(ctx, current, item)
+		if opErr != nil {
+			return opErr
+		}
+		if val, ok := state.(error); ok && o.hasPolicy {
+			return val
+		}
+		return nil
+	})
+	return state, err
+}
@@ -2,10 +2,14 @@ package binary
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/gofunky/automi/api"
+	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/state"
 	"github.com/gofunky/automi/testutil"
 )
 
@@ -142,3 +146,340 @@ func BenchmarkBinaryOp_Exec(b *testing.B) {
 		b.Fatal("Took too long")
 	}
 }
+
+func TestBinaryOp_ErrorPolicy(t *testing.T) {
+	op := api.BinFunc(func(ctx context.Context, op1, op2 interface{}) (interface{}, error) {
+		if op2.(int) < 0 {
+			return nil, errors.New("negative value")
+		}
+		return op1.(int) + op2.(int), nil
+	})
+
+	run := func(policy api.ErrorPolicy) ([]interface{}, error) {
+		o := New(context.Background())
+		o.SetInitialState(0)
+		o.SetOperation(op)
+		o.SetErrorPolicy(policy)
+		in := make(chan interface{})
+		go func() {
+			for _, i := range []int{1, -2, 3} {
+				in <- i
+			}
+			close(in)
+		}()
+		o.SetInput(in)
+		drain := make(chan error, 1)
+		o.Exec(drain)
+
+		var results []interface{}
+		for item := range o.GetOutput() {
+			results = append(results, item)
+		}
+		select {
+		case err := <-drain:
+			return results, err
+		default:
+			return results, nil
+		}
+	}
+
+	results, err := run(api.SkipOnError())
+	if err != nil || len(results) != 1 || results[0] != 4 {
+		t.Fatal("expecting skipped item to leave state unchanged, got ", results, err)
+	}
+
+	results, err = run(api.FailOnError())
+	var perr api.ProcError
+	if !errors.As(err, &perr) || perr.Item != -2 || len(results) != 0 {
+		t.Fatal("expecting failure without state, got ", results, err)
+	}
+}
+
+func TestBinaryOp_ErrorValue(t *testing.T) {
+	o := New(context.Background())
+	o.SetInitialState(0)
+	o.SetOperation(api.BinFunc(func(ctx context.Context, op1, op2 interface{}) (interface{}, error) {
+		if op2.(int) < 0 {
+			return errors.New("negative value"), nil
+		}
+		return op1.(int) + op2.(int), nil
+	}))
+	in := make(chan interface{})
+	go func() {
+		for _, i := range []int{1, -2, 3} {
+			in <- i
+		}
+		close(in)
+	}()
+	o.SetInput(in)
+	drain := make(chan error, 1)
+	o.Exec(drain)
+
+	var results []interface{}
+	for item := range o.GetOutput() {
+		results = append(results, item)
+	}
+	select {
+	case err := <-drain:
+		t.Fatal("expecting error value not to fail the operator, got ", err)
+	default:
+	}
+	if len(results) != 1 || results[0] != 4 {
+		t.Fatal("expecting error value to leave state unchanged, got ", results)
+	}
+}
+
+func TestBinaryOp_Concurrency(t *testing.T) {
+	sum := api.BinFunc(func(ctx context.Context, op1, op2 interface{}) (interface{}, error) {
+		return op1.(int) + op2.(int), nil
+	})
+	tests := []struct {
+		name     string
+		combiner api.BinOperation
+	}{
+		{name: "shared state"},
+		{name: "combiner", combiner: sum},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			o := New(context.Background())
+			o.SetInitialState(0)
+			o.SetOperation(sum)
+			o.SetCombiner(test.combiner)
+			o.SetConcurrency(4)
+			in := make(chan interface{})
+			go func() {
+				for i := 1; i <= 1000; i++ {
+					in <- i
+				}
+				close(in)
+			}()
+			o.SetInput(in)
+
+			drain := make(chan error, 1)
+			o.Exec(drain)
+
+			var results []interface{}
+			for out := range o.GetOutput() {
+				results = append(results, out)
+			}
+			if len(results) != 1 || results[0] != 500500 {
+				t.Fatal("expecting a single sum of 500500, got ", results)
+			}
+			select {
+			case err := <-drain:
+				t.Fatal(err)
+			default:
+			}
+		})
+	}
+}
+
+func TestBinaryOp_CombinerError(t *testing.T) {
+	errCombine := errors.New("combine failed")
+	o := New(context.Background())
+	o.SetInitialState(0)
+	o.SetOperation(api.BinFunc(func(ctx context.Context, op1, op2 interface{}) (interface{}, error) {
+		return op1.(int) + op2.(int), nil
+	}))
+	o.SetCombiner(api.BinFunc(func(ctx context.Context, op1, op2 interface{}) (interface{}, error) {
+		return nil, errCombine
+	}))
+	o.SetConcurrency(2)
+	in := make(chan interface{})
+	go func() {
+		in <- 1
+		in <- 2
+		close(in)
+	}()
+	o.SetInput(in)
+
+	drain := make(chan error, 1)
+	o.Exec(drain)
+	for out := range o.GetOutput() {
+		t.Fatal("unexpected output ", out)
+	}
+	if err := <-drain; !errors.Is(err, errCombine) {
+		t.Fatal("unexpected error ", err)
+	}
+}
+
+func TestBinaryOp_EmitInterval(t *testing.T) {
+	o := New(context.Background())
+	o.SetInitialState(0)
+	o.SetOperation(api.BinFunc(func(ctx context.Context, op1, op2 interface{}) (interface{}, error) {
+		return op1.(int) + op2.(int), nil
+	}))
+	o.SetEmitInterval(5 * time.Millisecond)
+	in := make(chan interface{})
+	go func() {
+		for i := 1; i <= 4; i++ {
+			in <- i
+			time.Sleep(10 * time.Millisecond)
+		}
+		close(in)
+	}()
+	o.SetInput(in)
+	o.Exec(make(chan error, 1))
+
+	var results []int
+	for out := range o.GetOutput() {
+		results = append(results, out.(int))
+	}
+	if len(results) < 3 || results[len(results)-1] != 10 {
+		t.Fatal("expecting intermediate sums and a final sum of 10, got ", results)
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i] < results[i-1] {
+			t.Fatal("expecting growing sums, got ", results)
+		}
+	}
+}
+
+func TestBinaryOp_EmitCopy(t *testing.T) {
+	o := New(context.Background())
+	o.SetInitialState(map[int]int{})
+	o.SetOperation(api.BinFunc(func(ctx context.Context, op1, op2 interface{}) (interface{}, error) {
+		counts := op1.(map[int]int)
+		counts[op2.(int)%4]++
+		return counts, nil
+	}))
+	o.SetConcurrency(4)
+	o.SetEmitCount(1)
+	in := make(chan interface{})
+	go func() {
+		for i := 0; i < 200; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+	o.SetInput(in)
+	o.Exec(make(chan error, 1))
+
+	// emitted states are read while the workers keep counting
+	var totals []int
+	for out := range o.GetOutput() {
+		total := 0
+		for _, count := range out.(map[int]int) {
+			total += count
+		}
+		totals = append(totals, total)
+	}
+	if len(totals) != 200 {
+		t.Fatal("expecting a state per item, got ", len(totals))
+	}
+	for i, total := range totals {
+		if total != i+1 {
+			t.Fatal("expecting the states to be emitted in order, got ", totals)
+		}
+	}
+}
+
+func TestBinaryOp_EmitCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		count    int
+		items    int
+		expected []int
+	}{
+		{name: "each item", count: 1, items: 4, expected: []int{1, 3, 6, 10}},
+		{name: "each two items", count: 2, items: 5, expected: []int{3, 10, 15}},
+		{name: "no item", count: 1, items: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			o := New(context.Background())
+			o.SetInitialState(0)
+			o.SetOperation(api.BinFunc(func(ctx context.Context, op1, op2 interface{}) (interface{}, error) {
+				return op1.(int) + op2.(int), nil
+			}))
+			o.SetEmitCount(test.count)
+			in := make(chan interface{})
+			go func() {
+				for i := 1; i <= test.items; i++ {
+					in <- i
+				}
+				close(in)
+			}()
+			o.SetInput(in)
+			o.Exec(make(chan error, 1))
+
+			var results []int
+			for out := range o.GetOutput() {
+				results = append(results, out.(int))
+			}
+			if fmt.Sprint(results) != fmt.Sprint(test.expected) {
+				t.Fatalf("expecting %v, got %v", test.expected, results)
+			}
+		})
+	}
+}
+
+func TestBinaryOp_StateStore(t *testing.T) {
+	store := state.NewMemory()
+	run := func(items ...int) []interface{} {
+		ctx := autoctx.WithNode(context.Background(), &autoctx.Node{Name: "total"})
+		o := New(ctx)
+		o.SetInitialState(0)
+		o.SetOperation(api.BinFunc(func(ctx context.Context, op1, op2 interface{}) (interface{}, error) {
+			return op1.(int) + op2.(int), nil
+		}))
+		o.SetStateStore(store, state.Gob())
+		o.SetEmitCount(1)
+		in := make(chan interface{})
+		go func() {
+			for _, i := range items {
+				in <- i
+			}
+			close(in)
+		}()
+		o.SetInput(in)
+
+		drain := make(chan error, 1)
+		o.Exec(drain)
+		var results []interface{}
+		for out := range o.GetOutput() {
+			results = append(results, out)
+		}
+		select {
+		case err := <-drain:
+			t.Fatal(err)
+		default:
+		}
+		return results
+	}
+
+	if results := run(1, 2); fmt.Sprint(results) != "[1 3]" {
+		t.Fatal("unexpected results ", results)
+	}
+	// the scan resumes from its saved state
+	if results := run(3); fmt.Sprint(results) != "[6]" {
+		t.Fatal("expecting state to be restored, got ", results)
+	}
+	if _, ok, _ := store.Get("total"); !ok {
+		t.Fatal("expecting state saved under the operator name")
+	}
+}
+
+func TestBinaryOp_StateStore_Cancel(t *testing.T) {
+	store := state.NewMemory()
+	ctx, cancel := context.WithCancel(autoctx.WithNode(context.Background(), &autoctx.Node{Name: "total"}))
+	o := New(ctx)
+	o.SetInitialState(0)
+	o.SetOperation(api.BinFunc(func(ctx context.Context, op1, op2 interface{}) (interface{}, error) {
+		return op1.(int) + op2.(int), nil
+	}))
+	o.SetStateStore(store, state.JSON(0))
+	in := make(chan interface{})
+	o.SetInput(in)
+	o.Exec(make(chan error, 1))
+	in <- 4
+	in <- 5
+	cancel()
+	for out := range o.GetOutput() {
+		t.Fatal("unexpected output ", out)
+	}
+	if data, _, _ := store.Get("total"); string(data) != "9" {
+		t.Fatal("expecting state to be saved on cancel, got ", string(data))
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"reflect"
 
 	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/util"
 )
 
 // ReduceFunc returns a binary function which takes a user-defined accumulator
@@ -43,6 +44,24 @@ func ReduceFunc(f interface{}) (api.BinFunc, error) {
 
 }
 
+// ReduceOf returns a binary function which applies the type-safe accumulator f
+// to the partial result of type S and the incoming item of type T.  Unlike
+// ReduceFunc, the signature is checked at compile time and f is invoked
+// directly without reflection.
+func ReduceOf[S, T any](f func(S, T) S) api.BinFunc {
+	return api.BinFunc(func(ctx context.Context, op0, op1 interface{}) (interface{}, error) {
+		state, err := util.Cast[S](op0)
+		if err != nil {
+			return nil, err
+		}
+		item, err := util.Cast[T](op1)
+		if err != nil {
+			return nil, err
+		}
+		return f(state, item), nil
+	})
+}
+
 func isBinaryFuncForm(ftype reflect.Type) error {
 	errorInterface := reflect.TypeOf((*error)(nil)).Elem()
 	switch ftype.Kind() {
@@ -47,3 +47,27 @@ func TestBinaryFunc_Reduce_WithError(t *testing.T) {
 		}
 	}
 }
+
+func TestBinaryFunc_ReduceOf(t *testing.T) {
+	op := ReduceOf(func(sum int, item string) int {
+		return sum + len(item)
+	})
+
+	var state interface{} = 0
+	ctx := context.TODO()
+	for _, v := range []string{"A", "BB", "CCC"} {
+		result, err := op.Apply(ctx, state, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		state = result
+	}
+
+	if state.(int) != 6 {
+		t.Fatal("unexpected result from ReduceOf: ", state)
+	}
+
+	if _, err := op.Apply(ctx, 0, 12); err == nil {
+		t.Fatal("expecting type mismatch error")
+	}
+}
@@ -0,0 +1,202 @@
+package branch
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/go-faces/logger"
+	"github.com/gofunky/automi/api"
+	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/util"
+)
+
+// DropPolicy determines what happens to an item when the
+// buffer of a branch is full.
+type DropPolicy int
+
+const (
+	// Block waits until the branch has room for the item.
+	// The slowest branch governs the pace of the whole stream.
+	Block DropPolicy = iota
+	// DropNewest discards the incoming item when the branch buffer is full.
+	DropNewest
+	// DropOldest discards the oldest buffered item of the branch
+	// to make room for the incoming item.
+	DropOldest
+)
+
+// Backpressure specifies the buffering and drop policy of each branch
+type Backpressure struct {
+	Buffer int
+	Policy DropPolicy
+}
+
+// Blocking returns a backpressure where the slowest branch governs
+// the stream.  This is the default backpressure.
+func Blocking() Backpressure {
+	return Backpressure{Buffer: 1024, Policy: Block}
+}
+
+// Buffered returns a backpressure where each branch buffers up to size
+// items.  When a branch buffer is full, the drop policy is applied so that
+// a slow branch does not hold back the other ones.
+func Buffered(size int, policy DropPolicy) Backpressure {
+	return Backpressure{Buffer: size, Policy: policy}
+}
+
+// BranchOperator is a node that terminates a stream and sends each
+// incoming item to every one of its branches.  Each branch is exposed
+// as an api.Source that can be used to feed another stream.
+type BranchOperator struct {
+	ctx      context.Context
+	input    <-chan interface{}
+	outputs  []chan interface{}
+	dropped  []int64
+	pressure Backpressure
+	log      logger.Interface
+}
+
+// New creates a *BranchOperator with count branches
+func New(ctx context.Context, count int) *BranchOperator {
+	log := autoctx.GetLogger(ctx)
+
+	o := new(BranchOperator)
+	o.ctx = ctx
+	o.log = log
+	o.outputs = make([]chan interface{}, count)
+	o.dropped = make([]int64, count)
+	o.SetBackpressure(Blocking())
+
+	util.Log(o.log, "branch operator initialized")
+	return o
+}
+
+// SetBackpressure sets the buffering and drop policy of the branches.
+// It must be called prior to retrieving the branches.
+func (o *BranchOperator) SetBackpressure(bp Backpressure) {
+	if bp.Buffer < 1 {
+		bp.Buffer = 1
+	}
+	o.pressure = bp
+	for i := range o.outputs {
+		o.outputs[i] = make(chan interface{}, bp.Buffer)
+	}
+}
+
+// SetInput sets the input channel for the node
+func (o *BranchOperator) SetInput(in <-chan interface{}) {
+	o.input = in
+}
+
+// Count returns the number of branches
+func (o *BranchOperator) Count() int {
+	return len(o.outputs)
+}
+
+// Branch returns the source for the branch at index i
+func (o *BranchOperator) Branch(i int) api.Source {
+	return &branchSource{output: o.outputs[i]}
+}
+
+// Dropped returns the number of items discarded for the branch at index i
+func (o *BranchOperator) Dropped(i int) int64 {
+	return atomic.LoadInt64(&o.dropped[i])
+}
+
+// Open starts sending the incoming items to all the branches.
+// The returned channel is closed once the input is drained and
+// all branches are closed.
+func (o *BranchOperator) Open(ctx context.Context) <-chan error {
+	result := make(chan error)
+	if o.input == nil {
+		go func() { result <- errors.New("branch operator missing input") }()
+		return result
+	}
+	if len(o.outputs) == 0 {
+		go func() { result <- errors.New("branch operator has no branches") }()
+		return result
+	}
+
+	go func() {
+		defer func() {
+			for _, output := range o.outputs {
+				close(output)
+			}
+			util.Log(o.log, "closing branch operator")
+			close(result)
+		}()
+
+		for {
+			select {
+			case item, opened := <-o.input:
+				if !opened {
+					return
+				}
+				for i := range o.outputs {
+					if !o.send(ctx, i, item) {
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return result
+}
+
+// send delivers item to branch i according to the drop policy.
+// It returns false if the context is done.
+func (o *BranchOperator) send(ctx context.Context, i int, item interface{}) bool {
+	output := o.outputs[i]
+	switch o.pressure.Policy {
+	case DropNewest:
+		select {
+		case output <- item:
+		default:
+			o.drop(i, item)
+		}
+	case DropOldest:
+		for {
+			select {
+			case output <- item:
+				return true
+			default:
+			}
+			select {
+			case old := <-output:
+				o.drop(i, old)
+			default:
+			}
+		}
+	default:
+		select {
+		case output <- item:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+func (o *BranchOperator) drop(i int, item interface{}) {
+	atomic.AddInt64(&o.dropped[i], 1)
+	util.Logf(o.log, "branch %d is full, dropping item", i)
+}
+
+// branchSource exposes a branch output as an api.Source
+type branchSource struct {
+	output <-chan interface{}
+}
+
+// GetOutput returns the output channel of the branch
+func (s *branchSource) GetOutput() <-chan interface{} {
+	return s.output
+}
+
+// Open is a no-op since the branch is fed by its BranchOperator
+func (s *branchSource) Open(ctx context.Context) error {
+	return nil
+}
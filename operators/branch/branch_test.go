@@ -0,0 +1,117 @@
+package branch
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBranchOp_New(t *testing.T) {
+	o := New(context.Background(), 3)
+	if o.Count() != 3 {
+		t.Fatal("unexpected branch count ", o.Count())
+	}
+	if o.pressure.Policy != Block {
+		t.Fatal("expecting blocking backpressure by default")
+	}
+	for i := 0; i < o.Count(); i++ {
+		if o.Branch(i).GetOutput() == nil {
+			t.Fatal("branch output not set")
+		}
+	}
+}
+
+func TestBranchOp_Open(t *testing.T) {
+	o := New(context.Background(), 2)
+	in := make(chan interface{})
+	go func() {
+		in <- "A"
+		in <- "B"
+		in <- "C"
+		close(in)
+	}()
+	o.SetInput(in)
+
+	var m sync.Mutex
+	counts := make([]int, o.Count())
+	var wg sync.WaitGroup
+	for i := 0; i < o.Count(); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for range o.Branch(i).GetOutput() {
+				m.Lock()
+				counts[i]++
+				m.Unlock()
+			}
+		}(i)
+	}
+
+	select {
+	case err := <-o.Open(context.Background()):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+	wg.Wait()
+
+	m.Lock()
+	defer m.Unlock()
+	for i, count := range counts {
+		if count != 3 {
+			t.Fatalf("branch %d expecting 3 items, got %d", i, count)
+		}
+	}
+}
+
+func TestBranchOp_DropPolicies(t *testing.T) {
+	tests := []struct {
+		policy   DropPolicy
+		expected []interface{}
+	}{
+		{policy: DropNewest, expected: []interface{}{1, 2}},
+		{policy: DropOldest, expected: []interface{}{4, 5}},
+	}
+
+	for _, test := range tests {
+		o := New(context.Background(), 1)
+		o.SetBackpressure(Buffered(2, test.policy))
+		in := make(chan interface{})
+		go func() {
+			for i := 1; i <= 5; i++ {
+				in <- i
+			}
+			close(in)
+		}()
+		o.SetInput(in)
+
+		// nothing reads the branch until the input is drained
+		select {
+		case err := <-o.Open(context.Background()):
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(50 * time.Millisecond):
+			t.Fatal("Took too long")
+		}
+
+		var result []interface{}
+		for item := range o.Branch(0).GetOutput() {
+			result = append(result, item)
+		}
+		if len(result) != len(test.expected) {
+			t.Fatalf("policy %d expecting %v, got %v", test.policy, test.expected, result)
+		}
+		for i := range result {
+			if result[i] != test.expected[i] {
+				t.Fatalf("policy %d expecting %v, got %v", test.policy, test.expected, result)
+			}
+		}
+		if o.Dropped(0) != 3 {
+			t.Fatalf("policy %d expecting 3 dropped items, got %d", test.policy, o.Dropped(0))
+		}
+	}
+}
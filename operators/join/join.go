@@ -0,0 +1,384 @@
+package join
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-faces/logger"
+	"github.com/gofunky/automi/api"
+	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/api/tuple"
+	"github.com/gofunky/automi/metrics"
+	"github.com/gofunky/automi/tracing"
+	"github.com/gofunky/automi/util"
+)
+
+// Kind is the type of join applied by a JoinOperator
+type Kind int
+
+const (
+	// Inner emits only the pairs of matched items.
+	Inner Kind = iota
+	// Left emits the pairs of matched items and also emits left items
+	// that were never matched as tuple.Pair{left, nil}.
+	Left
+	// Outer emits the pairs of matched items, the unmatched left items as
+	// tuple.Pair{left, nil} and the unmatched right items as tuple.Pair{nil, right}.
+	Outer
+)
+
+// Retention bounds how long items are buffered while waiting for a match.
+// When both bounds are zero, items are retained until the end of the
+// stream, including the items that were matched, since they may match
+// later items.  Memory then grows with the input, unbounded sources
+// require a bound or a one-to-one join.
+type Retention struct {
+	// Window is how long an item is retained after its arrival.
+	Window time.Duration
+	// MaxItems is the maximum number of items buffered on each side.
+	MaxItems int
+	// OneToOne matches each item with at most one item of the other side,
+	// the oldest buffered one with the same key.  Matched items are no
+	// longer buffered, so that only the items waiting for a match are kept.
+	OneToOne bool
+}
+
+// Within returns a retention that keeps items for duration d
+func Within(d time.Duration) Retention {
+	return Retention{Window: d}
+}
+
+// MaxItems returns a retention that keeps at most n items on each side
+func MaxItems(n int) Retention {
+	return Retention{MaxItems: n}
+}
+
+// OneToOne returns a retention that keeps the items until they
+// are matched with a single item of the other side
+func OneToOne() Retention {
+	return Retention{OneToOne: true}
+}
+
+// entry is a buffered item waiting for a match, along with
+// the context of its span, see tracing.Recorder
+type entry struct {
+	key     interface{}
+	item    interface{}
+	ctx     context.Context
+	arrived time.Time
+	matched bool
+	removed bool
+}
+
+// side holds the buffered items of one of the joined streams.  Entries
+// removed when they are matched are dropped lazily, from the front or once
+// they make up most of the entries, live is the number of entries that
+// were not removed.
+type side struct {
+	entries []*entry
+	index   map[interface{}][]*entry
+	live    int
+}
+
+func newSide() *side {
+	return &side{index: make(map[interface{}][]*entry)}
+}
+
+func (s *side) add(e *entry) {
+	s.entries = append(s.entries, e)
+	s.index[e.key] = append(s.index[e.key], e)
+	s.live++
+}
+
+// first returns the oldest entry, if any
+func (s *side) first() *entry {
+	for len(s.entries) > 0 && s.entries[0].removed {
+		s.entries[0] = nil
+		s.entries = s.entries[1:]
+	}
+	if len(s.entries) == 0 {
+		return nil
+	}
+	return s.entries[0]
+}
+
+// evictFirst removes and returns the oldest entry
+func (s *side) evictFirst() *entry {
+	e := s.first()
+	s.entries[0] = nil
+	s.entries = s.entries[1:]
+	s.unindex(e)
+	s.live--
+	return e
+}
+
+// remove removes e, which is dropped from the entries later
+func (s *side) remove(e *entry) {
+	e.removed = true
+	s.unindex(e)
+	s.live--
+	if len(s.entries) > 64 && len(s.entries) > 2*s.live {
+		entries := make([]*entry, 0, s.live)
+		for _, e := range s.entries {
+			if !e.removed {
+				entries = append(entries, e)
+			}
+		}
+		s.entries = entries
+	}
+}
+
+func (s *side) unindex(e *entry) {
+	keyed := s.index[e.key]
+	for i := range keyed {
+		if keyed[i] == e {
+			keyed = append(keyed[:i], keyed[i+1:]...)
+			break
+		}
+	}
+	if len(keyed) == 0 {
+		delete(s.index, e.key)
+	} else {
+		s.index[e.key] = keyed
+	}
+}
+
+// JoinOperator is an executor node that correlates the items of two
+// streams, left and right, using a key extracted from each item.
+// Matched items are emitted downstream as tuple.Pair{left, right}.
+type JoinOperator struct {
+	ctx       context.Context
+	kind      Kind
+	leftKey   api.UnOperation
+	rightKey  api.UnOperation
+	retention Retention
+	input     <-chan interface{}
+	right     <-chan interface{}
+	output    chan interface{}
+	log       logger.Interface
+	metrics   *metrics.Recorder
+	trace     *tracing.Recorder
+}
+
+// New creates a *JoinOperator value
+func New(ctx context.Context) *JoinOperator {
+	log := autoctx.GetLogger(ctx)
+
+	o := new(JoinOperator)
+	o.ctx = ctx
+	o.log = log
+	o.metrics = metrics.NewRecorder(ctx, "join")
+	o.trace = tracing.NewRecorder(ctx, "join")
+	o.output = make(chan interface{}, 1024)
+
+	util.Log(o.log, "join operator initialized")
+	return o
+}
+
+// SetKind sets the type of join to apply
+func (o *JoinOperator) SetKind(kind Kind) {
+	o.kind = kind
+}
+
+// SetKeys sets the operations that extract the join key of
+// the left and the right items respectively.
+func (o *JoinOperator) SetKeys(left, right api.UnOperation) {
+	o.leftKey = left
+	o.rightKey = right
+}
+
+// SetRetention sets how long unmatched items are buffered
+func (o *JoinOperator) SetRetention(r Retention) {
+	o.retention = r
+}
+
+// SetInput sets the left input channel of the executor node
+func (o *JoinOperator) SetInput(in <-chan interface{}) {
+	o.input = in
+}
+
+// SetRightInput sets the right input channel of the executor node
+func (o *JoinOperator) SetRightInput(in <-chan interface{}) {
+	o.right = in
+}
+
+// RightCollector returns an api.Sink which can terminate another
+// stream to feed the right input of the operator.
+func (o *JoinOperator) RightCollector() api.Sink {
+	return &rightCollector{op: o}
+}
+
+// GetOutput returns the output channel of the executor node
+func (o *JoinOperator) GetOutput() <-chan interface{} {
+	return o.output
+}
+
+// Exec is the entry point for the executor
+func (o *JoinOperator) Exec(drain chan<- error) {
+	if o.input == nil || o.right == nil {
+		drain <- fmt.Errorf("join operator missing input channel")
+		return
+	}
+	if o.leftKey == nil || o.rightKey == nil {
+		drain <- errors.New("join operator missing key operation")
+		return
+	}
+
+	go func() {
+		defer func() {
+			util.Log(o.log, "join operator closing")
+			close(o.output)
+		}()
+		if err := o.doProc(o.ctx); err != nil {
+			util.Log(o.log, err)
+			drain <- err
+		}
+	}()
+}
+
+func (o *JoinOperator) doProc(ctx context.Context) error {
+	left, right := newSide(), newSide()
+	leftIn, rightIn := o.input, o.right
+
+	var tick <-chan time.Time
+	if o.retention.Window > 0 {
+		ticker := time.NewTicker(o.retention.Window / 2)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for leftIn != nil || rightIn != nil {
+		select {
+		case item, opened := <-leftIn:
+			if !opened {
+				leftIn = nil
+				continue
+			}
+			if err := o.join(ctx, item, o.leftKey, left, right, true); err != nil {
+				return err
+			}
+		case item, opened := <-rightIn:
+			if !opened {
+				rightIn = nil
+				continue
+			}
+			if err := o.join(ctx, item, o.rightKey, right, left, false); err != nil {
+				return err
+			}
+		case now := <-tick:
+			o.evict(left, true, now)
+			o.evict(right, false, now)
+		case <-ctx.Done():
+			util.Log(o.log, "join operator cancelling...")
+			return nil
+		}
+	}
+
+	// flush unmatched items
+	for left.live > 0 {
+		o.emitUnmatched(left.evictFirst(), true)
+	}
+	for right.live > 0 {
+		o.emitUnmatched(right.evictFirst(), false)
+	}
+	return nil
+}
+
+// join matches item against the items buffered on the other side and
+// then buffers the item on its own side.  The pairs of matched items are
+// traced as results of item.
+func (o *JoinOperator) join(ctx context.Context, item interface{}, keyOp api.UnOperation, own, other *side, isLeft bool) (err error) {
+	o.metrics.In()
+	itemCtx, item := o.trace.Start(ctx, item)
+	defer func() { o.trace.End(itemCtx, err) }()
+	key, err := keyOp.Apply(itemCtx, item)
+	if err != nil {
+		o.metrics.Error()
+		return err
+	}
+	if key != nil && !reflect.TypeOf(key).Comparable() {
+		return fmt.Errorf("join key of type %T is not comparable", key)
+	}
+
+	e := &entry{key: key, item: item, ctx: itemCtx, arrived: time.Now()}
+	if o.retention.OneToOne {
+		// the items of a one-to-one join are matched once
+		if matches := other.index[key]; len(matches) > 0 {
+			match := matches[0]
+			other.remove(match)
+			if isLeft {
+				o.send(o.trace.Wrap(itemCtx, tuple.Pair{item, match.item}))
+			} else {
+				o.send(o.trace.Wrap(itemCtx, tuple.Pair{match.item, item}))
+			}
+			return nil
+		}
+	}
+	for _, match := range other.index[key] {
+		match.matched = true
+		e.matched = true
+		if isLeft {
+			o.send(o.trace.Wrap(itemCtx, tuple.Pair{item, match.item}))
+		} else {
+			o.send(o.trace.Wrap(itemCtx, tuple.Pair{match.item, item}))
+		}
+	}
+	own.add(e)
+
+	if o.retention.MaxItems > 0 {
+		for own.live > o.retention.MaxItems {
+			o.emitUnmatched(own.evictFirst(), isLeft)
+		}
+	}
+	return nil
+}
+
+// evict removes the entries that have outlived the retention window
+func (o *JoinOperator) evict(s *side, isLeft bool, now time.Time) {
+	for e := s.first(); e != nil && now.Sub(e.arrived) >= o.retention.Window; e = s.first() {
+		o.emitUnmatched(s.evictFirst(), isLeft)
+	}
+}
+
+// emitUnmatched emits an evicted entry that was never matched
+// when required by the kind of join.
+func (o *JoinOperator) emitUnmatched(e *entry, isLeft bool) {
+	if e.matched {
+		return
+	}
+	switch {
+	case isLeft && (o.kind == Left || o.kind == Outer):
+		o.send(o.trace.Wrap(e.ctx, tuple.Pair{e.item, nil}))
+	case !isLeft && o.kind == Outer:
+		o.send(o.trace.Wrap(e.ctx, tuple.Pair{nil, e.item}))
+	}
+}
+
+// rightCollector terminates a stream into the right input of a JoinOperator
+type rightCollector struct {
+	op *JoinOperator
+}
+
+// SetInput sets the right input of the join operator
+func (c *rightCollector) SetInput(in <-chan interface{}) {
+	c.op.SetRightInput(in)
+}
+
+// Open returns a closed channel since the items are consumed by the join operator
+func (c *rightCollector) Open(ctx context.Context) <-chan error {
+	result := make(chan error)
+	close(result)
+	return result
+}
+
+// send sends item downstream unless the context is done
+func (o *JoinOperator) send(item interface{}) {
+	select {
+	case o.output <- item:
+		o.metrics.Out(o.output)
+	case <-o.ctx.Done():
+	}
+}
@@ -0,0 +1,164 @@
+package join
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/api/tuple"
+)
+
+func firstChar() api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) (interface{}, error) {
+		return data.(string)[:1], nil
+	})
+}
+
+func runJoin(t *testing.T, o *JoinOperator, left, right []string) []tuple.Pair {
+	leftIn := make(chan interface{})
+	rightIn := make(chan interface{})
+	go func() {
+		for _, item := range left {
+			leftIn <- item
+		}
+		close(leftIn)
+	}()
+	go func() {
+		// let the left side be buffered first
+		time.Sleep(5 * time.Millisecond)
+		for _, item := range right {
+			rightIn <- item
+		}
+		close(rightIn)
+	}()
+	o.SetInput(leftIn)
+	o.RightCollector().SetInput(rightIn)
+
+	var result []tuple.Pair
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for item := range o.GetOutput() {
+			result = append(result, item.(tuple.Pair))
+		}
+	}()
+
+	drain := make(chan error)
+	o.Exec(drain)
+
+	select {
+	case <-wait:
+	case err := <-drain:
+		t.Fatal(err)
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+	return result
+}
+
+func count(pairs []tuple.Pair, matched, leftOnly, rightOnly *int) {
+	for _, pair := range pairs {
+		switch {
+		case pair[0] != nil && pair[1] != nil:
+			*matched++
+		case pair[1] == nil:
+			*leftOnly++
+		default:
+			*rightOnly++
+		}
+	}
+}
+
+func TestJoinOp_Kinds(t *testing.T) {
+	tests := []struct {
+		kind                         Kind
+		matched, leftOnly, rightOnly int
+	}{
+		{kind: Inner, matched: 3},
+		{kind: Left, matched: 3, leftOnly: 1},
+		{kind: Outer, matched: 3, leftOnly: 1, rightOnly: 1},
+	}
+
+	for _, test := range tests {
+		o := New(context.Background())
+		o.SetKind(test.kind)
+		o.SetKeys(firstChar(), firstChar())
+		result := runJoin(t, o, []string{"apple", "banana", "cherry"}, []string{"avocado", "apricot", "blueberry", "date"})
+
+		matched, leftOnly, rightOnly := 0, 0, 0
+		count(result, &matched, &leftOnly, &rightOnly)
+		if matched != test.matched || leftOnly != test.leftOnly || rightOnly != test.rightOnly {
+			t.Fatalf("kind %d: unexpected result %v", test.kind, result)
+		}
+	}
+}
+
+func TestJoinOp_MaxItems(t *testing.T) {
+	o := New(context.Background())
+	o.SetKind(Left)
+	o.SetKeys(firstChar(), firstChar())
+	o.SetRetention(MaxItems(1))
+	result := runJoin(t, o, []string{"apple", "banana"}, []string{"avocado", "blueberry"})
+
+	// apple is evicted before the right items arrive
+	matched, leftOnly, rightOnly := 0, 0, 0
+	count(result, &matched, &leftOnly, &rightOnly)
+	if matched != 1 || leftOnly != 1 {
+		t.Fatal("unexpected result ", result)
+	}
+}
+
+func TestJoinOp_Window(t *testing.T) {
+	o := New(context.Background())
+	o.SetKeys(firstChar(), firstChar())
+	o.SetRetention(Within(time.Millisecond))
+	result := runJoin(t, o, []string{"apple"}, []string{"avocado"})
+
+	if len(result) != 0 {
+		t.Fatal("expecting expired item not to be matched, got ", result)
+	}
+}
+
+func TestJoinOp_OneToOne(t *testing.T) {
+	o := New(context.Background())
+	o.SetKind(Outer)
+	o.SetKeys(firstChar(), firstChar())
+	o.SetRetention(OneToOne())
+	result := runJoin(t, o, []string{"apple", "banana", "avocado"}, []string{"apricot", "blueberry", "almond", "anise"})
+
+	// apricot and almond match apple and avocado in order, anise is left unmatched
+	matched, leftOnly, rightOnly := 0, 0, 0
+	count(result, &matched, &leftOnly, &rightOnly)
+	if matched != 3 || leftOnly != 0 || rightOnly != 1 {
+		t.Fatal("unexpected result ", result)
+	}
+	if result[0][0] != "apple" || result[0][1] != "apricot" || result[2][0] != "avocado" || result[2][1] != "almond" {
+		t.Fatal("expecting the oldest items to be matched first, got ", result)
+	}
+}
+
+func TestJoinOp_MissingKeys(t *testing.T) {
+	o := New(context.Background())
+	o.SetInput(make(chan interface{}))
+	o.SetRightInput(make(chan interface{}))
+	drain := make(chan error, 1)
+	o.Exec(drain)
+	if err := <-drain; err == nil {
+		t.Fatal("expecting missing key error")
+	}
+}
+
+func TestSide_Remove(t *testing.T) {
+	s := newSide()
+	head := &entry{key: "a"}
+	s.add(head)
+	for i := 0; i < 1000; i++ {
+		e := &entry{key: i}
+		s.add(e)
+		s.remove(e)
+	}
+	if s.live != 1 || len(s.entries) > 130 || s.first() != head {
+		t.Fatal("expecting removed entries to be dropped, got ", len(s.entries))
+	}
+}
@@ -0,0 +1,86 @@
+package keyed
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/util"
+)
+
+// ProcessFunc returns a keyed function which applies the specified
+// user-defined function to each item along with the state of its key,
+// and returns a result value.  The provided function must be of type:
+//
+//	func(api.State, T) R
+//	func(api.State, T) (R, error)
+//	  where T is the type of incoming item
+//	  R the type of returned processed item
+//
+// A nil result, such as a nil pointer, is not sent downstream.
+func ProcessFunc(f interface{}) (api.KeyedFunc, error) {
+	fntype := reflect.TypeOf(f)
+	if err := isKeyedFuncForm(fntype); err != nil {
+		return nil, err
+	}
+
+	fnval := reflect.ValueOf(f)
+
+	return api.KeyedFunc(func(ctx context.Context, state api.State, data interface{}) (result interface{}, err error) {
+		arg1 := reflect.ValueOf(data)
+		if data == nil {
+			arg1 = reflect.Zero(fntype.In(1))
+		}
+		call := fnval.Call([]reflect.Value{reflect.ValueOf(state), arg1})
+		result = dropNil(call[0].Interface())
+		if len(call) > 1 && !call[1].IsNil() {
+			err = call[1].Interface().(error)
+		}
+		return
+	}), nil
+}
+
+// ProcessOf returns a keyed function which applies the type-safe
+// user-defined function f to each incoming item of type T along with the
+// state of its key.  Unlike ProcessFunc, the signature is checked at
+// compile time and f is invoked directly without reflection.  A nil
+// result, such as a nil pointer, is not sent downstream.
+func ProcessOf[T, R any](f func(api.State, T) (R, error)) api.KeyedFunc {
+	return api.KeyedFunc(func(ctx context.Context, state api.State, data interface{}) (interface{}, error) {
+		item, err := util.Cast[T](data)
+		if err != nil {
+			return nil, err
+		}
+		result, err := f(state, item)
+		return dropNil(result), err
+	})
+}
+
+// dropNil returns nil for nil pointers, so that they are not sent downstream
+func dropNil(result interface{}) interface{} {
+	if val := reflect.ValueOf(result); val.Kind() == reflect.Ptr && val.IsNil() {
+		return nil
+	}
+	return result
+}
+
+// isKeyedFuncForm ensures type is a function of form
+// func(api.State, in)out or func(api.State, in)(out, error).
+func isKeyedFuncForm(ftype reflect.Type) error {
+	errorInterface := reflect.TypeOf((*error)(nil)).Elem()
+	stateInterface := reflect.TypeOf((*api.State)(nil)).Elem()
+	if ftype == nil || ftype.Kind() != reflect.Func {
+		return fmt.Errorf("keyed func %v must be of type func(api.State, T)R or func(api.State, T)(R, error)", ftype)
+	}
+	if ftype.NumIn() != 2 || ftype.In(0) != stateInterface {
+		return fmt.Errorf("keyed func %v must take an api.State and an item", ftype)
+	}
+	if ftype.NumOut() == 0 || ftype.NumOut() > 2 {
+		return fmt.Errorf("keyed func %v must return one value or two with the second being an error", ftype)
+	}
+	if ftype.NumOut() == 2 && !ftype.Out(1).Implements(errorInterface) {
+		return fmt.Errorf("the second return value's type of the keyed func %v must be an error", ftype)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package keyed
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gofunky/automi/api"
+)
+
+func TestKeyedFunc_Process(t *testing.T) {
+	states := newMemStates(0)
+	op, err := ProcessFunc(func(state api.State, word string) *string {
+		if _, ok := state.Get(); ok {
+			return nil
+		}
+		state.Put(true)
+		return &word
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := op.Apply(context.TODO(), states.state("a", "string:a"), "apple")
+	if err != nil || *result.(*string) != "apple" {
+		t.Fatal("unexpected result ", result, err)
+	}
+	result, err = op.Apply(context.TODO(), states.state("a", "string:a"), "avocado")
+	if err != nil || result != nil {
+		t.Fatal("expecting duplicate to be dropped, got ", result, err)
+	}
+}
+
+func TestKeyedFunc_Process_Invalid(t *testing.T) {
+	for _, f := range []interface{}{
+		"not a func",
+		func(word string) string { return word },
+		func(state api.State, word string) {},
+		func(state api.State, word string) (string, string) { return word, word },
+	} {
+		if _, err := ProcessFunc(f); err == nil {
+			t.Fatalf("expecting error for %T", f)
+		}
+	}
+}
+
+func TestKeyedFunc_ProcessOf(t *testing.T) {
+	errBad := errors.New("bad count")
+	op := ProcessOf(func(state api.State, n int) (int, error) {
+		if n < 0 {
+			return 0, errBad
+		}
+		total, _ := state.Get()
+		if total == nil {
+			total = 0
+		}
+		state.Put(total.(int) + n)
+		return total.(int) + n, nil
+	})
+	states := newMemStates(0)
+	for _, n := range []int{1, 2} {
+		if _, err := op.Apply(context.TODO(), states.state("k", "string:k"), n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if total, _ := states.state("k", "string:k").Get(); total != 3 {
+		t.Fatal("expecting total of 3, got ", total)
+	}
+	if _, err := op.Apply(context.TODO(), states.state("k", "string:k"), -1); !errors.Is(err, errBad) {
+		t.Fatal("unexpected error ", err)
+	}
+	if _, err := op.Apply(context.TODO(), states.state("k", "string:k"), "one"); err == nil {
+		t.Fatal("expecting type error")
+	}
+}
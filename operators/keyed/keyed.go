@@ -0,0 +1,388 @@
+package keyed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-faces/logger"
+	"github.com/gofunky/automi/api"
+	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/metrics"
+	"github.com/gofunky/automi/tracing"
+	"github.com/gofunky/automi/util"
+)
+
+// KeyedOperator is an executor node that applies a keyed operation to
+// each item along with the state of its key.  Items are partitioned by
+// key across shards, each processed by its own worker, so that the items
+// of a key are processed one at a time and in order.
+type KeyedOperator struct {
+	ctx       context.Context
+	key       api.UnOperation
+	op        api.KeyedOperation
+	policy    api.ErrorPolicy
+	hasPolicy bool
+	shards    int
+	ttl       time.Duration
+	store     api.StateStore
+	codec     api.Codec
+	input     <-chan interface{}
+	output    chan interface{}
+	log       logger.Interface
+	metrics   *metrics.Recorder
+	trace     *tracing.Recorder
+	cancelled bool
+	mutex     sync.RWMutex
+}
+
+// New creates a new *KeyedOperator
+func New(ctx context.Context) *KeyedOperator {
+	// extract logger
+	log := autoctx.GetLogger(ctx)
+
+	o := new(KeyedOperator)
+	o.ctx = ctx
+	o.log = log
+	o.metrics = metrics.NewRecorder(ctx, "keyed")
+	o.trace = tracing.NewRecorder(ctx, "keyed")
+	o.shards = 1
+	o.output = make(chan interface{}, 1024)
+
+	util.Log(o.log, "keyed operator initialized")
+	return o
+}
+
+// SetKeyExtractor sets the operation that returns the key of an item,
+// keys must be comparable
+func (o *KeyedOperator) SetKeyExtractor(key api.UnOperation) {
+	o.key = key
+}
+
+// SetOperation sets the keyed operation to execute
+func (o *KeyedOperator) SetOperation(op api.KeyedOperation) {
+	o.op = op
+}
+
+// SetErrorPolicy sets the policy applied to items whose key or operation
+// returns an error, or whose operation returns an error value as result.
+// By default, the operator fails on the first error returned, while error
+// values returned as result are logged and dropped.
+func (o *KeyedOperator) SetErrorPolicy(policy api.ErrorPolicy) {
+	o.policy = policy
+	o.hasPolicy = true
+}
+
+// SetConcurrency sets the number of shards, the items of a key are
+// always processed by the same shard
+func (o *KeyedOperator) SetConcurrency(concurr int) {
+	o.shards = concurr
+	if o.shards < 1 {
+		o.shards = 1
+	}
+}
+
+// SetStateTTL sets the time to live of the state of a key, which expires
+// once ttl elapsed since its value was last put.  By default, states
+// never expire.
+func (o *KeyedOperator) SetStateTTL(ttl time.Duration) {
+	o.ttl = ttl
+}
+
+// SetStateStore sets the store of the key states, whose values are encoded
+// with codec.  States are stored under the name of the operator and their
+// key, so that they are restored when an operator of the same name
+// starts.  Keys must then be strings, numbers or booleans, other keys are
+// rejected as set by the error policy.  By default, states are kept in
+// memory, without encoding.
+func (o *KeyedOperator) SetStateStore(store api.StateStore, codec api.Codec) {
+	o.store = store
+	o.codec = codec
+}
+
+// SetInput sets the input channel for the executor node
+func (o *KeyedOperator) SetInput(in <-chan interface{}) {
+	o.input = in
+}
+
+// GetOutput returns the output channel for the executor node
+func (o *KeyedOperator) GetOutput() <-chan interface{} {
+	return o.output
+}
+
+// Exec is the entry point for the executor
+func (o *KeyedOperator) Exec(drain chan<- error) {
+	if o.input == nil {
+		drain <- fmt.Errorf("no input channel found")
+		return
+	}
+	if o.key == nil || o.op == nil {
+		drain <- errors.New("keyed operator missing key extractor or operation")
+		return
+	}
+	if o.shards < 1 {
+		o.shards = 1
+	}
+	if o.store != nil && o.codec == nil {
+		drain <- errors.New("keyed operator missing state codec")
+		return
+	}
+
+	// shards share a context that is cancelled when one of them fails
+	exeCtx, cancel := context.WithCancel(o.ctx)
+
+	go func() {
+		failed := false
+		defer func() {
+			util.Log(o.log, "keyed operator closing")
+			close(o.output)
+			cancel()
+			if failed {
+				// keep upstream flowing, remaining items are discarded
+				for range o.input {
+				}
+			}
+		}()
+		fail := func(err error) {
+			o.mutex.Lock()
+			failed = true
+			o.mutex.Unlock()
+			cancel()
+			drain <- err
+		}
+
+		shards := make([]chan keyedItem, o.shards)
+		var barrier sync.WaitGroup
+		barrier.Add(o.shards)
+		for i := range shards {
+			shards[i] = make(chan keyedItem, 64)
+			go func(in <-chan keyedItem, states shardStates) {
+				defer barrier.Done()
+				if err := o.doProc(exeCtx, in, states); err != nil {
+					fail(err)
+				}
+			}(shards[i], o.newStates(i))
+		}
+
+		if err := o.dispatch(exeCtx, shards); err != nil {
+			fail(err)
+		}
+		for _, shard := range shards {
+			close(shard)
+		}
+
+		// shards return once their input is closed or the context
+		// is done, the output is closed after their last send
+		barrier.Wait()
+		switch {
+		case failed:
+			util.Log(o.log, "keyed operator failed")
+		case o.cancelled:
+			util.Log(o.log, "keyed operator cancelled")
+		}
+	}()
+}
+
+// keyedItem is an item along with its key, the id of the key, and
+// the context that carries the span of the item
+type keyedItem struct {
+	ctx  context.Context
+	key  interface{}
+	id   string
+	item interface{}
+}
+
+// newStates returns the states of the keys of shard
+func (o *KeyedOperator) newStates(shard int) shardStates {
+	if o.store == nil {
+		return newMemStates(o.ttl)
+	}
+	return &storeStates{
+		store:  o.store,
+		codec:  o.codec,
+		prefix: autoctx.GetNodeName(o.ctx, "keyed") + "/",
+		ttl:    o.ttl,
+		shard:  shard,
+		shards: o.shards,
+	}
+}
+
+// dispatch sends each item to the shard of its key until the input is
+// closed or the context is done.  It returns an error if the key of an
+// item cannot be extracted, as set by the error policy.
+func (o *KeyedOperator) dispatch(ctx context.Context, shards []chan keyedItem) error {
+	for {
+		select {
+		case item, opened := <-o.input:
+			if !opened {
+				return nil
+			}
+			// the span of the item starts before its key is extracted,
+			// which requires the unwrapped item
+			itemCtx, item := o.trace.Start(ctx, item)
+			key, err := o.key.Apply(itemCtx, item)
+			if err == nil && !isComparable(key) {
+				err = fmt.Errorf("key of type %T is not comparable", key)
+			}
+			if err == nil && o.store != nil && !isStorable(key) {
+				err = fmt.Errorf("key of type %T cannot be stored, stored keys must be strings, numbers or booleans", key)
+			}
+			if err != nil {
+				o.trace.End(itemCtx, err)
+				util.Log(o.log, err)
+				o.metrics.In()
+				o.metrics.Error()
+				if err := o.policy.Reject(ctx, util.ProcErr(err, item)); err != nil {
+					return err
+				}
+				continue
+			}
+			id := keyID(key)
+			select {
+			case shards[shardOf(id, len(shards))] <- keyedItem{ctx: itemCtx, key: key, id: id, item: item}:
+			case <-ctx.Done():
+				o.trace.End(itemCtx, ctx.Err())
+				o.setCancelled()
+				return nil
+			}
+		case <-ctx.Done():
+			o.setCancelled()
+			return nil
+		}
+	}
+}
+
+// doProc processes the items of a shard, whose states it owns
+func (o *KeyedOperator) doProc(ctx context.Context, in <-chan keyedItem, states shardStates) error {
+	var sweep <-chan time.Time
+	if o.ttl > 0 {
+		ticker := time.NewTicker(o.ttl)
+		defer ticker.Stop()
+		sweep = ticker.C
+	}
+
+	for {
+		select {
+		case ki, opened := <-in:
+			if !opened {
+				return nil
+			}
+			o.metrics.In()
+			itemCtx, item := ki.ctx, ki.item
+			start := o.metrics.Now()
+			result, err := o.apply(itemCtx, states.state(ki.key, ki.id), item)
+			o.metrics.Since(start)
+			// failing to access the stored state fails the operator
+			if err := states.takeErr(); err != nil {
+				o.trace.End(itemCtx, err)
+				util.Log(o.log, err)
+				return err
+			}
+			o.trace.End(itemCtx, err)
+			if err != nil {
+				util.Log(o.log, err)
+				o.metrics.Error()
+				if err := o.policy.Reject(ctx, util.ProcErr(err, item)); err != nil {
+					return err
+				}
+				continue
+			}
+			if val, ok := result.(error); ok {
+				// without a policy, error values are logged and dropped
+				util.Log(o.log, val)
+				o.metrics.Error()
+				continue
+			}
+			if result == nil {
+				continue
+			}
+			select {
+			case o.output <- o.trace.Wrap(itemCtx, result):
+				o.metrics.Out(o.output)
+			case <-ctx.Done():
+				o.setCancelled()
+				return nil
+			}
+		case <-sweep:
+			if err := states.expire(); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			o.setCancelled()
+			return nil
+		}
+	}
+}
+
+// apply applies the operation to the state and item, retrying as set
+// by the error policy.  An error value returned as result is treated as a
+// failure if a policy is set.
+func (o *KeyedOperator) apply(ctx context.Context, state api.State, item interface{}) (result interface{}, err error) {
+	err = o.policy.Do(ctx, func() error {
+		var opErr error
+		result, opErr = o.op.Apply(ctx, state, item)
+		if opErr != nil {
+			return opErr
+		}
+		if val, ok := result.(error); ok && o.hasPolicy {
+			return val
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (o *KeyedOperator) setCancelled() {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if !o.cancelled {
+		util.Log(o.log, "keyed operator cancelling...")
+	}
+	o.cancelled = true
+}
+
+// isComparable reports whether key can be used as a map key
+func isComparable(key interface{}) bool {
+	return key == nil || reflect.TypeOf(key).Comparable()
+}
+
+// isStorable reports whether the id of key identifies it across
+// restarts, which is required to store its state.  Unlike other keys,
+// such as structs or pointers, the value of strings, numbers and
+// booleans is both stable and unambiguous.
+func isStorable(key interface{}) bool {
+	if key == nil {
+		return false
+	}
+	switch reflect.TypeOf(key).Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// keyID returns the id of key, made of its type and value, which
+// identifies its shard and, for storable keys, its stored state
+func keyID(key interface{}) string {
+	if k, ok := key.(string); ok {
+		return "string:" + k
+	}
+	return fmt.Sprintf("%T:%v", key, key)
+}
+
+// shardOf returns the shard of the key of id among n shards
+func shardOf(id string, n int) int {
+	if n == 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return int(h.Sum32() % uint32(n))
+}
@@ -0,0 +1,290 @@
+package keyed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofunky/automi/api"
+	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/api/tuple"
+	"github.com/gofunky/automi/state"
+)
+
+// userKey returns the first value of tuple.KV{user, seq} items
+var userKey = api.UnFunc(func(ctx context.Context, item interface{}) (interface{}, error) {
+	return item.(tuple.KV)[0], nil
+})
+
+func TestKeyedOp_New(t *testing.T) {
+	o := New(context.Background())
+	if o.output == nil {
+		t.Fatal("Missing output")
+	}
+	if o.shards != 1 {
+		t.Fatal("expecting a single shard, got ", o.shards)
+	}
+}
+
+func TestKeyedOp_Exec(t *testing.T) {
+	var mutex sync.Mutex
+	workers := make(map[interface{}]map[int]bool)
+
+	o := New(context.Background())
+	o.SetKeyExtractor(userKey)
+	o.SetConcurrency(4)
+	o.SetOperation(api.KeyedFunc(func(ctx context.Context, state api.State, item interface{}) (interface{}, error) {
+		time.Sleep(time.Duration(rand.Intn(200)) * time.Microsecond)
+		seq := item.(tuple.KV)[1].(int)
+		last, ok := state.Get()
+		if ok && last.(int) >= seq {
+			return nil, fmt.Errorf("item %d of %v after %d", seq, state.Key(), last)
+		}
+		state.Put(seq)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		if workers[state.Key()] == nil {
+			workers[state.Key()] = make(map[int]bool)
+		}
+		workers[state.Key()][shardOf(keyID(state.Key()), 4)] = true
+		return item, nil
+	}))
+	in := make(chan interface{})
+	go func() {
+		for i := 0; i < 200; i++ {
+			in <- tuple.KV{fmt.Sprintf("user%d", i%10), i}
+		}
+		close(in)
+	}()
+	o.SetInput(in)
+
+	drain := make(chan error, 1)
+	o.Exec(drain)
+
+	count := 0
+	for range o.GetOutput() {
+		count++
+	}
+	select {
+	case err := <-drain:
+		t.Fatal(err)
+	default:
+	}
+	if count != 200 {
+		t.Fatal("expecting 200 items, got ", count)
+	}
+	for key, shards := range workers {
+		if len(shards) != 1 {
+			t.Fatalf("expecting key %v on a single shard, got %v", key, shards)
+		}
+	}
+}
+
+func TestKeyedOp_StateTTL(t *testing.T) {
+	o := New(context.Background())
+	o.SetKeyExtractor(userKey)
+	o.SetStateTTL(20 * time.Millisecond)
+	o.SetOperation(api.KeyedFunc(func(ctx context.Context, state api.State, item interface{}) (interface{}, error) {
+		count, _ := state.Get()
+		if count == nil {
+			count = 0
+		}
+		state.Put(count.(int) + 1)
+		return count.(int) + 1, nil
+	}))
+	in := make(chan interface{})
+	go func() {
+		in <- tuple.KV{"a", 1}
+		in <- tuple.KV{"a", 2}
+		time.Sleep(50 * time.Millisecond)
+		in <- tuple.KV{"a", 3}
+		close(in)
+	}()
+	o.SetInput(in)
+	o.Exec(make(chan error, 1))
+
+	var counts []interface{}
+	for count := range o.GetOutput() {
+		counts = append(counts, count)
+	}
+	if fmt.Sprint(counts) != "[1 2 1]" {
+		t.Fatal("expecting the state to expire, got ", counts)
+	}
+}
+
+func TestKeyedOp_Error(t *testing.T) {
+	errBad := errors.New("bad item")
+	tests := []struct {
+		name   string
+		key    api.UnOperation
+		policy api.ErrorPolicy
+		count  int
+		fail   bool
+	}{
+		{name: "fail", key: userKey, fail: true},
+		{name: "skip", key: userKey, policy: api.SkipOnError(), count: 3},
+		{name: "uncomparable key", key: api.UnFunc(func(ctx context.Context, item interface{}) (interface{}, error) {
+			return []int{1}, nil
+		}), fail: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			o := New(context.Background())
+			o.SetKeyExtractor(test.key)
+			o.SetErrorPolicy(test.policy)
+			o.SetConcurrency(2)
+			o.SetOperation(api.KeyedFunc(func(ctx context.Context, state api.State, item interface{}) (interface{}, error) {
+				if item.(tuple.KV)[1] == 2 {
+					return nil, errBad
+				}
+				return item, nil
+			}))
+			in := make(chan interface{})
+			go func() {
+				for i := 1; i <= 4; i++ {
+					in <- tuple.KV{"a", i}
+				}
+				close(in)
+			}()
+			o.SetInput(in)
+
+			drain := make(chan error, 2)
+			o.Exec(drain)
+			count := 0
+			for range o.GetOutput() {
+				count++
+			}
+			select {
+			case err := <-drain:
+				var perr api.ProcError
+				if !test.fail || !errors.As(err, &perr) {
+					t.Fatal("unexpected error ", err)
+				}
+			default:
+				if test.fail {
+					t.Fatal("expecting error")
+				}
+				if count != test.count {
+					t.Fatalf("expecting %d items, got %d", test.count, count)
+				}
+			}
+		})
+	}
+}
+
+func TestKeyedOp_StateStore(t *testing.T) {
+	store := state.NewMemory()
+	ctx := autoctx.WithNode(context.Background(), &autoctx.Node{Name: "counts"})
+	run := func(items ...interface{}) []interface{} {
+		o := New(ctx)
+		o.SetKeyExtractor(userKey)
+		o.SetConcurrency(2)
+		o.SetStateStore(store, state.Gob())
+		o.SetOperation(api.KeyedFunc(func(ctx context.Context, state api.State, item interface{}) (interface{}, error) {
+			count, _ := state.Get()
+			if count == nil {
+				count = 0
+			}
+			state.Put(count.(int) + 1)
+			return tuple.KV{state.Key(), count.(int) + 1}, nil
+		}))
+		in := make(chan interface{}, len(items))
+		for _, item := range items {
+			in <- item
+		}
+		close(in)
+		o.SetInput(in)
+		drain := make(chan error, 1)
+		o.Exec(drain)
+
+		var results []interface{}
+		for result := range o.GetOutput() {
+			results = append(results, result)
+		}
+		select {
+		case err := <-drain:
+			t.Fatal(err)
+		default:
+		}
+		return results
+	}
+
+	run(tuple.KV{"a", 1}, tuple.KV{"b", 1}, tuple.KV{"a", 2})
+	if _, ok, _ := store.Get("counts/string:a"); !ok {
+		t.Fatal("expecting the state of a to be stored")
+	}
+	results := run(tuple.KV{"a", 3}, tuple.KV{"b", 2})
+	if len(results) != 2 {
+		t.Fatal("expecting 2 results, got ", results)
+	}
+	for _, result := range results {
+		kv := result.(tuple.KV)
+		if (kv[0] == "a" && kv[1] != 3) || (kv[0] == "b" && kv[1] != 2) {
+			t.Fatal("expecting the states to be restored, got ", results)
+		}
+	}
+}
+
+func TestKeyedOp_StateStoreError(t *testing.T) {
+	store, err := state.OpenFile(filepath.Join(t.TempDir(), "state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	o := New(context.Background())
+	o.SetKeyExtractor(userKey)
+	o.SetStateStore(store, state.Gob())
+	o.SetOperation(api.KeyedFunc(func(ctx context.Context, state api.State, item interface{}) (interface{}, error) {
+		state.Put(1)
+		return item, nil
+	}))
+	in := make(chan interface{}, 1)
+	in <- tuple.KV{"a", 1}
+	close(in)
+	o.SetInput(in)
+	drain := make(chan error, 1)
+	o.Exec(drain)
+
+	for item := range o.GetOutput() {
+		t.Fatal("unexpected item ", item)
+	}
+	select {
+	case err := <-drain:
+		if err == nil {
+			t.Fatal("expecting an error")
+		}
+	default:
+		t.Fatal("expecting the store error to fail the operator")
+	}
+}
+
+func TestKeyedOp_StateStoreKey(t *testing.T) {
+	o := New(context.Background())
+	o.SetKeyExtractor(api.UnFunc(func(ctx context.Context, item interface{}) (interface{}, error) {
+		return item, nil
+	}))
+	o.SetStateStore(state.NewMemory(), state.Gob())
+	o.SetOperation(api.KeyedFunc(func(ctx context.Context, state api.State, item interface{}) (interface{}, error) {
+		return item, nil
+	}))
+	in := make(chan interface{}, 1)
+	in <- tuple.KV{"a b", "c"}
+	close(in)
+	o.SetInput(in)
+	drain := make(chan error, 1)
+	o.Exec(drain)
+
+	for item := range o.GetOutput() {
+		t.Fatal("unexpected item ", item)
+	}
+	if err := <-drain; err == nil {
+		t.Fatal("expecting a key that cannot be stored to be rejected")
+	}
+}
@@ -0,0 +1,227 @@
+package keyed
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofunky/automi/api"
+)
+
+// shardStates holds the states of the keys of a shard
+type shardStates interface {
+	// state returns the state of key, whose id is id
+	state(key interface{}, id string) api.State
+	// expire removes the expired values
+	expire() error
+	// takeErr returns, and clears, the first error
+	// raised while accessing the states
+	takeErr() error
+}
+
+// memStates holds the states of the keys of a shard in memory
+type memStates struct {
+	ttl    time.Duration
+	values map[interface{}]memValue
+}
+
+// memValue is the value of a state and the time it expires,
+// if the states have a time to live
+type memValue struct {
+	value   interface{}
+	expires time.Time
+}
+
+func newMemStates(ttl time.Duration) *memStates {
+	return &memStates{ttl: ttl, values: make(map[interface{}]memValue)}
+}
+
+// state returns the state of key
+func (s *memStates) state(key interface{}, id string) api.State {
+	return &memState{states: s, key: key}
+}
+
+// expire removes the expired values
+func (s *memStates) expire() error {
+	now := time.Now()
+	for key, val := range s.values {
+		if s.expired(val, now) {
+			delete(s.values, key)
+		}
+	}
+	return nil
+}
+
+func (s *memStates) takeErr() error {
+	return nil
+}
+
+func (s *memStates) expired(val memValue, now time.Time) bool {
+	return s.ttl > 0 && !now.Before(val.expires)
+}
+
+// memState implements api.State for a key of memStates
+type memState struct {
+	states *memStates
+	key    interface{}
+}
+
+// Key returns the key of the state
+func (s *memState) Key() interface{} {
+	return s.key
+}
+
+// Get returns the value of the state, unless it expired
+func (s *memState) Get() (interface{}, bool) {
+	val, ok := s.states.values[s.key]
+	if !ok || s.states.expired(val, time.Now()) {
+		return nil, false
+	}
+	return val.value, true
+}
+
+// Put sets the value of the state
+func (s *memState) Put(value interface{}) {
+	val := memValue{value: value}
+	if s.states.ttl > 0 {
+		val.expires = time.Now().Add(s.states.ttl)
+	}
+	s.states.values[s.key] = val
+}
+
+// Delete removes the value of the state
+func (s *memState) Delete() {
+	delete(s.states.values, s.key)
+}
+
+// storeStates holds the states of the keys of a shard in an
+// api.StateStore, which may be shared with the other shards.  Each
+// stored value is prefixed with the time it expires, in Unix nanoseconds,
+// or 0 if it never expires.
+type storeStates struct {
+	store  api.StateStore
+	codec  api.Codec
+	prefix string
+	ttl    time.Duration
+	shard  int
+	shards int
+	err    error
+}
+
+// state returns the state of key, stored under the id of key
+func (s *storeStates) state(key interface{}, id string) api.State {
+	return &storeState{states: s, key: key, storeKey: s.prefix + id}
+}
+
+// expire removes the expired values of the keys of the shard
+func (s *storeStates) expire() error {
+	now := time.Now()
+	var keys []string
+	err := s.store.Range(s.prefix, func(key string, value []byte) bool {
+		if shardOf(strings.TrimPrefix(key, s.prefix), s.shards) != s.shard {
+			return true
+		}
+		if expires, _, err := splitValue(value); err == nil && expired(expires, now) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("unable to expire states: %w", err)
+	}
+	for _, key := range keys {
+		if err := s.store.Delete(key); err != nil {
+			return fmt.Errorf("unable to expire states: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *storeStates) takeErr() error {
+	err := s.err
+	s.err = nil
+	return err
+}
+
+func (s *storeStates) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// storeState implements api.State for a key of storeStates
+type storeState struct {
+	states   *storeStates
+	key      interface{}
+	storeKey string
+}
+
+// Key returns the key of the state
+func (s *storeState) Key() interface{} {
+	return s.key
+}
+
+// Get returns the stored value of the state, unless it expired
+func (s *storeState) Get() (interface{}, bool) {
+	data, ok, err := s.states.store.Get(s.storeKey)
+	if err != nil || !ok {
+		if err != nil {
+			s.states.fail(fmt.Errorf("unable to get state of %v: %w", s.key, err))
+		}
+		return nil, false
+	}
+	expires, data, err := splitValue(data)
+	if err == nil && expired(expires, time.Now()) {
+		return nil, false
+	}
+	var value interface{}
+	if err == nil {
+		value, err = s.states.codec.Decode(data)
+	}
+	if err != nil {
+		s.states.fail(fmt.Errorf("unable to decode state of %v: %w", s.key, err))
+		return nil, false
+	}
+	return value, true
+}
+
+// Put encodes and stores the value of the state
+func (s *storeState) Put(value interface{}) {
+	data, err := s.states.codec.Encode(value)
+	if err != nil {
+		s.states.fail(fmt.Errorf("unable to encode state of %v: %w", s.key, err))
+		return
+	}
+	var expires int64
+	if s.states.ttl > 0 {
+		expires = time.Now().Add(s.states.ttl).UnixNano()
+	}
+	stored := binary.BigEndian.AppendUint64(make([]byte, 0, 8+len(data)), uint64(expires))
+	if err := s.states.store.Put(s.storeKey, append(stored, data...)); err != nil {
+		s.states.fail(fmt.Errorf("unable to put state of %v: %w", s.key, err))
+	}
+}
+
+// Delete removes the stored value of the state
+func (s *storeState) Delete() {
+	if err := s.states.store.Delete(s.storeKey); err != nil {
+		s.states.fail(fmt.Errorf("unable to delete state of %v: %w", s.key, err))
+	}
+}
+
+// splitValue splits a stored value into the time it
+// expires, in Unix nanoseconds, and its data
+func splitValue(value []byte) (int64, []byte, error) {
+	if len(value) < 8 {
+		return 0, nil, errors.New("invalid stored state")
+	}
+	return int64(binary.BigEndian.Uint64(value)), value[8:], nil
+}
+
+// expired reports whether a value that expires at expires, in
+// Unix nanoseconds, or never if it is 0, expired at now
+func expired(expires int64, now time.Time) bool {
+	return expires != 0 && expires <= now.UnixNano()
+}
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"github.com/emirpasic/gods/containers"
 	"github.com/go-faces/logger"
+	"github.com/gofunky/automi/api"
 	autoctx "github.com/gofunky/automi/api/context"
 	"github.com/gofunky/automi/api/tuple"
+	"github.com/gofunky/automi/metrics"
+	"github.com/gofunky/automi/tracing"
 	"github.com/gofunky/automi/util"
 	"github.com/gofunky/pyraset/v2"
 	"reflect"
@@ -16,10 +19,11 @@ import (
 // map, array, or slice and unpacks and emits each item individually
 // downstream.
 type StreamOperator struct {
-	ctx    context.Context
-	input  <-chan interface{}
-	output chan interface{}
-	log    logger.Interface
+	ctx     context.Context
+	input   <-chan interface{}
+	output  chan interface{}
+	log     logger.Interface
+	metrics *metrics.Recorder
 }
 
 // New creates a *StreamOperator value
@@ -29,6 +33,7 @@ func New(ctx context.Context) *StreamOperator {
 	r := new(StreamOperator)
 	r.ctx = ctx
 	r.log = log
+	r.metrics = metrics.NewRecorder(ctx, "stream")
 	r.output = make(chan interface{}, 1024)
 
 	util.Log(r.log, "stream operator initialized")
@@ -63,38 +68,71 @@ func (r *StreamOperator) Exec(drain chan<- error) {
 				if !opened {
 					return
 				}
+				r.metrics.In()
 
-				itemVal := reflect.ValueOf(item)
-
-				switch item.(type) {
-				case containers.Container:
-					itemSet := item.(containers.Container)
-					for _, subItem := range itemSet.Values() {
-						r.output <- subItem
-					}
-				case mapset.Set:
-					itemSet := item.(mapset.Set)
-					for subItem := range itemSet.Iter() {
-						r.output <- subItem
-					}
-				default:
-					itemType := reflect.TypeOf(item)
-					switch itemType.Kind() {
-					case reflect.Array, reflect.Slice:
-						for i := 0; i < itemVal.Len(); i++ {
-							j := itemVal.Index(i)
-							r.output <- j.Interface()
-						}
-					case reflect.Map:
-						for _, key := range itemVal.MapKeys() {
-							val := itemVal.MapIndex(key)
-							r.output <- tuple.KV{key.Interface(), val.Interface()}
-						}
-					default:
-						r.output <- item
-					}
+				if !r.restream(tracing.Unwrap(item)) {
+					util.Log(r.log, "stream operator cancelled")
+					return
 				}
+			case <-r.ctx.Done():
+				util.Log(r.log, "stream operator cancelled")
+				return
 			}
 		}
 	}()
 }
+
+// restream sends the elements of item downstream, traced in the span of
+// item.  It returns false if the context is done before all are sent.
+func (r *StreamOperator) restream(span api.Span, item interface{}) bool {
+	switch item.(type) {
+	case containers.Container:
+		itemSet := item.(containers.Container)
+		for _, subItem := range itemSet.Values() {
+			if !r.send(span, subItem) {
+				return false
+			}
+		}
+	case mapset.Set:
+		itemSet := item.(mapset.Set)
+		sent := true
+		itemSet.Each(func(subItem interface{}) bool {
+			sent = r.send(span, subItem)
+			return !sent
+		})
+		return sent
+	default:
+		itemVal := reflect.ValueOf(item)
+		itemType := reflect.TypeOf(item)
+		switch itemType.Kind() {
+		case reflect.Array, reflect.Slice:
+			for i := 0; i < itemVal.Len(); i++ {
+				j := itemVal.Index(i)
+				if !r.send(span, j.Interface()) {
+					return false
+				}
+			}
+		case reflect.Map:
+			for _, key := range itemVal.MapKeys() {
+				val := itemVal.MapIndex(key)
+				if !r.send(span, tuple.KV{key.Interface(), val.Interface()}) {
+					return false
+				}
+			}
+		default:
+			return r.send(span, item)
+		}
+	}
+	return true
+}
+
+// send sends item downstream, traced in span, unless the context is done
+func (r *StreamOperator) send(span api.Span, item interface{}) bool {
+	select {
+	case r.output <- tracing.Wrap(span, item):
+		r.metrics.Out(r.output)
+		return true
+	case <-r.ctx.Done():
+		return false
+	}
+}
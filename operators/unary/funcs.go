@@ -8,6 +8,7 @@ import (
 	"reflect"
 
 	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/util"
 )
 
 // ProcessFunc returns a unary function which applies the specified
@@ -113,6 +114,60 @@ func FlatMapFunc(f interface{}) (api.UnFunc, error) {
 	}), nil
 }
 
+// ProcessOf returns a unary function which applies the type-safe user-defined
+// function f to each incoming item of type T.  Unlike ProcessFunc, the signature
+// is checked at compile time and f is invoked directly without reflection.
+func ProcessOf[T, R any](f func(T) (R, error)) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) (interface{}, error) {
+		item, err := util.Cast[T](data)
+		if err != nil {
+			return nil, err
+		}
+		return f(item)
+	})
+}
+
+// FilterOf returns a unary function which applies the type-safe predicate f
+// to each incoming item of type T.  Items for which f returns false are
+// removed from the stream.
+func FilterOf[T any](f func(T) bool) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) (interface{}, error) {
+		item, err := util.Cast[T](data)
+		if err != nil {
+			return nil, err
+		}
+		if !f(item) {
+			return nil, nil
+		}
+		return data, nil
+	})
+}
+
+// MapOf returns a unary function which maps, one-to-one, each incoming
+// item of type T to a value of type R using the type-safe function f.
+func MapOf[T, R any](f func(T) R) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) (interface{}, error) {
+		item, err := util.Cast[T](data)
+		if err != nil {
+			return nil, err
+		}
+		return f(item), nil
+	})
+}
+
+// FlatMapOf returns a unary function which maps each incoming item of type T
+// to a slice []R using the type-safe function f.  The resulting slice is
+// expected to be re-streamed by a downstream stream operator.
+func FlatMapOf[T, R any](f func(T) []R) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) (interface{}, error) {
+		item, err := util.Cast[T](data)
+		if err != nil {
+			return nil, err
+		}
+		return f(item), nil
+	})
+}
+
 // isUnaryFuncForm ensures type is a function of form func(in)out or func(in)(out, error).
 func isUnaryFuncForm(ftype reflect.Type) error {
 	errorInterface := reflect.TypeOf((*error)(nil)).Elem()
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"strings"
 	"testing"
 )
 
@@ -137,3 +138,81 @@ func TestUnaryFunc_FlatMap(t *testing.T) {
 		t.Fatal("unexpected result from FlatMapFunc:", len(slice))
 	}
 }
+
+func TestUnaryFunc_ProcessOf(t *testing.T) {
+	op := ProcessOf(func(item int) (string, error) {
+		if item < 0 {
+			return "", errors.New("negative")
+		}
+		return strings.Repeat("a", item), nil
+	})
+
+	ctx := context.TODO()
+	result, err := op.Apply(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.(string) != "aaa" {
+		t.Fatal("unexpected result from ProcessOf: ", result)
+	}
+	if _, err := op.Apply(ctx, -1); err == nil {
+		t.Fatal("expecting error from user func")
+	}
+	if _, err := op.Apply(ctx, "3"); err == nil {
+		t.Fatal("expecting type mismatch error")
+	}
+}
+
+func TestUnaryFunc_FilterOf(t *testing.T) {
+	op := FilterOf(func(item int) bool {
+		return item%2 == 0
+	})
+
+	ctx := context.TODO()
+	count := 0
+	for _, v := range []int{1, 2, 3, 4, 5, 6} {
+		result, err := op.Apply(ctx, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != nil {
+			count++
+		}
+	}
+	if count != 3 {
+		t.Fatal("unexpected result from FilterOf: ", count)
+	}
+}
+
+func TestUnaryFunc_MapOf(t *testing.T) {
+	op := MapOf(func(item string) int {
+		return len(item)
+	})
+
+	sum := 0
+	ctx := context.TODO()
+	for _, v := range []string{"A", "BB", "CCC"} {
+		result, err := op.Apply(ctx, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum += result.(int)
+	}
+	if sum != 6 {
+		t.Fatal("unexpected result from MapOf: ", sum)
+	}
+}
+
+func TestUnaryFunc_FlatMapOf(t *testing.T) {
+	op := FlatMapOf(func(item string) []string {
+		return strings.Split(item, " ")
+	})
+
+	result, err := op.Apply(context.TODO(), "hello big world")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.([]string)) != 3 {
+		t.Fatal("unexpected result from FlatMapOf: ", result)
+	}
+}
@@ -0,0 +1,45 @@
+package unary
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/api/tuple"
+	"github.com/gofunky/automi/util"
+)
+
+// ScanByKeyFunc returns a unary function that keeps a running state for
+// each key of the items, as returned by key, starting from seed.  Each
+// item updates the state of its key with op, applied to the state and the
+// item, and the function returns a copy of the updated state, see
+// util.CopyState, as tuple.KV{key, state}.  The states are kept for the
+// life of the function, which is safe for concurrent use.
+func ScanByKeyFunc(key api.UnOperation, seed interface{}, op api.BinOperation) api.UnFunc {
+	var mutex sync.Mutex
+	states := make(map[interface{}]interface{})
+	return api.UnFunc(func(ctx context.Context, item interface{}) (interface{}, error) {
+		k, err := key.Apply(ctx, item)
+		if err != nil {
+			return nil, err
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		state, ok := states[k]
+		if !ok {
+			state = seed
+		}
+		state, err = op.Apply(ctx, state, item)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := state.(error); ok {
+			// an error value leaves the state unchanged,
+			// it is handled by the operator as a result
+			return state, nil
+		}
+		states[k] = state
+		return tuple.KV{k, util.CopyState(state)}, nil
+	})
+}
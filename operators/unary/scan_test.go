@@ -0,0 +1,77 @@
+package unary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/api/tuple"
+)
+
+func TestScanByKeyFunc(t *testing.T) {
+	key := api.UnFunc(func(ctx context.Context, item interface{}) (interface{}, error) {
+		word := item.(string)
+		if word == "" {
+			return nil, errors.New("empty word")
+		}
+		return word[:1], nil
+	})
+	count := api.BinFunc(func(ctx context.Context, state, item interface{}) (interface{}, error) {
+		return state.(int) + len(item.(string)), nil
+	})
+	op := ScanByKeyFunc(key, 0, count)
+
+	var results []string
+	for _, word := range []string{"apple", "berry", "avocado", "blue", "cherry"} {
+		result, err := op.Apply(context.TODO(), word)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kv := result.(tuple.KV)
+		results = append(results, fmt.Sprintf("%s:%d", kv[0], kv[1]))
+	}
+	if strings.Join(results, " ") != "a:5 b:5 a:12 b:9 c:6" {
+		t.Fatal("unexpected running states ", results)
+	}
+	if _, err := op.Apply(context.TODO(), ""); err == nil {
+		t.Fatal("expecting key error")
+	}
+}
+
+func TestScanByKeyFunc_CopyState(t *testing.T) {
+	key := api.UnFunc(func(ctx context.Context, item interface{}) (interface{}, error) {
+		return item.(string)[:1], nil
+	})
+	// the state is updated in place
+	collect := api.BinFunc(func(ctx context.Context, state, item interface{}) (interface{}, error) {
+		words, _ := state.(map[string]int)
+		if words == nil {
+			words = make(map[string]int)
+		}
+		words[item.(string)]++
+		return words, nil
+	})
+	op := ScanByKeyFunc(key, nil, collect)
+
+	first, err := op.Apply(context.TODO(), "apple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := op.Apply(context.TODO(), "avocado"); err != nil {
+		t.Fatal(err)
+	}
+	if words := first.(tuple.KV)[1].(map[string]int); len(words) != 1 || words["apple"] != 1 {
+		t.Fatal("expecting the emitted state not to be updated, got ", words)
+	}
+	first.(tuple.KV)[1].(map[string]int)["apple"] = 10
+	last, err := op.Apply(context.TODO(), "apple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if words := last.(tuple.KV)[1].(map[string]int); words["apple"] != 2 || words["avocado"] != 1 {
+		t.Fatal("expecting the state not to be updated by the emitted one, got ", words)
+	}
+}
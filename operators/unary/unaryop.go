@@ -9,6 +9,8 @@ import (
 	"github.com/go-faces/logger"
 	"github.com/gofunky/automi/api"
 	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/metrics"
+	"github.com/gofunky/automi/tracing"
 	"github.com/gofunky/automi/util"
 )
 
@@ -24,10 +26,16 @@ func pack(vals ...interface{}) packed {
 type UnaryOperator struct {
 	ctx         context.Context
 	op          api.UnOperation
+	policy      api.ErrorPolicy
+	hasPolicy   bool
 	concurrency int
+	ordered     bool
+	reorder     int
 	input       <-chan interface{}
 	output      chan interface{}
 	log         logger.Interface
+	metrics     *metrics.Recorder
+	trace       *tracing.Recorder
 	cancelled   bool
 	mutex       sync.RWMutex
 }
@@ -40,6 +48,8 @@ func New(ctx context.Context) *UnaryOperator {
 	o := new(UnaryOperator)
 	o.ctx = ctx
 	o.log = log
+	o.metrics = metrics.NewRecorder(ctx, "unary")
+	o.trace = tracing.NewRecorder(ctx, "unary")
 
 	o.concurrency = 1
 	o.output = make(chan interface{}, 1024)
@@ -53,6 +63,15 @@ func (o *UnaryOperator) SetOperation(op api.UnOperation) {
 	o.op = op
 }
 
+// SetErrorPolicy sets the policy applied to items whose operation
+// returns an error, or returns an error value as result.  By default,
+// the operator fails on the first error returned, while error values
+// returned as result are logged and dropped.
+func (o *UnaryOperator) SetErrorPolicy(policy api.ErrorPolicy) {
+	o.policy = policy
+	o.hasPolicy = true
+}
+
 // SetConcurrency sets the concurrency level for the operation
 func (o *UnaryOperator) SetConcurrency(concurr int) {
 	o.concurrency = concurr
@@ -61,6 +80,16 @@ func (o *UnaryOperator) SetConcurrency(concurr int) {
 	}
 }
 
+// SetOrdered sets whether the results of concurrent operations are sent
+// in the order of their items.  Results that are ready before those of
+// earlier items are held in a reorder buffer of size items, at most,
+// the input is not read while the buffer is full.  If size is not
+// positive, the buffer holds 16 items per worker.
+func (o *UnaryOperator) SetOrdered(ordered bool, size int) {
+	o.ordered = ordered
+	o.reorder = size
+}
+
 // SetInput sets the input channel for the executor node
 func (o *UnaryOperator) SetInput(in <-chan interface{}) {
 	o.input = in
@@ -84,42 +113,163 @@ func (o *UnaryOperator) Exec(drain chan<- error) {
 		o.concurrency = 1
 	}
 
+	// workers share a context that is cancelled when one of them fails
+	exeCtx, cancel := context.WithCancel(o.ctx)
+
 	go func() {
+		failed := false
 		defer func() {
 			util.Log(o.log, "unary operator closing")
 			close(o.output)
+			cancel()
+			if failed {
+				// keep upstream flowing, remaining items are discarded
+				for range o.input {
+				}
+			}
 		}()
+		fail := func(err error) {
+			o.mutex.Lock()
+			failed = true
+			o.mutex.Unlock()
+			cancel()
+			drain <- err
+		}
 
-		var barrier sync.WaitGroup
-		wgDelta := o.concurrency
-		barrier.Add(wgDelta)
-
-		wait := make(chan struct{})
-		go func(wg *sync.WaitGroup) {
-			defer close(wait)
-			wg.Wait()
-		}(&barrier)
-
-		for i := 0; i < o.concurrency; i++ {
-			go func(wg *sync.WaitGroup) {
-				defer wg.Done()
-				if err := o.doProc(o.ctx); err != nil {
-					drain <- err
-				}
-			}(&barrier)
+		if o.ordered && o.concurrency > 1 {
+			o.execOrdered(exeCtx, fail)
+		} else {
+			o.execUnordered(exeCtx, fail)
 		}
+		switch {
+		case failed:
+			util.Log(o.log, "unary operator failed")
+		case o.cancelled:
+			util.Log(o.log, "unary operator cancelled")
+		}
+	}()
+}
 
-		select {
-		case <-wait:
-			if o.cancelled {
-				util.Log(o.log, "unary operator cancelled")
+// execUnordered runs the workers, each of them sending its results as
+// soon as they are ready.  It returns once all workers are done.
+func (o *UnaryOperator) execUnordered(ctx context.Context, fail func(error)) {
+	var barrier sync.WaitGroup
+	barrier.Add(o.concurrency)
+	for i := 0; i < o.concurrency; i++ {
+		go func() {
+			defer barrier.Done()
+			if err := o.doProc(ctx); err != nil {
+				fail(err)
+			}
+		}()
+	}
+
+	// workers return once the input is closed or the context is
+	// done, the output is closed after their last send
+	barrier.Wait()
+}
+
+// sequenced is an item, or the output of an item,
+// numbered in the order of the input
+type sequenced struct {
+	seq  uint64
+	item interface{}
+}
+
+// execOrdered runs the workers and sends their results in the order of
+// the input.  Each item takes a slot of the reorder buffer until its
+// result is sent, so that results held waiting for those of earlier
+// items are bounded.  It returns once all results are sent.
+func (o *UnaryOperator) execOrdered(ctx context.Context, fail func(error)) {
+	if o.op == nil {
+		err := errors.New("unary operator missing operation")
+		util.Log(o.log, err)
+		fail(err)
+		return
+	}
+	size := o.reorder
+	if size < 1 {
+		size = 16 * o.concurrency
+	}
+	slots := make(chan struct{}, size)
+	jobs := make(chan sequenced)
+	results := make(chan sequenced, size)
+
+	// number the items and dispatch them to the workers
+	go func() {
+		defer close(jobs)
+		for seq := uint64(0); ; seq++ {
+			select {
+			case item, opened := <-o.input:
+				if !opened {
+					return
+				}
+				select {
+				case slots <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+				jobs <- sequenced{seq: seq, item: item}
+			case <-ctx.Done():
 				return
 			}
-		case <-o.ctx.Done():
-			util.Log(o.log, "unary operator done")
-			return
 		}
 	}()
+
+	var workers sync.WaitGroup
+	workers.Add(o.concurrency)
+	for i := 0; i < o.concurrency; i++ {
+		go func() {
+			defer workers.Done()
+			for job := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				out, err := o.process(ctx, job.item)
+				if err != nil {
+					fail(err)
+					continue
+				}
+				// results never exceed the slots, sends do not block
+				results <- sequenced{seq: job.seq, item: out}
+			}
+		}()
+	}
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	// send the results in sequence, releasing their slots
+	pending := make(map[uint64]interface{})
+	var next uint64
+	for result := range results {
+		pending[result.seq] = result.item
+		for {
+			out, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			<-slots
+			// once cancelled, or failed, the results are discarded
+			if out == nil || ctx.Err() != nil {
+				continue
+			}
+			select {
+			case o.output <- out:
+				o.metrics.Out(o.output)
+			case <-ctx.Done():
+			}
+		}
+	}
+
+	if ctx.Err() != nil {
+		o.mutex.Lock()
+		o.cancelled = true
+		o.mutex.Unlock()
+	}
 }
 
 func (o *UnaryOperator) doProc(ctx context.Context) error {
@@ -138,20 +288,17 @@ func (o *UnaryOperator) doProc(ctx context.Context) error {
 				return nil
 			}
 
-			result, err := o.op.Apply(exeCtx, item)
+			out, err := o.process(exeCtx, item)
 			if err != nil {
-				util.Log(o.log, err)
 				return err
 			}
-
-			switch val := result.(type) {
-			case nil:
+			if out == nil {
 				continue
-			case error, api.ProcError:
-				util.Log(o.log, val)
-				continue
-			default:
-				o.output <- val
+			}
+			select {
+			case o.output <- out:
+				o.metrics.Out(o.output)
+			case <-ctx.Done():
 			}
 
 		// is cancelling
@@ -165,3 +312,48 @@ func (o *UnaryOperator) doProc(ctx context.Context) error {
 		}
 	}
 }
+
+// process applies the operation to item and returns the output to send
+// downstream, or nil if there is none.  It returns an error if the item
+// fails the operator, as set by the error policy.
+func (o *UnaryOperator) process(ctx context.Context, item interface{}) (interface{}, error) {
+	o.metrics.In()
+	itemCtx, item := o.trace.Start(ctx, item)
+	start := o.metrics.Now()
+	result, err := o.apply(itemCtx, item)
+	o.metrics.Since(start)
+	o.trace.End(itemCtx, err)
+	if err != nil {
+		util.Log(o.log, err)
+		o.metrics.Error()
+		return nil, o.policy.Reject(ctx, util.ProcErr(err, item))
+	}
+	if val, ok := result.(error); ok {
+		// without a policy, error values are logged and dropped
+		util.Log(o.log, val)
+		o.metrics.Error()
+		return nil, nil
+	}
+	if result == nil {
+		return nil, nil
+	}
+	return o.trace.Wrap(itemCtx, result), nil
+}
+
+// apply applies the operation to item, retrying as set by the error
+// policy.  An error value returned as result is treated as a failure
+// if a policy is set.
+func (o *UnaryOperator) apply(ctx context.Context, item interface{}) (result interface{}, err error) {
+	err = o.policy.Do(ctx, func() error {
+		var opErr error
+		result, opErr = o.op.Apply(ctx, item)
+		if opErr != nil {
+			return opErr
+		}
+		if val, ok := result.(error); ok && o.hasPolicy {
+			return val
+		}
+		return nil
+	})
+	return result, err
+}
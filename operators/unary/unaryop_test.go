@@ -2,6 +2,7 @@ package unary
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"testing"
 	"time"
@@ -102,6 +103,135 @@ func TestUnaryOp_Exec(t *testing.T) {
 	}
 }
 
+func TestUnaryOp_ErrorPolicy(t *testing.T) {
+	errOdd := errors.New("odd value")
+	attempts := 0
+	op := api.UnFunc(func(ctx context.Context, data interface{}) (interface{}, error) {
+		attempts++
+		if data.(int)%2 == 1 {
+			return nil, errOdd
+		}
+		return data, nil
+	})
+	dlq := make(chan interface{}, 4)
+	tests := []struct {
+		name     string
+		policy   api.ErrorPolicy
+		results  int
+		attempts int
+		fail     bool
+	}{
+		{name: "fail", policy: api.FailOnError(), results: 1, attempts: 2, fail: true},
+		{name: "skip", policy: api.SkipOnError(), results: 2, attempts: 4},
+		{name: "retry", policy: api.RetryOnError(2, time.Millisecond), results: 1, attempts: 4, fail: true},
+		{name: "dead letter", policy: api.DeadLetterOnError(dlq), results: 2, attempts: 4},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attempts = 0
+			o := New(context.Background())
+			o.SetOperation(op)
+			o.SetErrorPolicy(test.policy)
+			in := make(chan interface{})
+			go func() {
+				for _, i := range []int{2, 1, 4, 3} {
+					in <- i
+				}
+				close(in)
+			}()
+			o.SetInput(in)
+
+			drain := make(chan error, 1)
+			o.Exec(drain)
+
+			results := 0
+			for range o.GetOutput() {
+				results++
+			}
+			if results != test.results || attempts != test.attempts {
+				t.Fatalf("expecting %d results and %d attempts, got %d and %d", test.results, test.attempts, results, attempts)
+			}
+			select {
+			case err := <-drain:
+				var perr api.ProcError
+				if !test.fail || !errors.As(err, &perr) || perr.Item != 1 || !errors.Is(err, errOdd) {
+					t.Fatal("unexpected error ", err)
+				}
+			default:
+				if test.fail {
+					t.Fatal("expecting error")
+				}
+			}
+		})
+	}
+
+	if len(dlq) != 2 {
+		t.Fatal("expecting 2 dead-lettered items, got ", len(dlq))
+	}
+	if perr := (<-dlq).(api.ProcError); perr.Item != 1 || perr.Err != errOdd {
+		t.Fatal("unexpected dead-lettered item ", perr)
+	}
+}
+
+func TestUnaryOp_ErrorValue(t *testing.T) {
+	o := New(context.Background())
+	o.SetOperation(api.UnFunc(func(ctx context.Context, data interface{}) (interface{}, error) {
+		if data.(int) == 1 {
+			return api.ProcError{Err: errors.New("rejected")}, nil
+		}
+		return data, nil
+	}))
+	o.SetErrorPolicy(api.SkipOnError())
+	in := make(chan interface{})
+	go func() {
+		in <- 1
+		in <- 2
+		close(in)
+	}()
+	o.SetInput(in)
+	o.Exec(make(chan error))
+
+	var results []interface{}
+	for item := range o.GetOutput() {
+		results = append(results, item)
+	}
+	if len(results) != 1 || results[0] != 2 {
+		t.Fatal("expecting error value to be skipped, got ", results)
+	}
+}
+
+func TestUnaryOp_ErrorValueDefault(t *testing.T) {
+	o := New(context.Background())
+	o.SetOperation(api.UnFunc(func(ctx context.Context, data interface{}) (interface{}, error) {
+		if data.(int) == 1 {
+			return errors.New("rejected"), nil
+		}
+		return data, nil
+	}))
+	in := make(chan interface{})
+	go func() {
+		in <- 1
+		in <- 2
+		close(in)
+	}()
+	o.SetInput(in)
+	drain := make(chan error, 1)
+	o.Exec(drain)
+
+	var results []interface{}
+	for item := range o.GetOutput() {
+		results = append(results, item)
+	}
+	if len(results) != 1 || results[0] != 2 {
+		t.Fatal("expecting error value to be dropped, got ", results)
+	}
+	select {
+	case err := <-drain:
+		t.Fatal("expecting error value not to fail the operator, got ", err)
+	default:
+	}
+}
+
 func BenchmarkUnaryOp_Exec(b *testing.B) {
 	ctx := context.Background()
 	o := New(ctx)
@@ -161,3 +291,132 @@ func BenchmarkUnaryOp_Exec(b *testing.B) {
 	}
 	m.RUnlock()
 }
+
+func TestUnaryOp_Ordered(t *testing.T) {
+	for _, size := range []int{0, 1, 4} {
+		o := New(context.Background())
+		o.SetOperation(api.UnFunc(func(ctx context.Context, data interface{}) (interface{}, error) {
+			// later items tend to be done first
+			time.Sleep(time.Duration(20-data.(int)%20) * 100 * time.Microsecond)
+			if data.(int)%3 == 0 {
+				return nil, nil
+			}
+			return data, nil
+		}))
+		o.SetConcurrency(4)
+		o.SetOrdered(true, size)
+		in := make(chan interface{})
+		go func() {
+			for i := 0; i < 100; i++ {
+				in <- i
+			}
+			close(in)
+		}()
+		o.SetInput(in)
+
+		drain := make(chan error, 1)
+		o.Exec(drain)
+
+		expected := 1
+		for data := range o.GetOutput() {
+			if data.(int) != expected {
+				t.Fatalf("reorder buffer %d: expecting %d, got %v", size, expected, data)
+			}
+			expected++
+			if expected%3 == 0 {
+				expected++
+			}
+		}
+		if expected < 100 {
+			t.Fatalf("reorder buffer %d: missing items after %d", size, expected)
+		}
+		select {
+		case err := <-drain:
+			t.Fatal(err)
+		default:
+		}
+	}
+}
+
+func TestUnaryOp_OrderedBuffer(t *testing.T) {
+	var mutex sync.Mutex
+	running, ahead := 0, 0
+	release := make(chan struct{})
+	o := New(context.Background())
+	o.SetOperation(api.UnFunc(func(ctx context.Context, data interface{}) (interface{}, error) {
+		if data.(int) == 0 {
+			<-release // hold the first item
+			return data, nil
+		}
+		mutex.Lock()
+		running++
+		if running > ahead {
+			ahead = running
+		}
+		mutex.Unlock()
+		return data, nil
+	}))
+	o.SetConcurrency(2)
+	o.SetOrdered(true, 3)
+	in := make(chan interface{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+	o.SetInput(in)
+	o.Exec(make(chan error, 1))
+
+	time.Sleep(20 * time.Millisecond)
+	mutex.Lock()
+	// the slots of the first item and of the two
+	// processed ahead of it are taken
+	if ahead != 2 {
+		t.Fatalf("expecting 2 items processed ahead of the first, got %d", ahead)
+	}
+	mutex.Unlock()
+	close(release)
+	count := 0
+	for range o.GetOutput() {
+		count++
+	}
+	if count != 10 {
+		t.Fatalf("expecting 10 items, got %d", count)
+	}
+}
+
+func TestUnaryOp_OrderedError(t *testing.T) {
+	errBad := errors.New("bad value")
+	o := New(context.Background())
+	o.SetOperation(api.UnFunc(func(ctx context.Context, data interface{}) (interface{}, error) {
+		if data.(int) == 50 {
+			return nil, errBad
+		}
+		return data, nil
+	}))
+	o.SetConcurrency(4)
+	o.SetOrdered(true, 0)
+	in := make(chan interface{})
+	go func() {
+		for i := 0; i < 1000; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+	o.SetInput(in)
+
+	drain := make(chan error, 4)
+	o.Exec(drain)
+
+	last := -1
+	for data := range o.GetOutput() {
+		if data.(int) != last+1 || data.(int) >= 50 {
+			t.Fatalf("unexpected item %v after %d", data, last)
+		}
+		last = data.(int)
+	}
+	if err := <-drain; !errors.Is(err, errBad) {
+		t.Fatal("unexpected error ", err)
+	}
+}
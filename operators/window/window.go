@@ -0,0 +1,359 @@
+package window
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/go-faces/logger"
+	"github.com/gofunky/automi/api"
+	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/metrics"
+	"github.com/gofunky/automi/tracing"
+	"github.com/gofunky/automi/util"
+)
+
+// Spec describes how items are assigned to time windows.
+type Spec struct {
+	// Size is the duration of tumbling and sliding windows.
+	Size time.Duration
+	// Slide is the interval at which sliding windows start.
+	Slide time.Duration
+	// Gap is the inactivity duration that closes a session window.
+	Gap time.Duration
+}
+
+// Tumbling returns a spec for fixed, non-overlapping windows of the given size
+func Tumbling(size time.Duration) Spec {
+	return Spec{Size: size, Slide: size}
+}
+
+// Sliding returns a spec for overlapping windows of the given size
+// that start every slide interval.
+func Sliding(size, slide time.Duration) Spec {
+	return Spec{Size: size, Slide: slide}
+}
+
+// Session returns a spec for windows that group items until
+// no item arrives for the duration of gap.
+func Session(gap time.Duration) Spec {
+	return Spec{Gap: gap}
+}
+
+func (s Spec) validate() error {
+	switch {
+	case s.Gap > 0:
+		return nil
+	case s.Size <= 0:
+		return fmt.Errorf("invalid window size %v", s.Size)
+	case s.Slide <= 0 || s.Slide > s.Size:
+		return fmt.Errorf("invalid window slide %v for size %v", s.Slide, s.Size)
+	}
+	return nil
+}
+
+// assign returns the windows [start, end) to which time t belongs
+func (s Spec) assign(t time.Time) []bounds {
+	if s.Gap > 0 {
+		return []bounds{{t, t.Add(s.Gap)}}
+	}
+	var result []bounds
+	for start := t.Truncate(s.Slide); t.Before(start.Add(s.Size)); start = start.Add(-s.Slide) {
+		result = append(result, bounds{start, start.Add(s.Size)})
+	}
+	return result
+}
+
+type bounds struct {
+	start, end time.Time
+}
+
+// LateItem is sent to the auxiliary channel of the stream for each item
+// that arrives after the watermark has passed all of its windows.
+type LateItem = api.LateItem
+
+type pane struct {
+	bounds
+	items []interface{}
+}
+
+// WindowOperator is an executor node that groups streamed items into
+// time windows (tumbling, sliding, or session).  Each window is emitted
+// downstream as an api.Window once its end time is reached.  Remaining
+// windows are flushed when the upstream is done.
+//
+// By default, items are assigned by their arrival (processing) time and
+// windows are emitted on a timer, even when no new item arrives.  When an
+// event time extractor is set, items are assigned by their event time and
+// windows are emitted once the watermark passes their end.  Items arriving
+// after the watermark has passed all of their windows are sent as LateItem
+// values to the auxiliary channel of the context, if any.
+type WindowOperator struct {
+	ctx        context.Context
+	spec       Spec
+	timestamps api.TimestampExtractor
+	watermark  *api.Watermark
+	panes      []*pane
+	input      <-chan interface{}
+	output     chan interface{}
+	log        logger.Interface
+	metrics    *metrics.Recorder
+}
+
+// New creates a *WindowOperator value
+func New(ctx context.Context) *WindowOperator {
+	log := autoctx.GetLogger(ctx)
+
+	o := new(WindowOperator)
+	o.ctx = ctx
+	o.log = log
+	o.metrics = metrics.NewRecorder(ctx, "window")
+	o.output = make(chan interface{}, 1024)
+
+	util.Log(o.log, "window operator initialized")
+	return o
+}
+
+// SetSpec sets how items are assigned to windows
+func (o *WindowOperator) SetSpec(spec Spec) {
+	o.spec = spec
+}
+
+// SetEventTime switches the operator to event time, using ts to extract
+// the timestamp of items.  The watermark trails the greatest observed
+// timestamp by maxOutOfOrder.
+func (o *WindowOperator) SetEventTime(ts api.TimestampExtractor, maxOutOfOrder time.Duration) {
+	o.timestamps = ts
+	o.watermark = &api.Watermark{MaxOutOfOrder: maxOutOfOrder}
+}
+
+// SetInput sets the input channel for the executor node
+func (o *WindowOperator) SetInput(in <-chan interface{}) {
+	o.input = in
+}
+
+// GetOutput returns the output channel for the executor node
+func (o *WindowOperator) GetOutput() <-chan interface{} {
+	return o.output
+}
+
+// Exec is the entry point for the executor
+func (o *WindowOperator) Exec(drain chan<- error) {
+	if o.input == nil {
+		drain <- fmt.Errorf("no input channel found")
+		return
+	}
+
+	go func() {
+		defer func() {
+			util.Log(o.log, "window operator closing")
+			close(o.output)
+		}()
+		if err := o.spec.validate(); err != nil {
+			drain <- err
+			return
+		}
+		if o.timestamps != nil {
+			if err := o.doEventTime(); err != nil {
+				util.Log(o.log, err)
+				drain <- err
+			}
+			return
+		}
+		o.doProcessingTime()
+	}()
+}
+
+// doProcessingTime assigns items by arrival time and fires windows on a timer
+func (o *WindowOperator) doProcessingTime() {
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case item, opened := <-o.input:
+			if !opened {
+				o.flush()
+				return
+			}
+			o.metrics.In()
+			_, item = tracing.Unwrap(item)
+			o.add(item, time.Now())
+		case <-timer.C:
+		case <-o.ctx.Done():
+			util.Log(o.log, "window operator done")
+			return
+		}
+		o.fire(time.Now())
+		o.schedule(timer)
+	}
+}
+
+// doEventTime assigns items by event time and fires windows as the watermark advances
+func (o *WindowOperator) doEventTime() error {
+	for {
+		select {
+		case item, opened := <-o.input:
+			if !opened {
+				o.flush()
+				return nil
+			}
+			o.metrics.In()
+			_, item = tracing.Unwrap(item)
+			t, err := o.timestamps.Timestamp(item)
+			if err != nil {
+				o.metrics.Error()
+				return err
+			}
+			if o.isLate(t) {
+				o.sendLate(item, t)
+				continue
+			}
+			o.add(item, t)
+			o.watermark.Observe(t)
+			o.fire(o.watermark.Current())
+		case <-o.ctx.Done():
+			util.Log(o.log, "window operator done")
+			return nil
+		}
+	}
+}
+
+// isLate returns true if the watermark has passed all windows of time t
+func (o *WindowOperator) isLate(t time.Time) bool {
+	current := o.watermark.Current()
+	if current.IsZero() {
+		return false
+	}
+	for _, b := range o.spec.assign(t) {
+		if b.end.After(current) {
+			return false
+		}
+	}
+	return true
+}
+
+// sendLate routes a late item to the auxiliary channel of the context
+func (o *WindowOperator) sendLate(item interface{}, t time.Time) {
+	late := LateItem{Item: item, Timestamp: t, Watermark: o.watermark.Current()}
+	aux, ok := autoctx.GetAuxChan(o.ctx)
+	if !ok {
+		util.Logf(o.log, "dropping late item %v with timestamp %v", item, t)
+		return
+	}
+	select {
+	case aux <- late:
+	case <-o.ctx.Done():
+	}
+}
+
+// add assigns item, with timestamp t, to its windows
+func (o *WindowOperator) add(item interface{}, t time.Time) {
+	for _, b := range o.spec.assign(t) {
+		if o.spec.Gap > 0 {
+			o.addToSession(item, b)
+			continue
+		}
+		p := o.find(b)
+		if p == nil {
+			p = &pane{bounds: b}
+			o.panes = append(o.panes, p)
+		}
+		p.items = append(p.items, item)
+	}
+}
+
+// addToSession merges session b, and all sessions overlapping it, into a single pane
+func (o *WindowOperator) addToSession(item interface{}, b bounds) {
+	merged := &pane{bounds: b, items: []interface{}{item}}
+	kept := o.panes[:0]
+	for _, p := range o.panes {
+		if p.start.After(merged.end) || merged.start.After(p.end) {
+			kept = append(kept, p)
+			continue
+		}
+		if p.start.Before(merged.start) {
+			merged.start = p.start
+		}
+		if p.end.After(merged.end) {
+			merged.end = p.end
+		}
+		merged.items = append(p.items, merged.items...)
+	}
+	o.panes = append(kept, merged)
+}
+
+func (o *WindowOperator) find(b bounds) *pane {
+	for _, p := range o.panes {
+		if p.bounds == b {
+			return p
+		}
+	}
+	return nil
+}
+
+// flush emits all remaining panes
+func (o *WindowOperator) flush() {
+	o.fire(time.Unix(1<<62, 0))
+}
+
+// fire emits, in end time order, the panes ending at or before now.
+func (o *WindowOperator) fire(now time.Time) {
+	sort.SliceStable(o.panes, func(i, j int) bool {
+		return o.panes[i].end.Before(o.panes[j].end)
+	})
+	fired := 0
+	for _, p := range o.panes {
+		if p.end.After(now) {
+			break
+		}
+		o.send(api.Window{Start: p.start, End: p.end, Data: makeBatch(p.items)})
+		fired++
+	}
+	o.panes = o.panes[fired:]
+}
+
+// schedule resets timer to fire at the earliest window end
+func (o *WindowOperator) schedule(timer *time.Timer) {
+	if len(o.panes) == 0 {
+		return
+	}
+	next := o.panes[0].end
+	for _, p := range o.panes[1:] {
+		if p.end.Before(next) {
+			next = p.end
+		}
+	}
+	timer.Reset(time.Until(next))
+}
+
+// makeBatch returns the items as a slice []T when all items are of
+// type T, otherwise as []interface{}.
+func makeBatch(items []interface{}) interface{} {
+	itemType := reflect.TypeOf(items[0])
+	for _, item := range items[1:] {
+		if reflect.TypeOf(item) != itemType {
+			return items
+		}
+	}
+	if itemType == nil {
+		return items
+	}
+	batch := reflect.MakeSlice(reflect.SliceOf(itemType), 0, len(items))
+	for _, item := range items {
+		batch = reflect.Append(batch, reflect.ValueOf(item))
+	}
+	return batch.Interface()
+}
+
+// send sends item downstream unless the context is done
+func (o *WindowOperator) send(item interface{}) {
+	select {
+	case o.output <- item:
+		o.metrics.Out(o.output)
+	case <-o.ctx.Done():
+	}
+}
@@ -0,0 +1,179 @@
+package window
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofunky/automi/api"
+	autoctx "github.com/gofunky/automi/api/context"
+)
+
+func TestSpec_Assign(t *testing.T) {
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := base.Add(25 * time.Second)
+
+	tumbling := Tumbling(10 * time.Second).assign(at)
+	if len(tumbling) != 1 || !tumbling[0].start.Equal(base.Add(20*time.Second)) {
+		t.Fatal("unexpected tumbling windows ", tumbling)
+	}
+
+	sliding := Sliding(10*time.Second, 5*time.Second).assign(at)
+	if len(sliding) != 2 || !sliding[0].start.Equal(base.Add(25*time.Second)) || !sliding[1].start.Equal(base.Add(20*time.Second)) {
+		t.Fatal("unexpected sliding windows ", sliding)
+	}
+
+	session := Session(time.Second).assign(at)
+	if len(session) != 1 || !session[0].end.Equal(at.Add(time.Second)) {
+		t.Fatal("unexpected session windows ", session)
+	}
+
+	if err := Sliding(time.Second, 2*time.Second).validate(); err == nil {
+		t.Fatal("expecting invalid slide error")
+	}
+}
+
+func collect(o *WindowOperator) <-chan []api.Window {
+	result := make(chan []api.Window, 1)
+	go func() {
+		var windows []api.Window
+		for item := range o.GetOutput() {
+			windows = append(windows, item.(api.Window))
+		}
+		result <- windows
+	}()
+	return result
+}
+
+func TestWindowOp_FlushOnTimer(t *testing.T) {
+	o := New(context.Background())
+	size := 20 * time.Millisecond
+	o.SetSpec(Tumbling(size))
+	in := make(chan interface{})
+	o.SetInput(in)
+	o.Exec(make(chan error))
+
+	// start at a window boundary so that both items fall into one window
+	time.Sleep(time.Until(time.Now().Truncate(size).Add(size)))
+	in <- 1
+	in <- 2
+	select {
+	case item := <-o.GetOutput():
+		win := item.(api.Window)
+		data := win.Data.([]int)
+		if len(data) != 2 || !win.End.After(win.Start) {
+			t.Fatal("unexpected window ", win)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("window not flushed without new items")
+	}
+	close(in)
+}
+
+func TestWindowOp_Session(t *testing.T) {
+	o := New(context.Background())
+	o.SetSpec(Session(20 * time.Millisecond))
+	in := make(chan interface{})
+	o.SetInput(in)
+	result := collect(o)
+	o.Exec(make(chan error))
+
+	in <- "A"
+	in <- "B"
+	time.Sleep(50 * time.Millisecond)
+	in <- "C"
+	close(in)
+
+	select {
+	case windows := <-result:
+		if len(windows) != 2 {
+			t.Fatal("expecting 2 sessions, got ", windows)
+		}
+		if len(windows[0].Data.([]string)) != 2 || len(windows[1].Data.([]string)) != 1 {
+			t.Fatal("unexpected sessions ", windows)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestWindowOp_Sliding(t *testing.T) {
+	o := New(context.Background())
+	o.SetSpec(Sliding(time.Hour, 30*time.Minute))
+	in := make(chan interface{})
+	o.SetInput(in)
+	result := collect(o)
+	o.Exec(make(chan error))
+
+	in <- "A"
+	in <- 1
+	close(in)
+
+	select {
+	case windows := <-result:
+		if len(windows) != 2 {
+			t.Fatal("expecting 2 overlapping windows, got ", windows)
+		}
+		for _, win := range windows {
+			if len(win.Data.([]interface{})) != 2 {
+				t.Fatal("unexpected window ", win)
+			}
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestWindowOp_InvalidSpec(t *testing.T) {
+	o := New(context.Background())
+	o.SetInput(make(chan interface{}))
+	drain := make(chan error, 1)
+	o.Exec(drain)
+	if err := <-drain; err == nil {
+		t.Fatal("expecting invalid spec error")
+	}
+}
+
+func TestWindowOp_EventTime(t *testing.T) {
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	aux := make(chan interface{}, 10)
+	o := New(autoctx.WithAuxChan(context.Background(), aux))
+	o.SetSpec(Tumbling(10 * time.Second))
+	o.SetEventTime(api.TimestampFunc(func(item interface{}) (time.Time, error) {
+		return base.Add(time.Duration(item.(int)) * time.Second), nil
+	}), 5*time.Second)
+
+	in := make(chan interface{})
+	o.SetInput(in)
+	result := collect(o)
+	o.Exec(make(chan error))
+
+	// 8 arrives out of order within bounds, 3 arrives after the watermark passed its window
+	for _, i := range []int{1, 12, 8, 16, 3, 25} {
+		in <- i
+	}
+	close(in)
+
+	select {
+	case windows := <-result:
+		if len(windows) != 3 {
+			t.Fatal("expecting 3 windows, got ", windows)
+		}
+		first := windows[0].Data.([]int)
+		if len(first) != 2 || first[1] != 8 || !windows[0].Start.Equal(base) {
+			t.Fatal("unexpected first window ", windows[0])
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	select {
+	case item := <-aux:
+		late := item.(LateItem)
+		if late.Item != 3 || !late.Watermark.Equal(base.Add(11*time.Second)) {
+			t.Fatal("unexpected late item ", late)
+		}
+	default:
+		t.Fatal("expecting late item on the auxiliary channel")
+	}
+}
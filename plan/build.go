@@ -0,0 +1,237 @@
+package plan
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/collectors"
+	"github.com/gofunky/automi/emitters"
+	"github.com/gofunky/automi/stream"
+)
+
+// Build builds the stream defined by the plan using the operations of reg,
+// which may be nil if the plan refers to none.  The nodes of the stream are
+// created with ctx.  Errors in the plan are returned by Build, the stream
+// reports the other errors when it is opened.
+func (p *Plan) Build(ctx context.Context, reg *Registry) (*stream.Stream, error) {
+	src, err := buildSource(p.Source)
+	if err != nil {
+		return nil, err
+	}
+	strm := stream.New(src).WithContext(ctx)
+
+	for i, step := range p.Operators {
+		add, ok := operators[step.Type]
+		if !ok {
+			return nil, fmt.Errorf("plan: operator %d: unknown type %q", i, step.Type)
+		}
+		if err := add(strm, step, reg); err != nil {
+			return nil, fmt.Errorf("plan: operator %d (%s): %w", i, step.Type, err)
+		}
+		if step.Name != "" && !opNamed[step.Type] {
+			strm.Named(step.Name)
+		}
+	}
+
+	snk, err := buildSink(p.Sink)
+	if err != nil {
+		return nil, err
+	}
+	return strm.Into(snk), nil
+}
+
+// buildSource returns the emitter of the source endpoint
+func buildSource(src Endpoint) (api.Source, error) {
+	params := src.Params
+	switch src.Type {
+	case "csv":
+		file, err := params.String("file", "")
+		if err != nil {
+			return nil, err
+		}
+		if file == "" {
+			return nil, errors.New("plan: csv source requires a file parameter")
+		}
+		delim, err := params.Char("delimiter", ',')
+		if err != nil {
+			return nil, err
+		}
+		comment, err := params.Char("comment", '#')
+		if err != nil {
+			return nil, err
+		}
+		header, err := params.Bool("header", false)
+		if err != nil {
+			return nil, err
+		}
+		maps, err := params.Bool("maps", false)
+		if err != nil {
+			return nil, err
+		}
+		fieldCount, err := params.Int("fieldCount", 0)
+		if err != nil {
+			return nil, err
+		}
+		var source interface{} = file
+		if file == "-" {
+			source = stdin()
+		}
+		csv := emitters.CSV(source).DelimChar(delim).CommentChar(comment).FieldCount(fieldCount)
+		if header {
+			csv.HasHeaders()
+		}
+		if maps {
+			csv.AsMaps()
+		}
+		return csv, nil
+	case "json":
+		if err := params.require("file"); err != nil {
+			return nil, err
+		}
+		rdr, err := openReader(params)
+		if err != nil {
+			return nil, err
+		}
+		return emitters.JSON(rdr), nil
+	case "reader":
+		if err := params.require("file"); err != nil {
+			return nil, err
+		}
+		rdr, err := openReader(params)
+		if err != nil {
+			return nil, err
+		}
+		size, err := params.Int("size", 0)
+		if err != nil {
+			return nil, err
+		}
+		reader := emitters.Reader(rdr)
+		if size > 0 {
+			reader.BufferSize(size)
+		}
+		return reader, nil
+	case "scanner":
+		if err := params.require("file"); err != nil {
+			return nil, err
+		}
+		rdr, err := openReader(params)
+		if err != nil {
+			return nil, err
+		}
+		split, err := params.String("split", "lines")
+		if err != nil {
+			return nil, err
+		}
+		splitter, ok := splitters[split]
+		if !ok {
+			return nil, fmt.Errorf("plan: unknown scanner split %q", split)
+		}
+		return emitters.Scanner(rdr, splitter), nil
+	case "slice":
+		if err := params.require("items"); err != nil {
+			return nil, err
+		}
+		items, ok := params["items"].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("plan: parameter items must be a list, got %T", params["items"])
+		}
+		return emitters.Slice(items), nil
+	case "":
+		return nil, errors.New("plan: missing source type")
+	}
+	return nil, fmt.Errorf("plan: unknown source type %q", src.Type)
+}
+
+// buildSink returns the collector of the sink endpoint,
+// the stream discards its items if the sink has no type.
+func buildSink(snk Endpoint) (api.Sink, error) {
+	params := snk.Params
+	switch snk.Type {
+	case "", "null":
+		return collectors.Null(), nil
+	case "csv":
+		file, err := params.String("file", "")
+		if err != nil {
+			return nil, err
+		}
+		if file == "" {
+			return nil, errors.New("plan: csv sink requires a file parameter")
+		}
+		delim, err := params.Char("delimiter", ',')
+		if err != nil {
+			return nil, err
+		}
+		headers, err := params.Strings("headers")
+		if err != nil {
+			return nil, err
+		}
+		var sink interface{} = file
+		if file == "-" {
+			sink = stdout()
+		}
+		return collectors.CSV(sink).DelimChar(delim).Headers(headers), nil
+	case "json":
+		file, err := params.String("file", "-")
+		if err != nil {
+			return nil, err
+		}
+		array, err := params.Bool("array", false)
+		if err != nil {
+			return nil, err
+		}
+		var sink interface{} = file
+		if file == "-" {
+			sink = stdout()
+		}
+		json := collectors.JSON(sink)
+		if array {
+			json.Array()
+		}
+		return json, nil
+	case "writer":
+		file, err := params.String("file", "-")
+		if err != nil {
+			return nil, err
+		}
+		if file == "-" {
+			return collectors.Writer(stdout()), nil
+		}
+		return &fileSink{name: file}, nil
+	}
+	return nil, fmt.Errorf("plan: unknown sink type %q", snk.Type)
+}
+
+var splitters = map[string]bufio.SplitFunc{
+	"lines": bufio.ScanLines,
+	"words": bufio.ScanWords,
+	"runes": bufio.ScanRunes,
+	"bytes": bufio.ScanBytes,
+}
+
+// openReader returns a reader of the file parameter, or of
+// the standard input if the file is "-"
+func openReader(params Params) (io.Reader, error) {
+	file, err := params.String("file", "")
+	if err != nil {
+		return nil, err
+	}
+	if file == "-" {
+		return stdin(), nil
+	}
+	return &fileReader{name: file}, nil
+}
+
+// stdin and stdout hide the *os.File of the standard
+// streams so that nodes do not close them
+func stdin() io.Reader {
+	return struct{ io.Reader }{os.Stdin}
+}
+
+func stdout() io.Writer {
+	return struct{ io.Writer }{os.Stdout}
+}
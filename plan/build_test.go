@@ -0,0 +1,257 @@
+package plan
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/collectors"
+)
+
+func TestPlan_Build_CSV(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in.csv"), filepath.Join(dir, "out.csv")
+	if err := os.WriteFile(in, []byte("name,qty\nalpha,1\nbeta,2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reg := NewRegistry()
+	if err := reg.RegisterUnary("upper", api.UnFunc(func(ctx context.Context, item interface{}) (interface{}, error) {
+		row := item.([]string)
+		return []string{strings.ToUpper(row[0]), row[1]}, nil
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.RegisterUnary("upper", api.UnFunc(nil)); err == nil {
+		t.Fatal("expecting error for duplicate operation")
+	}
+
+	p, err := ParseYAML([]byte(`
+source:
+  type: csv
+  params: {file: ` + in + `, header: true}
+operators:
+  - type: map
+    op: upper
+sink:
+  type: csv
+  params: {file: ` + out + `, headers: [name, qty]}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	strm, err := p.Build(context.Background(), reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-strm.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "name,qty\nALPHA,1\nBETA,2\n" {
+		t.Fatalf("unexpected output %q", data)
+	}
+}
+
+func TestPlan_Build_Reduce(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.txt")
+	reg := NewRegistry()
+	reg.RegisterUnary("even", api.UnFunc(func(ctx context.Context, item interface{}) (interface{}, error) {
+		return item.(int)%2 == 0, nil
+	}))
+	reg.RegisterUnary("pair", api.UnFunc(func(ctx context.Context, item interface{}) (interface{}, error) {
+		return []int{item.(int), item.(int)}, nil
+	}))
+	reg.RegisterBinary("add", api.BinFunc(func(ctx context.Context, state, item interface{}) (interface{}, error) {
+		return state.(int) + item.(int), nil
+	}))
+
+	p := &Plan{
+		Source: Endpoint{Type: "slice", Params: Params{"items": []interface{}{1, 2, 3, 4}}},
+		Operators: []Step{
+			{Type: "filter", Op: "even"},
+			{Type: "flatMap", Op: "pair"},
+			{Type: "reduce", Op: "add", Name: "total", Params: Params{"seed": 0}},
+		},
+		Sink: Endpoint{Type: "writer", Params: Params{"file": out}},
+	}
+	strm, err := p.Build(context.Background(), reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-strm.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "12" {
+		t.Fatalf("unexpected output %q", data)
+	}
+}
+
+func TestPlan_Build_Scanner(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in.txt"), filepath.Join(dir, "out.txt")
+	if err := os.WriteFile(in, []byte("b a\nc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := &Plan{
+		Source:    Endpoint{Type: "scanner", Params: Params{"file": in, "split": "words"}},
+		Operators: []Step{{Type: "batch"}, {Type: "sort"}, {Type: "restream"}},
+		Sink:      Endpoint{Type: "writer", Params: Params{"file": out}},
+	}
+	strm, err := p.Build(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-strm.Open(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "abc" {
+		t.Fatalf("unexpected output %q", data)
+	}
+}
+
+func TestPlan_Build_JSON(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in.json"), filepath.Join(dir, "out.json")
+	if err := os.WriteFile(in, []byte(`[{"name": "alpha", "qty": 1}, {"name": "beta", "qty": 4}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := &Plan{
+		Source:    Endpoint{Type: "json", Params: Params{"file": in}},
+		Operators: []Step{{Type: "filter", Params: Params{"expr": "qty > 2"}}},
+		Sink:      Endpoint{Type: "json", Params: Params{"file": out, "array": true}},
+	}
+	strm, err := p.Build(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-strm.Open(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "[\n{\"name\":\"beta\",\"qty\":4}\n]\n" {
+		t.Fatalf("unexpected output %q", data)
+	}
+}
+
+func TestPlan_Build_CSVMaps(t *testing.T) {
+	in := filepath.Join(t.TempDir(), "in.csv")
+	if err := os.WriteFile(in, []byte("name,qty\nalpha,1\nbeta,4\ngamma,3,extra\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := &Plan{
+		Source: Endpoint{Type: "csv", Params: Params{"file": in, "header": true, "maps": true, "fieldCount": -1}},
+		Operators: []Step{
+			{Type: "filter", Params: Params{"expr": "number(qty) > 2"}},
+			{Type: "map", Params: Params{"expr": "name"}},
+		},
+	}
+	strm, err := p.Build(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snk := collectors.Slice()
+	if err := <-strm.Into(snk).Open(); err != nil {
+		t.Fatal(err)
+	}
+	if result := snk.Get(); len(result) != 2 || result[0] != "beta" || result[1] != "gamma" {
+		t.Fatal("unexpected result ", result)
+	}
+}
+
+func TestPlan_Build_Errors(t *testing.T) {
+	slice := Endpoint{Type: "slice", Params: Params{"items": []interface{}{1}}}
+	tests := map[string]*Plan{
+		"missing source":     {},
+		"unknown source":     {Source: Endpoint{Type: "kafka"}},
+		"missing file":       {Source: Endpoint{Type: "reader"}},
+		"unknown operator":   {Source: slice, Operators: []Step{{Type: "explode"}}},
+		"missing operation":  {Source: slice, Operators: []Step{{Type: "map"}}},
+		"unregistered":       {Source: slice, Operators: []Step{{Type: "map", Op: "upper"}}},
+		"missing parameter":  {Source: slice, Operators: []Step{{Type: "sortByName"}}},
+		"invalid duration":   {Source: slice, Operators: []Step{{Type: "tumblingWindow", Params: Params{"size": "soon"}}}},
+		"unknown sink":       {Source: slice, Sink: Endpoint{Type: "kafka"}},
+		"invalid param type": {Source: slice, Operators: []Step{{Type: "batch", Params: Params{"size": "big"}}}},
+	}
+	for name, p := range tests {
+		if _, err := p.Build(context.Background(), nil); err == nil {
+			t.Error(name, ": expecting error")
+		}
+	}
+}
+
+func TestPlan_Build_FilterResult(t *testing.T) {
+	reg := NewRegistry()
+	reg.RegisterUnary("bad", api.UnFunc(func(ctx context.Context, item interface{}) (interface{}, error) {
+		return "yes", nil
+	}))
+	p := &Plan{
+		Source:    Endpoint{Type: "slice", Params: Params{"items": []interface{}{1}}},
+		Operators: []Step{{Type: "filter", Op: "bad"}},
+	}
+	strm, err := p.Build(context.Background(), reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-strm.Open(); err == nil || !strings.Contains(err.Error(), "must return a bool") {
+		t.Fatal("expecting filter error, got ", err)
+	}
+}
+
+func TestPlan_Build_Expr(t *testing.T) {
+	p, err := ParseJSON([]byte(`{
+		"source": {"type": "slice", "params": {"items": [
+			{"name": "alpha", "qty": 1}, {"name": "beta", "qty": 4}, {"name": "gamma", "qty": 3}
+		]}},
+		"operators": [
+			{"type": "filter", "params": {"expr": "qty > 2"}},
+			{"type": "map", "name": "label", "params": {"expr": "upper(name) + \":\" + string(qty * 10)"}}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	strm, err := p.Build(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snk := collectors.Slice()
+	strm.Into(snk)
+	if err := <-strm.Open(); err != nil {
+		t.Fatal(err)
+	}
+	result := snk.Get()
+	if len(result) != 2 || result[0] != "BETA:40" || result[1] != "GAMMA:30" {
+		t.Fatal("unexpected result ", result)
+	}
+
+	invalid := []Step{
+		{Type: "filter", Params: Params{"expr": "qty >"}},
+		{Type: "map", Op: "upper", Params: Params{"expr": "qty"}},
+	}
+	for _, step := range invalid {
+		p.Operators = []Step{step}
+		if _, err := p.Build(context.Background(), nil); err == nil {
+			t.Error(step, ": expecting error")
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package plan
+
+import (
+	"context"
+	"os"
+
+	"github.com/gofunky/automi/collectors"
+)
+
+// fileReader opens the named file on first read and
+// closes it once reading fails, i.e. at the end of the file
+type fileReader struct {
+	name string
+	file *os.File
+	err  error
+}
+
+func (r *fileReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if r.file == nil {
+		if r.file, r.err = os.Open(r.name); r.err != nil {
+			return 0, r.err
+		}
+	}
+	n, err := r.file.Read(p)
+	if err != nil {
+		r.err = err
+		r.file.Close()
+	}
+	return n, err
+}
+
+// fileSink writes the items to the named file, which
+// is created when the sink is opened and closed after
+// the last item is written
+type fileSink struct {
+	name  string
+	input <-chan interface{}
+}
+
+func (s *fileSink) SetInput(in <-chan interface{}) {
+	s.input = in
+}
+
+func (s *fileSink) Open(ctx context.Context) <-chan error {
+	result := make(chan error)
+	file, err := os.Create(s.name)
+	if err != nil {
+		go func() { result <- err }()
+		return result
+	}
+
+	writer := collectors.Writer(file)
+	writer.SetInput(s.input)
+	done := writer.Open(ctx)
+	go func() {
+		defer close(result)
+		// the writer reports its first error, or closes done
+		err := <-done
+		if e := file.Close(); err == nil {
+			err = e
+		}
+		if err != nil {
+			result <- err
+		}
+	}()
+	return result
+}
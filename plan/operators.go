@@ -0,0 +1,213 @@
+package plan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/expr"
+	"github.com/gofunky/automi/stream"
+)
+
+// adder adds the operator of step to strm
+type adder func(strm *stream.Stream, step Step, reg *Registry) error
+
+// operators are the operator types of a plan by name
+var operators = map[string]adder{
+	"map":         addMap,
+	"filter":      addFilter,
+	"flatMap":     addFlatMap,
+	"reduce":      addReduce,
+	"restream":    simple((*stream.Stream).ReStream),
+	"batch":       addBatch,
+	"groupByKey":  withKey((*stream.Stream).GroupByKey),
+	"groupByName": withName((*stream.Stream).GroupByName),
+	"groupByPos":  withPos((*stream.Stream).GroupByPos),
+	"sort":        simple((*stream.Stream).Sort),
+	"sortByKey":   withKey((*stream.Stream).SortByKey),
+	"sortByName":  withName((*stream.Stream).SortByName),
+	"sortByPos":   withPos((*stream.Stream).SortByPos),
+	"sum":         simple((*stream.Stream).Sum),
+	"sumByKey":    withKey((*stream.Stream).SumByKey),
+	"sumByName":   withName((*stream.Stream).SumByName),
+	"sumByPos":    withPos((*stream.Stream).SumByPos),
+	"sumAllKeys":  simple((*stream.Stream).SumAllKeys),
+	"tumblingWindow": func(strm *stream.Stream, step Step, _ *Registry) error {
+		size, err := step.Params.Duration("size")
+		if err != nil {
+			return err
+		}
+		strm.TumblingWindow(size)
+		return nil
+	},
+	"slidingWindow": func(strm *stream.Stream, step Step, _ *Registry) error {
+		size, err := step.Params.Duration("size")
+		if err != nil {
+			return err
+		}
+		slide, err := step.Params.Duration("slide")
+		if err != nil {
+			return err
+		}
+		strm.SlidingWindow(size, slide)
+		return nil
+	},
+	"sessionWindow": func(strm *stream.Stream, step Step, _ *Registry) error {
+		gap, err := step.Params.Duration("gap")
+		if err != nil {
+			return err
+		}
+		strm.SessionWindow(gap)
+		return nil
+	},
+}
+
+func simple(add func(*stream.Stream) *stream.Stream) adder {
+	return func(strm *stream.Stream, _ Step, _ *Registry) error {
+		add(strm)
+		return nil
+	}
+}
+
+func withKey(add func(*stream.Stream, interface{}) *stream.Stream) adder {
+	return func(strm *stream.Stream, step Step, _ *Registry) error {
+		if err := step.Params.require("key"); err != nil {
+			return err
+		}
+		add(strm, step.Params["key"])
+		return nil
+	}
+}
+
+func withName(add func(*stream.Stream, string) *stream.Stream) adder {
+	return func(strm *stream.Stream, step Step, _ *Registry) error {
+		if err := step.Params.require("name"); err != nil {
+			return err
+		}
+		name, err := step.Params.String("name", "")
+		if err != nil {
+			return err
+		}
+		add(strm, name)
+		return nil
+	}
+}
+
+func withPos(add func(*stream.Stream, int) *stream.Stream) adder {
+	return func(strm *stream.Stream, step Step, _ *Registry) error {
+		if err := step.Params.require("pos"); err != nil {
+			return err
+		}
+		pos, err := step.Params.Int("pos", 0)
+		if err != nil {
+			return err
+		}
+		add(strm, pos)
+		return nil
+	}
+}
+
+func addBatch(strm *stream.Stream, step Step, _ *Registry) error {
+	size, err := step.Params.Int("size", 0)
+	if err != nil {
+		return err
+	}
+	if size > 0 {
+		strm.BatchBySize(int64(size))
+		return nil
+	}
+	strm.Batch()
+	return nil
+}
+
+// opNamed are the operator types that apply a registered operation, the
+// operator is named after the operation unless the step has a name
+var opNamed = map[string]bool{"map": true, "filter": true, "flatMap": true, "reduce": true}
+
+// opName returns the name of the operator applying the operation of step,
+// operators applying an expression are named after their type
+func opName(step Step) string {
+	switch {
+	case step.Name != "":
+		return step.Name
+	case step.Op != "":
+		return step.Op
+	}
+	return step.Type
+}
+
+// unary returns the registered unary operation of step, or the
+// operation compiled from its expr parameter
+func unary(step Step, reg *Registry, compile func(string) (api.UnOperation, error)) (api.UnOperation, error) {
+	if step.Params.Has("expr") {
+		if step.Op != "" {
+			return nil, errors.New("operation and expr are exclusive")
+		}
+		src, err := step.Params.String("expr", "")
+		if err != nil {
+			return nil, err
+		}
+		return compile(src)
+	}
+	if step.Op == "" {
+		return nil, errors.New("missing operation")
+	}
+	return reg.Unary(step.Op)
+}
+
+func addMap(strm *stream.Stream, step Step, reg *Registry) error {
+	op, err := unary(step, reg, expr.Map)
+	if err != nil {
+		return err
+	}
+	strm.Transform(op).Named(opName(step))
+	return nil
+}
+
+func addFilter(strm *stream.Stream, step Step, reg *Registry) error {
+	op, err := unary(step, reg, expr.Filter)
+	if err != nil {
+		return err
+	}
+	if step.Op == "" {
+		strm.Transform(op).Named(opName(step))
+		return nil
+	}
+	strm.Transform(api.UnFunc(func(ctx context.Context, item interface{}) (interface{}, error) {
+		result, err := op.Apply(ctx, item)
+		if err != nil {
+			return nil, err
+		}
+		keep, ok := result.(bool)
+		if !ok {
+			return nil, fmt.Errorf("filter %s must return a bool, got %T", step.Op, result)
+		}
+		if !keep {
+			return nil, nil
+		}
+		return item, nil
+	})).Named(opName(step))
+	return nil
+}
+
+func addFlatMap(strm *stream.Stream, step Step, reg *Registry) error {
+	op, err := unary(step, reg, expr.Map)
+	if err != nil {
+		return err
+	}
+	strm.Transform(op).Named(opName(step)).ReStream()
+	return nil
+}
+
+func addReduce(strm *stream.Stream, step Step, reg *Registry) error {
+	if step.Op == "" {
+		return errors.New("missing operation")
+	}
+	op, err := reg.Binary(step.Op)
+	if err != nil {
+		return err
+	}
+	strm.Reduce(step.Params["seed"], op).Named(opName(step))
+	return nil
+}
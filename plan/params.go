@@ -0,0 +1,122 @@
+package plan
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Params are the parameters of a source, an operator or a sink
+type Params map[string]interface{}
+
+// Has returns true if the parameter is set
+func (p Params) Has(key string) bool {
+	_, ok := p[key]
+	return ok
+}
+
+// String returns the string parameter key, or def if not set
+func (p Params) String(key, def string) (string, error) {
+	val, ok := p[key]
+	if !ok {
+		return def, nil
+	}
+	s, ok := val.(string)
+	if !ok {
+		return "", fmt.Errorf("plan: parameter %s must be a string, got %T", key, val)
+	}
+	return s, nil
+}
+
+// Strings returns the list of strings parameter key, or nil if not set
+func (p Params) Strings(key string) ([]string, error) {
+	val, ok := p[key]
+	if !ok {
+		return nil, nil
+	}
+	list, ok := val.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("plan: parameter %s must be a list, got %T", key, val)
+	}
+	strs := make([]string, len(list))
+	for i, item := range list {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("plan: parameter %s must be a list of strings, got %T", key, item)
+		}
+		strs[i] = s
+	}
+	return strs, nil
+}
+
+// Int returns the integer parameter key, or def if not set.
+// Numbers decoded from JSON are accepted if they are integral.
+func (p Params) Int(key string, def int) (int, error) {
+	val, ok := p[key]
+	if !ok {
+		return def, nil
+	}
+	switch n := val.(type) {
+	case int:
+		return n, nil
+	case int64:
+		return int(n), nil
+	case float64:
+		if n == math.Trunc(n) {
+			return int(n), nil
+		}
+	}
+	return 0, fmt.Errorf("plan: parameter %s must be an integer, got %v", key, val)
+}
+
+// Bool returns the boolean parameter key, or def if not set
+func (p Params) Bool(key string, def bool) (bool, error) {
+	val, ok := p[key]
+	if !ok {
+		return def, nil
+	}
+	b, ok := val.(bool)
+	if !ok {
+		return false, fmt.Errorf("plan: parameter %s must be a boolean, got %T", key, val)
+	}
+	return b, nil
+}
+
+// Duration returns the duration parameter key, written
+// as a time.ParseDuration string such as "1m30s".
+func (p Params) Duration(key string) (time.Duration, error) {
+	val, ok := p[key]
+	if !ok {
+		return 0, fmt.Errorf("plan: missing parameter %s", key)
+	}
+	s, ok := val.(string)
+	if !ok {
+		return 0, fmt.Errorf("plan: parameter %s must be a duration string, got %T", key, val)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("plan: parameter %s: %w", key, err)
+	}
+	return d, nil
+}
+
+// Char returns the single character parameter key, or def if not set
+func (p Params) Char(key string, def rune) (rune, error) {
+	s, err := p.String(key, string(def))
+	if err != nil {
+		return 0, err
+	}
+	chars := []rune(s)
+	if len(chars) != 1 {
+		return 0, fmt.Errorf("plan: parameter %s must be a single character, got %q", key, s)
+	}
+	return chars[0], nil
+}
+
+// require returns an error if the parameter is not set
+func (p Params) require(key string) error {
+	if !p.Has(key) {
+		return fmt.Errorf("plan: missing parameter %s", key)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// Package plan builds streams from declarative pipeline definitions
+// written in JSON or YAML.  A plan names a source, a sequence of
+// operators with their parameters, and a sink:
+//
+//	source:
+//	  type: csv
+//	  params: {file: orders.csv, header: true}
+//	operators:
+//	  - type: map
+//	    op: parseOrder       # an api.UnOperation registered by name
+//	  - type: filter
+//	    params: {expr: "Amount > 0"}
+//	  - type: batch
+//	  - type: sumByName
+//	    params: {name: Amount}
+//	sink:
+//	  type: csv
+//	  params: {file: totals.csv}
+//
+// Operations are looked up by name in a Registry, see Registry.RegisterUnary
+// and Registry.RegisterBinary.
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Plan is the definition of a stream
+type Plan struct {
+	Source    Endpoint `json:"source" yaml:"source"`
+	Operators []Step   `json:"operators,omitempty" yaml:"operators,omitempty"`
+	Sink      Endpoint `json:"sink,omitempty" yaml:"sink,omitempty"`
+}
+
+// Endpoint is the source or sink of a plan.  Sources are of type csv, json,
+// reader, scanner or slice, sinks of type csv, json, writer or null, which
+// must be quoted in YAML.  A plan without a sink discards the items.  The file
+// parameter of sources and sinks may be "-" for the standard input or output.
+type Endpoint struct {
+	Type   string `json:"type" yaml:"type"`
+	Params Params `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+// Step is an operator of a plan.  Type is the stream method that adds the
+// operator, in lower camel case (i.e. batch, sortByKey, sumByName, restream).
+// Operators of type map, filter, flatMap and reduce apply the operation
+// registered as Op.  Operators of type map, filter and flatMap may instead
+// apply the expression of their expr parameter, see package expr.
+// The operator is renamed to Name, if set, see Stream.Named.
+type Step struct {
+	Type   string `json:"type" yaml:"type"`
+	Name   string `json:"name,omitempty" yaml:"name,omitempty"`
+	Op     string `json:"op,omitempty" yaml:"op,omitempty"`
+	Params Params `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+// ParseJSON parses a plan written in JSON.  Unknown fields are rejected.
+func ParseJSON(data []byte) (*Plan, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	p := new(Plan)
+	if err := dec.Decode(p); err != nil {
+		return nil, fmt.Errorf("plan: %w", err)
+	}
+	return p, nil
+}
+
+// ParseYAML parses a plan written in YAML.  Unknown fields are rejected.
+func ParseYAML(data []byte) (*Plan, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	p := new(Plan)
+	if err := dec.Decode(p); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("plan: %w", err)
+	}
+	return p, nil
+}
+
+// Load reads and parses the plan in the named file.  Files
+// with the .json extension are parsed as JSON, others as YAML.
+func Load(name string) (*Plan, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		return ParseJSON(data)
+	}
+	return ParseYAML(data)
+}
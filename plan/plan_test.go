@@ -0,0 +1,107 @@
+package plan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const yamlPlan = `
+source:
+  type: slice
+  params:
+    items: [1, 2, 3]
+operators:
+  - type: batch
+    name: all
+  - type: sum
+sink:
+  type: "null"
+`
+
+const jsonPlan = `{
+  "source": {"type": "slice", "params": {"items": [1, 2, 3]}},
+  "operators": [{"type": "batch", "name": "all"}, {"type": "sum"}],
+  "sink": {"type": "null"}
+}`
+
+func TestParse(t *testing.T) {
+	for name, parse := range map[string]func() (*Plan, error){
+		"yaml": func() (*Plan, error) { return ParseYAML([]byte(yamlPlan)) },
+		"json": func() (*Plan, error) { return ParseJSON([]byte(jsonPlan)) },
+	} {
+		p, err := parse()
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if p.Source.Type != "slice" || len(p.Source.Params["items"].([]interface{})) != 3 {
+			t.Fatal(name, "unexpected source ", p.Source)
+		}
+		if len(p.Operators) != 2 || p.Operators[0].Name != "all" || p.Operators[1].Type != "sum" {
+			t.Fatal(name, "unexpected operators ", p.Operators)
+		}
+		if p.Sink.Type != "null" {
+			t.Fatal(name, "unexpected sink ", p.Sink)
+		}
+	}
+}
+
+func TestParse_UnknownField(t *testing.T) {
+	if _, err := ParseYAML([]byte("source: {type: slice, param: {}}")); err == nil {
+		t.Fatal("expecting error for unknown yaml field")
+	}
+	if _, err := ParseJSON([]byte(`{"source": {"type": "slice", "param": {}}}`)); err == nil {
+		t.Fatal("expecting error for unknown json field")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{"plan.yaml": yamlPlan, "plan.json": jsonPlan} {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		p, err := Load(file)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if len(p.Operators) != 2 {
+			t.Fatal(name, "unexpected operators ", p.Operators)
+		}
+	}
+	if _, err := Load(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Fatal("expecting error for missing file")
+	}
+}
+
+func TestParams(t *testing.T) {
+	p := Params{"n": 2, "f": float64(3), "x": 1.5, "s": "a", "b": true, "d": "1s", "l": []interface{}{"a", "b"}}
+	if n, err := p.Int("n", 0); err != nil || n != 2 {
+		t.Fatal("unexpected int ", n, err)
+	}
+	if n, err := p.Int("f", 0); err != nil || n != 3 {
+		t.Fatal("unexpected int from float ", n, err)
+	}
+	if _, err := p.Int("x", 0); err == nil {
+		t.Fatal("expecting error for non integral number")
+	}
+	if n, err := p.Int("missing", 7); err != nil || n != 7 {
+		t.Fatal("expecting default int ", n, err)
+	}
+	if _, err := p.String("n", ""); err == nil {
+		t.Fatal("expecting error for non string")
+	}
+	if c, err := p.Char("s", ','); err != nil || c != 'a' {
+		t.Fatal("unexpected char ", c, err)
+	}
+	if b, err := p.Bool("b", false); err != nil || !b {
+		t.Fatal("unexpected bool ", b, err)
+	}
+	if d, err := p.Duration("d"); err != nil || d.Seconds() != 1 {
+		t.Fatal("unexpected duration ", d, err)
+	}
+	if l, err := p.Strings("l"); err != nil || len(l) != 2 {
+		t.Fatal("unexpected strings ", l, err)
+	}
+}
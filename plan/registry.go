@@ -0,0 +1,82 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gofunky/automi/api"
+)
+
+// Registry holds the named operations that plans refer to.
+// It is safe for concurrent use.
+type Registry struct {
+	mutex  sync.RWMutex
+	unary  map[string]api.UnOperation
+	binary map[string]api.BinOperation
+}
+
+// NewRegistry creates a new, empty, *Registry value
+func NewRegistry() *Registry {
+	return &Registry{
+		unary:  make(map[string]api.UnOperation),
+		binary: make(map[string]api.BinOperation),
+	}
+}
+
+// RegisterUnary registers op as name for the map, filter and flatMap
+// operators.  A filter operation must return a bool result.
+func (r *Registry) RegisterUnary(name string, op api.UnOperation) error {
+	if name == "" || op == nil {
+		return errors.New("plan: unary operation requires a name and an operation")
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.unary[name]; ok {
+		return fmt.Errorf("plan: unary operation %q already registered", name)
+	}
+	r.unary[name] = op
+	return nil
+}
+
+// RegisterBinary registers op as name for the reduce operator
+func (r *Registry) RegisterBinary(name string, op api.BinOperation) error {
+	if name == "" || op == nil {
+		return errors.New("plan: binary operation requires a name and an operation")
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.binary[name]; ok {
+		return fmt.Errorf("plan: binary operation %q already registered", name)
+	}
+	r.binary[name] = op
+	return nil
+}
+
+// Unary returns the unary operation registered as name
+func (r *Registry) Unary(name string) (api.UnOperation, error) {
+	if r == nil {
+		return nil, fmt.Errorf("plan: unary operation %q not registered", name)
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	op, ok := r.unary[name]
+	if !ok {
+		return nil, fmt.Errorf("plan: unary operation %q not registered", name)
+	}
+	return op, nil
+}
+
+// Binary returns the binary operation registered as name
+func (r *Registry) Binary(name string) (api.BinOperation, error) {
+	if r == nil {
+		return nil, fmt.Errorf("plan: binary operation %q not registered", name)
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	op, ok := r.binary[name]
+	if !ok {
+		return nil, fmt.Errorf("plan: binary operation %q not registered", name)
+	}
+	return op, nil
+}
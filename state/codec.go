@@ -0,0 +1,80 @@
+// Package state provides stores and codecs for the states of stream
+// operators, so that stateful operators, such as Reduce, Batch or
+// ProcessByKey, can resume after a restart:
+//
+//	store, err := state.OpenFile("counts.state")
+//	...
+//	defer store.Close()
+//	strm.KeyBy(user).ProcessByKey(count).Named("counts").StateStore(store, state.Gob())
+//
+// States are stored under the name of their operator, see Stream.Named,
+// stateful operators sharing a store must have distinct names.
+package state
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"reflect"
+
+	"github.com/gofunky/automi/api"
+)
+
+// GobCodec is an api.Codec that encodes values with encoding/gob
+type GobCodec struct{}
+
+// Gob returns a codec that encodes values with encoding/gob.  Values are
+// encoded as interfaces, their concrete types, other than the basic
+// types, must be registered with gob.Register.
+func Gob() api.Codec {
+	return GobCodec{}
+}
+
+// Encode encodes value
+func (GobCodec) Encode(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode decodes a value encoded by Encode
+func (GobCodec) Decode(data []byte) (interface{}, error) {
+	var value interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// JSONCodec is an api.Codec that encodes values with encoding/json
+type JSONCodec struct {
+	valueType reflect.Type
+}
+
+// JSON returns a codec that encodes values with encoding/json, and decodes
+// them as values of the type of prototype.  If prototype is nil, values are
+// decoded as by json.Unmarshal into an interface{}, i.e. numbers as float64.
+func JSON(prototype interface{}) api.Codec {
+	return JSONCodec{valueType: reflect.TypeOf(prototype)}
+}
+
+// Encode encodes value
+func (c JSONCodec) Encode(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+// Decode decodes a value encoded by Encode
+func (c JSONCodec) Decode(data []byte) (interface{}, error) {
+	if c.valueType == nil {
+		var value interface{}
+		err := json.Unmarshal(data, &value)
+		return value, err
+	}
+	ptr := reflect.New(c.valueType)
+	if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+		return nil, err
+	}
+	return ptr.Elem().Interface(), nil
+}
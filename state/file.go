@@ -0,0 +1,301 @@
+package state
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	opPut    byte = 1
+	opDelete byte = 2
+
+	// logs smaller than minCompactSize are not compacted
+	minCompactSize = 1 << 20
+)
+
+// File is an api.StateStore persisted in a local file.  The file is an
+// append-only log of the changes of the store, which is replayed when the
+// store is opened and compacted once it grows to twice the size of the
+// stored values.  Values are also kept in memory.  It is safe for
+// concurrent use.
+//
+// Changes are written to the file as they are made, so that they survive
+// a crash of the process, but they are only synced to disk by Sync and
+// Close.  A change that was partly written is discarded on open.
+type File struct {
+	mutex  sync.RWMutex
+	path   string
+	file   *os.File
+	values map[string][]byte
+	size   int64 // size of the log
+	live   int64 // size of the records of the stored values
+	err    error
+}
+
+// OpenFile opens the store persisted in the named file,
+// which is created if it does not exist.
+func OpenFile(name string) (*File, error) {
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{path: name, file: file, values: make(map[string][]byte)}
+	if err := f.replay(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// replay loads the values from the log, and truncates
+// the log after its last complete change
+func (f *File) replay() error {
+	reader := bufio.NewReader(f.file)
+	for {
+		op, key, value, n, err := readRecord(reader)
+		if err != nil {
+			if err != io.EOF {
+				// a partly written change, or a corrupted one, ends the log
+				if err := f.file.Truncate(f.size); err != nil {
+					return err
+				}
+			}
+			break
+		}
+		f.apply(op, key, value, int64(n))
+		f.size += int64(n)
+	}
+	_, err := f.file.Seek(f.size, io.SeekStart)
+	return err
+}
+
+// apply applies a change of size n to the values
+func (f *File) apply(op byte, key string, value []byte, n int64) {
+	if old, ok := f.values[key]; ok {
+		f.live -= recordSize(key, old)
+	}
+	switch op {
+	case opPut:
+		f.values[key] = value
+		f.live += n
+	case opDelete:
+		delete(f.values, key)
+	}
+}
+
+// Get returns the value stored for key, if any
+func (f *File) Get(key string) ([]byte, bool, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	value, ok := f.values[key]
+	return value, ok, nil
+}
+
+// Put stores a copy of value for key
+func (f *File) Put(key string, value []byte) error {
+	return f.write(opPut, key, append([]byte{}, value...))
+}
+
+// Delete removes the value stored for key
+func (f *File) Delete(key string) error {
+	f.mutex.RLock()
+	_, ok := f.values[key]
+	f.mutex.RUnlock()
+	if !ok {
+		return nil
+	}
+	return f.write(opDelete, key, nil)
+}
+
+// Range calls f for the keys starting with prefix, and their
+// values, until fn returns false.  fn may modify the store.
+func (f *File) Range(prefix string, fn func(key string, value []byte) bool) error {
+	f.mutex.RLock()
+	var keys []string
+	for key := range f.values {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	f.mutex.RUnlock()
+
+	for _, key := range keys {
+		value, ok, _ := f.Get(key)
+		if ok && !fn(key, value) {
+			break
+		}
+	}
+	return nil
+}
+
+// write appends a change to the log and applies it
+func (f *File) write(op byte, key string, value []byte) error {
+	record := appendRecord(nil, op, key, value)
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	if _, err := f.file.Write(record); err != nil {
+		// the log may end with a partial change, which is
+		// discarded on open, but cannot be appended to
+		f.err = fmt.Errorf("state file %s: %w", f.path, err)
+		return f.err
+	}
+	f.apply(op, key, value, int64(len(record)))
+	f.size += int64(len(record))
+
+	if f.size >= minCompactSize && f.size >= 2*f.live {
+		return f.compact()
+	}
+	return nil
+}
+
+// Compact rewrites the log with the stored values only
+func (f *File) Compact() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	return f.compact()
+}
+
+func (f *File) compact() error {
+	tmpName := f.path + ".compact"
+	tmp, err := os.OpenFile(tmpName, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(tmp)
+	var size int64
+	var record []byte
+	for key, value := range f.values {
+		record = appendRecord(record[:0], opPut, key, value)
+		if _, err := writer.Write(record); err != nil {
+			tmp.Close()
+			os.Remove(tmpName)
+			return err
+		}
+		size += int64(len(record))
+	}
+	if err := writer.Flush(); err == nil {
+		err = tmp.Sync()
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+
+	// the compacted log replaces the log, which remains
+	// valid until then, and is appended to from now on
+	if err := os.Rename(tmpName, f.path); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	f.file.Close()
+	f.file = tmp
+	f.size, f.live = size, size
+	return nil
+}
+
+// Sync commits the changes written to the file to disk
+func (f *File) Sync() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.file.Sync()
+}
+
+// Close syncs and closes the file, the store cannot be used afterwards
+func (f *File) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.err == errClosed {
+		return nil
+	}
+	err := f.file.Sync()
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	f.err = errClosed
+	return err
+}
+
+var errClosed = errors.New("state file closed")
+
+// A record of the log is made of the CRC-32 checksum of the rest of the
+// record, the operation, the length of the key and the key, and for
+// puts, the length of the value and the value.  Lengths are varints.
+
+func recordSize(key string, value []byte) int64 {
+	return int64(len(appendRecord(nil, opPut, key, value)))
+}
+
+func appendRecord(buf []byte, op byte, key string, value []byte) []byte {
+	start := len(buf)
+	buf = append(buf, 0, 0, 0, 0, op)
+	buf = binary.AppendUvarint(buf, uint64(len(key)))
+	buf = append(buf, key...)
+	if op == opPut {
+		buf = binary.AppendUvarint(buf, uint64(len(value)))
+		buf = append(buf, value...)
+	}
+	binary.BigEndian.PutUint32(buf[start:], crc32.ChecksumIEEE(buf[start+4:]))
+	return buf
+}
+
+// readRecord reads a record and returns its size, it returns io.EOF at
+// the end of the log, and another error for partial or corrupted records
+func readRecord(reader *bufio.Reader) (op byte, key string, value []byte, n int, err error) {
+	var header [5]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, "", nil, 0, errors.New("partial record")
+		}
+		return 0, "", nil, 0, err
+	}
+	op = header[4]
+	if op != opPut && op != opDelete {
+		return 0, "", nil, 0, errors.New("invalid record")
+	}
+	keyData, err := readBytes(reader)
+	if err != nil {
+		return 0, "", nil, 0, err
+	}
+	if op == opPut {
+		if value, err = readBytes(reader); err != nil {
+			return 0, "", nil, 0, err
+		}
+	}
+	record := appendRecord(nil, op, string(keyData), value)
+	if binary.BigEndian.Uint32(header[:4]) != binary.BigEndian.Uint32(record) {
+		return 0, "", nil, 0, errors.New("corrupted record")
+	}
+	return op, string(keyData), value, len(record), nil
+}
+
+// readBytes reads a length-prefixed byte slice
+func readBytes(reader *bufio.Reader) ([]byte, error) {
+	length, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return nil, errors.New("partial record")
+	}
+	if length > 1<<30 {
+		return nil, errors.New("invalid record")
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, errors.New("partial record")
+	}
+	return data, nil
+}
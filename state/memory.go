@@ -0,0 +1,63 @@
+package state
+
+import (
+	"strings"
+	"sync"
+)
+
+// Memory is an in-memory api.StateStore, whose states do not survive a
+// restart of the process.  It is safe for concurrent use.
+type Memory struct {
+	mutex  sync.RWMutex
+	values map[string][]byte
+}
+
+// NewMemory creates a new, empty, *Memory store
+func NewMemory() *Memory {
+	return &Memory{values: make(map[string][]byte)}
+}
+
+// Get returns the value stored for key, if any
+func (m *Memory) Get(key string) ([]byte, bool, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	value, ok := m.values[key]
+	return value, ok, nil
+}
+
+// Put stores a copy of value for key
+func (m *Memory) Put(key string, value []byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.values[key] = append([]byte(nil), value...)
+	return nil
+}
+
+// Delete removes the value stored for key
+func (m *Memory) Delete(key string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+// Range calls f for the keys starting with prefix, and their values,
+// until f returns false.  f may modify the store.
+func (m *Memory) Range(prefix string, f func(key string, value []byte) bool) error {
+	m.mutex.RLock()
+	var keys []string
+	for key := range m.values {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	m.mutex.RUnlock()
+
+	for _, key := range keys {
+		value, ok, _ := m.Get(key)
+		if ok && !f(key, value) {
+			break
+		}
+	}
+	return nil
+}
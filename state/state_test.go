@@ -0,0 +1,200 @@
+package state
+
+import (
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gofunky/automi/api"
+)
+
+type session struct {
+	User   string
+	Clicks int
+}
+
+func init() {
+	gob.Register(session{})
+}
+
+func TestCodecs(t *testing.T) {
+	tests := []struct {
+		name     string
+		codec    api.Codec
+		value    interface{}
+		expected interface{}
+	}{
+		{name: "gob int", codec: Gob(), value: 42, expected: 42},
+		{name: "gob struct", codec: Gob(), value: session{"ann", 3}, expected: session{"ann", 3}},
+		{name: "gob slice", codec: Gob(), value: []string{"a", "b"}, expected: []string{"a", "b"}},
+		{name: "json struct", codec: JSON(session{}), value: session{"ann", 3}, expected: session{"ann", 3}},
+		{name: "json pointer", codec: JSON(&session{}), value: &session{"bob", 1}, expected: &session{"bob", 1}},
+		{name: "json untyped", codec: JSON(nil), value: 42, expected: float64(42)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := test.codec.Encode(test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			value, err := test.codec.Decode(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(value, test.expected) {
+				t.Fatalf("expecting %#v, got %#v", test.expected, value)
+			}
+		})
+	}
+
+	if _, err := Gob().Encode(struct{ A int }{1}); err == nil {
+		t.Fatal("expecting error for unregistered type")
+	}
+}
+
+// testStore checks the operations of an empty store
+func testStore(t *testing.T, store api.StateStore) {
+	if _, ok, err := store.Get("a/1"); ok || err != nil {
+		t.Fatal("expecting no value, got ", ok, err)
+	}
+	for key, value := range map[string]string{"a/1": "one", "a/2": "two", "b/1": "uno"} {
+		if err := store.Put(key, []byte(value)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Put("a/1", []byte("eins")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete("a/2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete("a/3"); err != nil {
+		t.Fatal(err)
+	}
+	if value, ok, err := store.Get("a/1"); !ok || err != nil || string(value) != "eins" {
+		t.Fatal("unexpected value ", string(value), ok, err)
+	}
+
+	var keys []string
+	err := store.Range("a/", func(key string, value []byte) bool {
+		keys = append(keys, key+"="+string(value))
+		return true
+	})
+	if err != nil || len(keys) != 1 || keys[0] != "a/1=eins" {
+		t.Fatal("unexpected range ", keys, err)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testStore(t, NewMemory())
+}
+
+func TestFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.state")
+	store, err := OpenFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, store)
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put("c", nil); err == nil {
+		t.Fatal("expecting error after close")
+	}
+
+	// the values are restored from the log
+	store, err = OpenFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	var keys []string
+	store.Range("", func(key string, value []byte) bool {
+		keys = append(keys, key+"="+string(value))
+		return true
+	})
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "a/1=eins" || keys[1] != "b/1=uno" {
+		t.Fatal("unexpected values after reopening ", keys)
+	}
+}
+
+func TestFile_PartialRecord(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.state")
+	store, err := OpenFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Put("a", []byte("one"))
+	store.Put("b", []byte("two"))
+	store.Close()
+
+	// drop the end of the last record, as a crash would
+	info, _ := os.Stat(name)
+	if err := os.Truncate(name, info.Size()-2); err != nil {
+		t.Fatal(err)
+	}
+	store, err = OpenFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := store.Get("b"); ok {
+		t.Fatal("expecting partial record to be discarded")
+	}
+	if err := store.Put("c", []byte("three")); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	store, err = OpenFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	a, _, _ := store.Get("a")
+	c, _, _ := store.Get("c")
+	if string(a) != "one" || string(c) != "three" {
+		t.Fatal("unexpected values ", string(a), string(c))
+	}
+}
+
+func TestFile_Compact(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.state")
+	store, err := OpenFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value := make([]byte, 1024)
+	for i := 0; i < 2048; i++ {
+		value[0] = byte(i)
+		if err := store.Put("counter", value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.Put("other", []byte("value"))
+	store.Delete("other")
+	if err := store.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	info, _ := os.Stat(name)
+	if info.Size() > 2*1024 {
+		t.Fatal("expecting compacted log, got size ", info.Size())
+	}
+	store, err = OpenFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if v, ok, _ := store.Get("counter"); !ok || v[0] != byte(2047%256) {
+		t.Fatal("unexpected value after compaction")
+	}
+	if _, ok, _ := store.Get("other"); ok {
+		t.Fatal("expecting deleted value to stay deleted")
+	}
+}
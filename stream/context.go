@@ -0,0 +1,85 @@
+package stream
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// streamContext is the context of a stream, which its operators are
+// created with as they are added.  The context set with WithContext may
+// be set after some operators were added, the stream context resolves to
+// it when the stream is opened: it carries its values and deadline, and
+// it is done once that context is done or the stream is cancelled.
+type streamContext struct {
+	mutex  sync.RWMutex
+	parent context.Context
+	done   chan struct{}
+	err    error
+}
+
+func newStreamContext() *streamContext {
+	return &streamContext{parent: context.Background(), done: make(chan struct{})}
+}
+
+// setParent sets the context the stream context resolves to
+func (c *streamContext) setParent(parent context.Context) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.parent = parent
+}
+
+func (c *streamContext) getParent() context.Context {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.parent
+}
+
+// Deadline returns the deadline of the parent context
+func (c *streamContext) Deadline() (time.Time, bool) {
+	return c.getParent().Deadline()
+}
+
+// Done returns a channel that is closed once the stream is cancelled
+func (c *streamContext) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the reason the stream context is done, if it is
+func (c *streamContext) Err() error {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.err
+}
+
+// Value returns the value of the parent context for key
+func (c *streamContext) Value(key interface{}) interface{} {
+	return c.getParent().Value(key)
+}
+
+// cancel closes the stream context with err, unless it is closed
+func (c *streamContext) cancel(err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	close(c.done)
+}
+
+// watch cancels the stream context once its parent is done.
+// It returns once either context is done.
+func (c *streamContext) watch() {
+	parent := c.getParent()
+	if parent.Done() == nil {
+		return
+	}
+	go func() {
+		select {
+		case <-parent.Done():
+			c.cancel(parent.Err())
+		case <-c.done:
+		}
+	}()
+}
@@ -0,0 +1,108 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrDrainTimeout is reported when a stream could not
+// be drained within the timeout passed to Handle.Drain.
+var ErrDrainTimeout = errors.New("stream drain timed out")
+
+// Handle controls a running stream, it is returned by Start.
+type Handle struct {
+	stream *Stream
+	done   chan struct{}
+	err    error
+}
+
+// Start opens the stream, as does Open, and returns a *Handle
+// that controls the running stream.
+//
+//	h := strm.Start()
+//	...
+//	if err := h.Drain(time.Second); err != nil {
+//		fmt.Println(err)
+//	}
+func (s *Stream) Start() *Handle {
+	h := &Handle{stream: s, done: make(chan struct{})}
+	result := s.Open()
+	go func() {
+		h.err = <-result
+		close(h.done)
+	}()
+	return h
+}
+
+// Done returns a channel that is closed once the stream is done
+func (h *Handle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Wait blocks until the stream is done and returns its error, see Open
+func (h *Handle) Wait() error {
+	<-h.done
+	return h.err
+}
+
+// Cancel cancels the context of the stream.  All nodes stop, items in
+// flight are dropped, and the stream reports context.Canceled.
+func (h *Handle) Cancel() {
+	h.stream.walk(func(s *Stream) {
+		s.ctx.cancel(context.Canceled)
+	})
+}
+
+// Pause stops pulling items from the sources of the stream.
+// Items already emitted continue through the stream.
+func (h *Handle) Pause() {
+	h.stream.walk(func(s *Stream) {
+		if s.valve != nil {
+			s.valve.pause()
+		}
+	})
+}
+
+// Resume resumes pulling items from the sources of a paused stream
+func (h *Handle) Resume() {
+	h.stream.walk(func(s *Stream) {
+		if s.valve != nil {
+			s.valve.unpause()
+		}
+	})
+}
+
+// Drain stops the sources of the stream and waits for the items in flight
+// to be flushed through the operators and sinks.  Batches, windows and
+// reductions emit their pending results as they do when the sources are
+// exhausted.  A paused stream is resumed to be drained.  If the stream is
+// not done within timeout, it is cancelled and ErrDrainTimeout is returned,
+// the errors of the cancelled stream are then reported by Wait.
+func (h *Handle) Drain(timeout time.Duration) error {
+	h.stream.walk(func(s *Stream) {
+		if s.valve != nil {
+			s.stopSource()
+			s.valve.unpause()
+		}
+	})
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-h.done:
+		return h.err
+	case <-timer.C:
+	}
+
+	h.Cancel()
+	return ErrDrainTimeout
+}
+
+// walk calls f for s and all of its child streams
+func (s *Stream) walk(f func(*Stream)) {
+	f(s)
+	for _, child := range s.children {
+		child.walk(f)
+	}
+}
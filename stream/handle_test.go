@@ -0,0 +1,157 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/collectors"
+)
+
+func TestHandle_Wait(t *testing.T) {
+	snk := collectors.Slice()
+	h := New([]int{1, 2, 3}).Into(snk).Start()
+
+	select {
+	case <-h.Done():
+		if err := h.Wait(); err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+	if len(snk.Get()) != 3 {
+		t.Fatal("expecting 3 items, got ", snk.Get())
+	}
+}
+
+func TestHandle_Cancel(t *testing.T) {
+	src := make(chan int)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case src <- i:
+			case <-time.After(time.Second):
+				return
+			}
+		}
+	}()
+
+	h := New(src).Map(func(i int) int { return i * 2 }).Batch().Start()
+	time.Sleep(5 * time.Millisecond)
+	h.Cancel()
+
+	select {
+	case <-h.Done():
+		if err := h.Wait(); !errors.Is(err, context.Canceled) {
+			t.Fatal("expecting cancellation error, got ", err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("stream not cancelled")
+	}
+}
+
+func TestHandle_Cancel_ContextSetLast(t *testing.T) {
+	started := make(chan struct{}, 2)
+	stopped := make(chan struct{}, 2)
+	blocking := api.UnFunc(func(ctx context.Context, item interface{}) (interface{}, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		stopped <- struct{}{}
+		return nil, ctx.Err()
+	})
+
+	// operators added before WithContext stop with its context
+	ctx, cancel := context.WithCancel(context.Background())
+	h := New([]int{1}).Transform(blocking).WithContext(ctx).Start()
+	<-started
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("operator not cancelled with the stream context")
+	}
+	if err := h.Wait(); !errors.Is(err, context.Canceled) {
+		t.Fatal("expecting cancellation error, got ", err)
+	}
+
+	// and with the handle
+	h = New([]int{1}).Transform(blocking).WithContext(context.Background()).Start()
+	<-started
+	h.Cancel()
+	select {
+	case <-stopped:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("operator not cancelled with the handle")
+	}
+	h.Wait()
+}
+
+func TestHandle_PauseResume(t *testing.T) {
+	src := make(chan int)
+	var count int32
+	h := New(src).Into(collectors.Func(func(interface{}) error {
+		atomic.AddInt32(&count, 1)
+		return nil
+	})).Start()
+
+	src <- 1
+	time.Sleep(5 * time.Millisecond)
+	h.Pause()
+	time.Sleep(5 * time.Millisecond)
+	src <- 2
+	src <- 3
+	time.Sleep(10 * time.Millisecond)
+	if c := atomic.LoadInt32(&count); c != 1 {
+		t.Fatal("expecting paused stream to hold items, got ", c)
+	}
+
+	h.Resume()
+	close(src)
+	if err := h.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if c := atomic.LoadInt32(&count); c != 3 {
+		t.Fatal("expecting 3 items after resume, got ", c)
+	}
+}
+
+func TestHandle_Drain(t *testing.T) {
+	src := make(chan int)
+	snk := collectors.Slice()
+	h := New(src).Batch().Into(snk).Start()
+
+	for i := 0; i < 3; i++ {
+		src <- i
+	}
+	time.Sleep(5 * time.Millisecond)
+	h.Pause()
+
+	if err := h.Drain(50 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if len(snk.Get()) != 1 || len(snk.Get()[0].([]int)) != 3 {
+		t.Fatal("expecting in-flight items to be batched, got ", snk.Get())
+	}
+}
+
+func TestHandle_Drain_Timeout(t *testing.T) {
+	started, block := make(chan struct{}), make(chan struct{})
+	defer close(block)
+	h := New([]int{1, 2}).Into(collectors.Func(func(item interface{}) error {
+		if item == 1 {
+			close(started)
+		}
+		<-block
+		return nil
+	})).Start()
+
+	<-started
+	err := h.Drain(10 * time.Millisecond)
+	if !errors.Is(err, ErrDrainTimeout) {
+		t.Fatal("expecting drain timeout, got ", err)
+	}
+}
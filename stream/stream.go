@@ -5,13 +5,18 @@ import (
 	"errors"
 	"io"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-faces/logger"
 	"github.com/gofunky/automi/api"
 	autoctx "github.com/gofunky/automi/api/context"
 	"github.com/gofunky/automi/collectors"
 	"github.com/gofunky/automi/emitters"
+	"github.com/gofunky/automi/operators/branch"
 	streamop "github.com/gofunky/automi/operators/stream"
 	"github.com/gofunky/automi/util"
 )
@@ -20,13 +25,34 @@ import (
 // attached to operate on the streamed data
 type Stream struct {
 	srcParam interface{}
+	unions   []interface{}
+	tagged   bool
 	snkParam interface{}
 	source   api.Source
 	sink     api.Sink
 	drain    chan error
+	errs     []error
 	ops      []api.Operator
-	ctx      context.Context
+	ctx      *streamContext
 	log      logger.Interface
+
+	valve      *valve
+	srcCtx     context.Context
+	stopSource context.CancelFunc
+	inner      bool
+
+	nodes []*node
+	sides []*sideOutput
+	side  bool
+
+	timestamps api.TimestampExtractor
+	lateness   time.Duration
+	keys       api.UnOperation
+
+	fanout   *branch.BranchOperator
+	branchAt int
+	children []*Stream
+	parent   *Stream
 }
 
 // New creates a new *Stream value
@@ -35,17 +61,30 @@ func New(src interface{}) *Stream {
 		srcParam: src,
 		ops:      make([]api.Operator, 0),
 		drain:    make(chan error),
-		ctx:      context.Background(),
+		ctx:      newStreamContext(),
 	}
-	s.log = autoctx.GetLogger(s.ctx)
 	return s
 }
 
+// Merge creates a new *Stream that multiplexes the items emitted by all
+// the specified sources.  Each source parameter accepts any value supported
+// by New.  The merged stream is done once all of its sources are done.
+func Merge(srcs ...interface{}) *Stream {
+	if len(srcs) == 0 {
+		return New(nil)
+	}
+	return New(srcs[0]).Union(srcs[1:]...)
+}
+
 // WithContext sets a context.Context to use.
 // Provide a context with a logger.Interface to turn on logging.
 // i.e. log.New(os.Stderr, log.Prefix(), log.Flags())
+// The stream stops once ctx is done, or once it is cancelled, see Handle.
+// The operators read the values of the context, such as the logger, when
+// they are added, WithContext is then to be called first.
 func (s *Stream) WithContext(ctx context.Context) *Stream {
-	s.ctx = ctx
+	s.ctx.setParent(ctx)
+	s.log = autoctx.GetLogger(ctx)
 	return s
 }
 
@@ -55,51 +94,237 @@ func (s *Stream) WithContext(ctx context.Context) *Stream {
 // 	return s
 // }
 
-// Into sets the terminal stream sink to use
+// Into sets the terminal stream sink to use.  Besides an api.Sink, the
+// sink may be a file name, an *os.File or an io.Writer, a pointer to a
+// slice, to which the items are appended, or a channel, to which the items
+// are sent, and which is closed once all items are sent.  Items are
+// converted to the element type of slices and channels, if needed.
+//
+//	var words []string
+//	strm.Into(&words)
 func (s *Stream) Into(snk interface{}) *Stream {
 	s.snkParam = snk
 	return s
 }
 
+// Union adds the specified sources to the stream so that their items
+// are multiplexed with the items of the stream source.
+func (s *Stream) Union(srcs ...interface{}) *Stream {
+	s.unions = append(s.unions, srcs...)
+	return s
+}
+
+// TagOrigin tags each item of a merged stream with its origin.  Items are
+// streamed as tuple.Tagged{origin, item} where origin is the index of the
+// source, the first source having index 0.
+func (s *Stream) TagOrigin() *Stream {
+	s.tagged = true
+	return s
+}
+
 // ReStream takes upstream items of types []slice []array, map[T]
 // and emmits their elements as individual channel items to downstream
 // operations.  Items of other types are ignored.
 func (s *Stream) ReStream() *Stream {
-	sop := streamop.New(s.ctx)
+	sop := streamop.New(s.nodeCtx("reStream"))
 	s.ops = append(s.ops, sop)
 	return s
 }
 
 // Open opens the Stream which executes all operators nodes.
-// If there's an issue prior to execution, an error is returned
-// in the error channel.
+// Once the stream is done, Open sends nil on the returned channel, or an
+// *ErrorReport listing all errors reported by the composition, the
+// operators, and the sinks of the stream.  When the stream has branches
+// or side outputs, Open waits for all of their sinks.
 func (s *Stream) Open() <-chan error {
+	if s.parent != nil {
+		return s.fail(errors.New("branch streams are opened by their parent stream"))
+	}
+
+	report := new(ErrorReport)
+	s.collectErrs(report)
+	if len(report.Errors) > 0 {
+		return s.fail(report.Errors...)
+	}
+
+	s.nameNodes()
 	if err := s.initGraph(); err != nil {
-		s.drainErr(err)
-		return s.drain
+		return s.fail(err)
 	}
 
-	// open stream
+	// collect errors reported by nodes while the stream runs
+	failures := make(chan error)
+	done := make(chan struct{})
+	collected := make(chan struct{})
 	go func() {
-		// open source, if err bail
-		if err := s.source.Open(s.ctx); err != nil {
-			s.drainErr(err)
-			return
+		defer close(collected)
+		for {
+			select {
+			case err := <-failures:
+				report.add(err)
+			case <-done:
+				return
+			}
 		}
-		// apply operators, if err bail
-		for _, op := range s.ops {
-			op.Exec(s.drain)
-		}
-		// open sink and block until stream is done
-		select {
-		case err := <-s.sink.Open(s.ctx):
-			s.drain <- err
+	}()
+
+	// open stream
+	go func() {
+		// open source, operators, and sinks, if err bail
+		var routers sync.WaitGroup
+		run := newRun()
+		err := s.exec(failures, run, &routers)
+
+		// block until all sinks are done, side outputs are
+		// done once the operators feeding them are done
+		var errs []error
+		if err == nil {
+			errs = run.await()
+		} else {
+			run.stop()
 		}
+		routers.Wait()
+		close(done)
+		<-collected
+
+		report.add(err)
+		s.walk(func(s *Stream) {
+			if src, ok := s.source.(failingSource); ok {
+				report.add(src.Err())
+			}
+		})
+		report.add(errs...)
+		// report cancellation of a stream that did not complete
+		report.add(s.ctx.Err())
+		s.walk(func(s *Stream) { s.ctx.cancel(context.Canceled) })
+		s.drain <- report.err()
 	}()
 
 	return s.drain
 }
 
+// run holds the sinks opened for a stream, along with its branches and
+// joined streams, and the runs of its side outputs.  The side items of
+// the stream are routed until the sinks of the run are done.
+type run struct {
+	results []<-chan error
+	done    chan struct{}
+	sides   []*run
+}
+
+func newRun() *run {
+	return &run{done: make(chan struct{})}
+}
+
+// await blocks until the sinks of the run are done, then until the sinks
+// of its side outputs are done, and returns their errors
+func (r *run) await() []error {
+	errs := awaitSinks(r.results)
+	close(r.done)
+	for _, side := range r.sides {
+		errs = append(errs, side.await()...)
+	}
+	return errs
+}
+
+// stop stops routing the side items of the run and of its side outputs
+func (r *run) stop() {
+	close(r.done)
+	for _, side := range r.sides {
+		side.stop()
+	}
+}
+
+// exec opens the source, executes the operators, and opens the sink
+// of the stream and of all its child streams (branches, joined streams,
+// side outputs).  The opened sinks are added to r, the sinks of side
+// outputs being added to runs of their own, so that the side items of a
+// stream are routed until its own operators are done.
+func (s *Stream) exec(drain chan error, r *run, routers *sync.WaitGroup) error {
+	s.routeSide(r.done, routers)
+
+	// open source, if err bail
+	if err := s.source.Open(s.srcCtx); err != nil {
+		return err
+	}
+	// apply operators
+	if s.valve != nil {
+		s.valve.Exec(drain)
+	}
+	for _, op := range s.ops {
+		op.Exec(drain)
+	}
+	r.results = append(r.results, s.sink.Open(s.ctx))
+
+	for _, child := range s.children {
+		childRun := r
+		if child.side {
+			childRun = newRun()
+			r.sides = append(r.sides, childRun)
+		}
+		if err := child.exec(drain, childRun, routers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// awaitSinks blocks until all sinks are done and returns their errors
+func awaitSinks(results []<-chan error) []error {
+	var errs []error
+	for _, result := range results {
+		if err := <-result; err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Branch splits the stream into count branches.  Each item reaching this
+// point of the stream is sent to every branch.  Each returned branch is a
+// stream that can have its own operators and sink.  The slowest branch
+// governs the pace of the stream, use BranchWith to select another
+// backpressure.
+//
+// Branches are opened along with their parent when Open is called
+// on the root stream.  No operator or sink can be added to the
+// parent stream once it is branched.
+func (s *Stream) Branch(count int) []*Stream {
+	return s.BranchWith(count, branch.Blocking())
+}
+
+// BranchWith splits the stream into count branches using the provided
+// backpressure to select how items are buffered and dropped for
+// each branch.  See Branch.
+//
+// See Also
+//
+//	"github.com/gofunky/automi/operators/branch"#Buffered
+func (s *Stream) BranchWith(count int, bp branch.Backpressure) []*Stream {
+	if s.fanout != nil {
+		s.drainErr(errors.New("stream is already branched"))
+		return nil
+	}
+
+	s.fanout = branch.New(s.nodeCtx("branch"), count)
+	s.fanout.SetBackpressure(bp)
+	s.branchAt = len(s.ops)
+	branches := make([]*Stream, count)
+	for i := range branches {
+		branches[i] = s.adopt(New(s.fanout.Branch(i)).WithContext(s.ctx))
+		branches[i].inner = true
+	}
+	return branches
+}
+
+// adopt makes child a child stream of s, opened along with s
+func (s *Stream) adopt(child *Stream) *Stream {
+	child.drain = s.drain
+	child.parent = s
+	s.children = append(s.children, child)
+	return child
+}
+
 // bindOps binds operator channels
 func (s *Stream) bindOps() {
 	util.Log(s.log, "binding operators")
@@ -108,16 +333,26 @@ func (s *Stream) bindOps() {
 	}
 	for i, op := range s.ops {
 		if i == 0 { // link 1st to source
-			op.SetInput(s.source.GetOutput())
+			op.SetInput(s.head())
 		} else {
 			op.SetInput(s.ops[i-1].GetOutput())
 		}
 	}
 }
 
+// head returns the channel of items flowing out of the
+// source, through the valve if the stream has one
+func (s *Stream) head() <-chan interface{} {
+	if s.valve != nil {
+		return s.valve.GetOutput()
+	}
+	return s.source.GetOutput()
+}
+
 // initGraph initialize stream graph source + ops +
 func (s *Stream) initGraph() error {
 	util.Log(s.log, "preparing stream operator graph")
+	s.ctx.watch()
 
 	// setup source type
 	if err := s.setupSource(); err != nil {
@@ -129,19 +364,34 @@ func (s *Stream) initGraph() error {
 		return err
 	}
 
-	// if there are no ops, link source to sink
+	// the source can be stopped on its own to drain the stream, the
+	// valve gates the items of the source of streams that are not
+	// fed by their parent (branches, side outputs)
+	s.srcCtx, s.stopSource = context.WithCancel(s.ctx)
+	if !s.inner {
+		s.valve = newValve(s.ctx)
+		s.valve.SetInput(s.source.GetOutput())
+	}
+
 	if len(s.ops) == 0 && s.sink != nil {
+		// if there are no ops, link source to sink
 		util.Log(s.log, "no operator nodes found, binding source to sink directly")
-		s.sink.SetInput(s.source.GetOutput())
-		return nil
-	}
+		s.sink.SetInput(s.head())
+	} else {
+		// link ops
+		s.bindOps()
 
-	// link ops
-	s.bindOps()
+		// link last op to sink
+		if s.sink != nil {
+			s.sink.SetInput(s.ops[len(s.ops)-1].GetOutput())
+		}
+	}
 
-	// link last op to sink
-	if s.sink != nil {
-		s.sink.SetInput(s.ops[len(s.ops)-1].GetOutput())
+	// setup child stream graphs
+	for _, child := range s.children {
+		if err := child.initGraph(); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -149,42 +399,84 @@ func (s *Stream) initGraph() error {
 
 // setupSource checks the source, setup the proper type or return err if problem
 func (s *Stream) setupSource() error {
-	if s.srcParam == nil {
-		return errors.New("stream missing source parameter")
+	src, err := resolveSource(s.srcParam)
+	if err != nil {
+		return err
+	}
+	if len(s.unions) == 0 && !s.tagged {
+		s.source = src
+		return nil
+	}
+
+	// multiplex all sources
+	srcs := []api.Source{src}
+	for _, param := range s.unions {
+		src, err := resolveSource(param)
+		if err != nil {
+			return err
+		}
+		srcs = append(srcs, src)
+	}
+	merged := emitters.Merge(srcs...)
+	if s.tagged {
+		merged.Tagged()
+	}
+	s.source = merged
+	return nil
+}
+
+// resolveSource returns the api.Source for the source parameter
+func resolveSource(param interface{}) (api.Source, error) {
+	if param == nil {
+		return nil, errors.New("stream missing source parameter")
 	}
 
 	// check specific type
-	switch src := s.srcParam.(type) {
+	switch src := param.(type) {
 	case api.Source:
-		s.source = src
+		return src, nil
 	case *os.File:
+		if isJSONFile(src.Name()) {
+			return emitters.JSON(src), nil
+		}
 		// assume csv
-		s.source = emitters.CSV(src)
+		return emitters.CSV(src), nil
 	case string:
+		if isJSONFile(src) {
+			return emitters.JSON(src), nil
+		}
 		// assume csv file name
-		s.source = emitters.CSV(src)
+		return emitters.CSV(src), nil
 	case io.Reader:
-		s.source = emitters.Reader(src)
+		return emitters.Reader(src), nil
 	}
 
 	// check on type kind
-	srcType := reflect.TypeOf(s.srcParam)
+	srcType := reflect.TypeOf(param)
 	switch srcType.Kind() {
 	case reflect.Slice:
-		s.source = emitters.Slice(s.srcParam)
+		return emitters.Slice(param), nil
 	case reflect.Chan:
-		s.source = emitters.Chan(s.srcParam)
-	}
-
-	if s.source == nil {
-		return errors.New("invalid source")
+		return emitters.Chan(param), nil
 	}
 
-	return nil
+	return nil, errors.New("invalid source")
 }
 
 // setupSink checks the sink param, setup the proper type or return err if problem
 func (s *Stream) setupSink() error {
+	// a branched stream terminates into its branches
+	if s.fanout != nil {
+		if s.snkParam != nil {
+			return errors.New("branched stream cannot have a sink")
+		}
+		if len(s.ops) != s.branchAt {
+			return errors.New("operators cannot be added to a branched stream")
+		}
+		s.sink = s.fanout
+		return nil
+	}
+
 	// if sink param is nil, use null collector
 	if s.snkParam == nil {
 		s.sink = collectors.Null()
@@ -196,9 +488,17 @@ func (s *Stream) setupSink() error {
 	case api.Sink:
 		s.sink = snk
 	case string:
+		if isJSONFile(snk) {
+			s.sink = jsonSink(snk, snk)
+			break
+		}
 		// assume csv file name
 		s.sink = collectors.CSV(snk)
 	case *os.File:
+		if isJSONFile(snk.Name()) {
+			s.sink = jsonSink(snk, snk.Name())
+			break
+		}
 		// assume csv file
 		s.sink = collectors.CSV(snk)
 	case io.Writer:
@@ -210,7 +510,12 @@ func (s *Stream) setupSink() error {
 		switch srcType.Kind() {
 		case reflect.Slice:
 			s.sink = collectors.Slice()
+		case reflect.Ptr:
+			if srcType.Elem().Kind() == reflect.Slice {
+				s.sink = collectors.Append(s.snkParam)
+			}
 		case reflect.Chan:
+			s.sink = collectors.Chan(s.snkParam).CloseOnFinish()
 		}
 	}
 
@@ -221,8 +526,45 @@ func (s *Stream) setupSink() error {
 	return nil
 }
 
+// isJSONFile reports whether the named file holds JSON values,
+// files of other names are assumed to hold CSV records
+func isJSONFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json", ".ndjson":
+		return true
+	}
+	return false
+}
+
+// jsonSink returns the collector of the named JSON file, items are
+// written as NDJSON to .ndjson files and as an array to .json files
+func jsonSink(sink interface{}, name string) api.Sink {
+	snk := collectors.JSON(sink)
+	if strings.ToLower(filepath.Ext(name)) == ".json" {
+		snk.Array()
+	}
+	return snk
+}
+
+// drainErr records an error found while composing the stream,
+// it is reported when the stream is opened
 func (s *Stream) drainErr(err error) {
 	if err != nil {
-		go func() { s.drain <- err }()
+		s.errs = append(s.errs, err)
 	}
 }
+
+// collectErrs adds the composition errors of s and its children to report
+func (s *Stream) collectErrs(report *ErrorReport) {
+	report.add(s.errs...)
+	for _, child := range s.children {
+		child.collectErrs(report)
+	}
+}
+
+// fail reports errs on the drain without running the stream
+func (s *Stream) fail(errs ...error) <-chan error {
+	report := &ErrorReport{Errors: errs}
+	go func() { s.drain <- report }()
+	return s.drain
+}
@@ -1,23 +1,42 @@
 package stream
 
 import (
+	"errors"
+	"time"
+
 	"github.com/gofunky/automi/api"
 	"github.com/gofunky/automi/operators/batch"
 	"github.com/gofunky/automi/operators/unary"
 )
 
 func (s *Stream) Batch() *Stream {
-	operator := batch.New(s.ctx)
+	operator := batch.New(s.nodeCtx("batch"))
 	operator.SetTrigger(batch.TriggerAll())
 	return s.appendOp(operator)
 }
 
 func (s *Stream) BatchBySize(size int64) *Stream {
-	operator := batch.New(s.ctx)
+	operator := batch.New(s.nodeCtx("batchBySize"))
 	operator.SetTrigger(batch.TriggerBySize(size))
 	return s.appendOp(operator)
 }
 
+// BatchByEventTime batches incoming items into consecutive event-time
+// intervals of the given size.  Each interval is batched separately, its
+// batch is emitted once the watermark passes the end of the interval.  Items arriving once their batch was
+// emitted are sent as api.LateItem values to the side outputs of the
+// stream, see SideOutput.  The stream timestamps must be set using
+// WithTimestamps first.
+func (s *Stream) BatchByEventTime(size time.Duration) *Stream {
+	if s.timestamps == nil {
+		s.drainErr(errors.New("batch by event time requires stream timestamps"))
+		return s
+	}
+	operator := batch.New(s.nodeCtx("batchByEventTime"))
+	operator.SetTrigger(batch.TriggerByEventTime(s.timestamps, size, s.lateness))
+	return s.appendOp(operator)
+}
+
 // GroupByKey groups incoming items that are batched as
 // type []map[K]V where parameter key is used to group
 // the items when K=key.  Items with same key values are
@@ -28,7 +47,7 @@ func (s *Stream) BatchBySize(size int64) *Stream {
 // See batch operator function GroupByKey in
 //   "github.com/gofunky/automi/operators/batch/"#GroupByKeyFunc
 func (s *Stream) GroupByKey(key interface{}) *Stream {
-	operator := unary.New(s.ctx)
+	operator := unary.New(s.nodeCtx("groupByKey"))
 	operator.SetOperation(batch.GroupByKeyFunc(key))
 	return s.appendOp(operator)
 }
@@ -43,7 +62,7 @@ func (s *Stream) GroupByKey(key interface{}) *Stream {
 // See batch operator function GroupByName in
 //    "github.com/gofunky/automi/operators/batch"
 func (s *Stream) GroupByName(name string) *Stream {
-	operator := unary.New(s.ctx)
+	operator := unary.New(s.nodeCtx("groupByName"))
 	operator.SetOperation(batch.GroupByNameFunc(name))
 	return s.appendOp(operator)
 }
@@ -57,7 +76,7 @@ func (s *Stream) GroupByName(name string) *Stream {
 // See the batch operator function GroupByPosFunc in
 //   "github.com/gofunky/automi/operators/batch"
 func (s *Stream) GroupByPos(pos int) *Stream {
-	operator := unary.New(s.ctx)
+	operator := unary.New(s.nodeCtx("groupByPos"))
 	operator.SetOperation(batch.GroupByPosFunc(pos))
 	return s.appendOp(operator)
 }
@@ -70,7 +89,7 @@ func (s *Stream) GroupByPos(pos int) *Stream {
 // See also the operator function SortFunc in
 //   "github.com/gofunky/automi/operators/batch"
 func (s *Stream) Sort() *Stream {
-	operator := unary.New(s.ctx)
+	operator := unary.New(s.nodeCtx("sort"))
 	operator.SetOperation(batch.SortFunc())
 	return s.appendOp(operator)
 }
@@ -84,7 +103,7 @@ func (s *Stream) Sort() *Stream {
 // See also the operator function SortByKeyFunc in
 //   "github.com/gofunky/automi/operators/batch"
 func (s *Stream) SortByKey(key interface{}) *Stream {
-	operator := unary.New(s.ctx)
+	operator := unary.New(s.nodeCtx("sortByKey"))
 	operator.SetOperation(batch.SortByKeyFunc(key))
 	return s.appendOp(operator)
 }
@@ -98,7 +117,7 @@ func (s *Stream) SortByKey(key interface{}) *Stream {
 // See also the operator function SortByNameFunc in
 //   "github.com/gofunky/automi/operators/batch"
 func (s *Stream) SortByName(name string) *Stream {
-	operator := unary.New(s.ctx)
+	operator := unary.New(s.nodeCtx("sortByName"))
 	operator.SetOperation(batch.SortByNameFunc(name))
 	return s.appendOp(operator)
 }
@@ -112,7 +131,7 @@ func (s *Stream) SortByName(name string) *Stream {
 // See also the operator function SortByPosFunc in
 //   "github.com/gofunky/automi/operators/batch"
 func (s *Stream) SortByPos(pos int) *Stream {
-	operator := unary.New(s.ctx)
+	operator := unary.New(s.nodeCtx("sortByPos"))
 	operator.SetOperation(batch.SortByPosFunc(pos))
 	return s.appendOp(operator)
 }
@@ -125,7 +144,7 @@ func (s *Stream) SortByPos(pos int) *Stream {
 // See also the operator function SortWithFunc in
 //   "github.com/gofunky/automi/operators/batch"
 func (s *Stream) SortWith(f func(batch interface{}, i, j int) bool) *Stream {
-	operator := unary.New(s.ctx)
+	operator := unary.New(s.nodeCtx("sortWith"))
 	operator.SetOperation(batch.SortWithFunc(f))
 	return s.appendOp(operator)
 }
@@ -139,7 +158,7 @@ func (s *Stream) SortWith(f func(batch interface{}, i, j int) bool) *Stream {
 // See also the operator function SumFunc in
 //   "github.com/gofunky/automi/operators/batch"
 func (s *Stream) Sum() *Stream {
-	operator := unary.New(s.ctx)
+	operator := unary.New(s.nodeCtx("sum"))
 	operator.SetOperation(batch.SumFunc())
 	return s.appendOp(operator)
 }
@@ -158,7 +177,7 @@ func (s *Stream) Sum() *Stream {
 // See also the operator function SumByKeyFunc in
 //   "github.com/gofunky/automi/operators/batch"
 func (s *Stream) SumByKey(key interface{}) *Stream {
-	operator := unary.New(s.ctx)
+	operator := unary.New(s.nodeCtx("sumByKey"))
 	operator.SetOperation(batch.SumByKeyFunc(key))
 	return s.appendOp(operator)
 }
@@ -179,7 +198,7 @@ func (s *Stream) SumAllKeys() *Stream {
 // See also the operator function SumByNameFunc in
 //   "github.com/gofunky/automi/operator/batch"
 func (s *Stream) SumByName(name string) *Stream {
-	operator := unary.New(s.ctx)
+	operator := unary.New(s.nodeCtx("sumByName"))
 	operator.SetOperation(batch.SumByNameFunc(name))
 	return s.appendOp(operator)
 }
@@ -194,7 +213,7 @@ func (s *Stream) SumByName(name string) *Stream {
 // See also the operator function SumByPosFunc in
 //   "github.com/gofunky/automi/operator/batch"
 func (s *Stream) SumByPos(pos int) *Stream {
-	operator := unary.New(s.ctx)
+	operator := unary.New(s.nodeCtx("sumByPos"))
 	operator.SetOperation(batch.SumByPosFunc(pos))
 	return s.appendOp(operator)
 }
@@ -1,6 +1,15 @@
 package stream
 
-import "github.com/gofunky/automi/operators/binary"
+import (
+	"errors"
+	"runtime"
+	"time"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/operators/batch"
+	"github.com/gofunky/automi/operators/binary"
+	"github.com/gofunky/automi/operators/unary"
+)
 
 // Reduce accumulates and reduces items from upstream into a
 // single value using the initial seed value and the reduction
@@ -9,11 +18,12 @@ import "github.com/gofunky/automi/operators/binary"
 //     where S is the type of the partial result
 //     T is the incoming item from the stream
 //     R is the type of the result, to be used in the next call
+// f may also be an api.BinOperation applied to the partial result and the item.
 // If reductive operations are called after open-ended emitters
 // (i.e. network service), they may never end.
 func (s *Stream) Reduce(seed, f interface{}) *Stream {
-	operator := binary.New(s.ctx)
-	op, err := binary.ReduceFunc(f)
+	operator := binary.New(s.nodeCtx("reduce"))
+	op, err := binaryOperation(f)
 	if err != nil {
 		s.drainErr(err)
 		return s
@@ -23,3 +33,156 @@ func (s *Stream) Reduce(seed, f interface{}) *Stream {
 	s.ops = append(s.ops, operator)
 	return s
 }
+
+// Scan accumulates items from upstream like Reduce, but emits the
+// accumulated value after each item rather than once the stream is
+// drained, which suits sources that may never end.  See EmitEach and
+// EmitEvery to emit it less often.
+//
+//	strm.Scan(0, func(total, amount int) int { return total + amount })
+func (s *Stream) Scan(seed, f interface{}) *Stream {
+	operator := binary.New(s.nodeCtx("scan"))
+	op, err := binaryOperation(f)
+	if err != nil {
+		s.drainErr(err)
+		return s
+	}
+	operator.SetOperation(op)
+	operator.SetInitialState(seed)
+	operator.SetEmitCount(1)
+	return s.appendOp(operator)
+}
+
+// ScanByKey accumulates items from upstream into a running value per
+// key, starting from seed for each of them, and emits the accumulated
+// value of the key of each item as tuple.KV{key, value}.  The key is
+// returned by the function key, of type func(T) K, and the accumulation
+// function f is of type func(S, T) S, see Reduce.
+//
+//	strm.ScanByKey(func(o Order) string { return o.Customer }, 0.0,
+//		func(total float64, o Order) float64 { return total + o.Amount })
+func (s *Stream) ScanByKey(key, seed, f interface{}) *Stream {
+	keyOp, err := unaryOperation(key)
+	if err != nil {
+		s.drainErr(err)
+		return s
+	}
+	op, err := binaryOperation(f)
+	if err != nil {
+		s.drainErr(err)
+		return s
+	}
+	return s.transform("scanByKey", unary.ScanByKeyFunc(keyOp, seed, op))
+}
+
+// Combine sets the function that merges two partial results of the last
+// added Reduce, or Scan.  With a combiner, each of the workers set with Parallel
+// reduces its share of the items from the seed, and the partial results
+// are merged when the result is emitted.  The seed must then be neutral
+// to the combiner, such as 0 for a sum.  The combiner must be of type
+// func(R, R) R, or an api.BinOperation.
+//
+//	strm.Reduce(0, add).Combine(add).Parallel(4)
+func (s *Stream) Combine(f interface{}) *Stream {
+	op, err := binaryOperation(f)
+	if err != nil {
+		s.drainErr(err)
+		return s
+	}
+	operator, ok := s.lastReduce()
+	if !ok {
+		s.drainErr(errors.New("combine requires a reduce or scan operator"))
+		return s
+	}
+	operator.SetCombiner(op)
+	return s
+}
+
+// EmitEvery emits the partial result of the last added Reduce, or Scan,
+// at each interval, for sources that may never end.  The final result is
+// still emitted once the stream is drained.
+//
+//	strm.Reduce(0, add).EmitEvery(time.Second)
+func (s *Stream) EmitEvery(interval time.Duration) *Stream {
+	if interval <= 0 {
+		s.drainErr(errors.New("emit interval must be positive"))
+		return s
+	}
+	operator, ok := s.lastReduce()
+	if !ok {
+		s.drainErr(errors.New("emit interval requires a reduce or scan operator"))
+		return s
+	}
+	operator.SetEmitInterval(interval)
+	return s
+}
+
+// EmitEach emits the partial result of the last added Reduce, or Scan,
+// after each n items.  The final result is emitted once the stream is
+// drained, unless it was emitted after the last item.
+//
+//	strm.Scan(0, add).EmitEach(100)
+func (s *Stream) EmitEach(n int) *Stream {
+	if n < 1 {
+		s.drainErr(errors.New("emit count must be positive"))
+		return s
+	}
+	operator, ok := s.lastReduce()
+	if !ok {
+		s.drainErr(errors.New("emit count requires a reduce or scan operator"))
+		return s
+	}
+	operator.SetEmitCount(n)
+	return s
+}
+
+// ReduceEach reduces each batch, or window, of items into a single value
+// using the seed and the reduction function f, see Reduce.  If combine is
+// not nil, each batch is split into parts that are reduced concurrently
+// and whose partial results are merged with combine, see Combine.
+//
+// See Also
+//
+//	"github.com/gofunky/automi/operators/batch"#ReduceFunc
+func (s *Stream) ReduceEach(seed, f, combine interface{}) *Stream {
+	op, err := binaryOperation(f)
+	if err != nil {
+		s.drainErr(err)
+		return s
+	}
+	var combiner api.BinOperation
+	if combine != nil {
+		if combiner, err = binaryOperation(combine); err != nil {
+			s.drainErr(err)
+			return s
+		}
+	}
+	operator := unary.New(s.nodeCtx("reduceEach"))
+	operator.SetOperation(batch.ReduceFunc(seed, op, combiner, runtime.GOMAXPROCS(0)))
+	return s.appendOp(operator)
+}
+
+// lastReduce returns the last added operator if it is a reduction, or a scan
+func (s *Stream) lastReduce() (*binary.BinaryOperator, bool) {
+	if len(s.ops) == 0 {
+		return nil, false
+	}
+	operator, ok := s.ops[len(s.ops)-1].(*binary.BinaryOperator)
+	return operator, ok
+}
+
+// unaryOperation returns f, or the unary operation applying f
+func unaryOperation(f interface{}) (api.UnOperation, error) {
+	if op, ok := f.(api.UnOperation); ok {
+		return op, nil
+	}
+	return unary.ProcessFunc(f)
+}
+
+// binaryOperation returns f, or the binary operation applying f
+func binaryOperation(f interface{}) (api.BinOperation, error) {
+	if op, ok := f.(api.BinOperation); ok {
+		return op, nil
+	}
+	return binary.ReduceFunc(f)
+}
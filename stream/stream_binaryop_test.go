@@ -2,6 +2,7 @@ package stream
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -46,3 +47,182 @@ func TestStream_Reduce_WithError(t *testing.T) {
 		t.Fatal("Took too long")
 	}
 }
+
+func TestStream_Reduce_Parallel(t *testing.T) {
+	items := make([]int, 1000)
+	for i := range items {
+		items[i] = i + 1
+	}
+	snk := collectors.Slice()
+	add := func(op1, op2 int) int {
+		return op1 + op2
+	}
+	strm := New(items).Reduce(0, add).Combine(add).Parallel(4).Into(snk)
+
+	if err := <-strm.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if len(snk.Get()) != 1 || snk.Get()[0] != 500500 {
+		t.Fatal("expecting 500500, got ", snk.Get())
+	}
+}
+
+func TestStream_Reduce_EmitEvery(t *testing.T) {
+	items := make(chan int)
+	go func() {
+		for i := 1; i <= 4; i++ {
+			items <- i
+			time.Sleep(10 * time.Millisecond)
+		}
+		close(items)
+	}()
+	snk := collectors.Slice()
+	strm := Reduce(Of[int](items), 0, func(sum, i int) int { return sum + i }).
+		Combine(func(sum0, sum1 int) int { return sum0 + sum1 }).
+		EmitEvery(5 * time.Millisecond).
+		Into(snk)
+
+	if err := <-strm.Open(); err != nil {
+		t.Fatal(err)
+	}
+	results := snk.Get()
+	if len(results) < 2 || results[len(results)-1] != 10 {
+		t.Fatal("expecting intermediate sums and a final sum of 10, got ", results)
+	}
+}
+
+func TestStream_ReduceEach(t *testing.T) {
+	snk := collectors.Slice()
+	add := func(op1, op2 int) int {
+		return op1 + op2
+	}
+	strm := New([]int{1, 2, 3, 4, 5, 6, 7}).BatchBySize(3).ReduceEach(0, add, add).Into(snk)
+
+	if err := <-strm.Open(); err != nil {
+		t.Fatal(err)
+	}
+	result := snk.Get()
+	if len(result) != 3 || result[0] != 6 || result[1] != 15 || result[2] != 7 {
+		t.Fatal("unexpected sums ", result)
+	}
+}
+
+func TestStream_Combine_Unsupported(t *testing.T) {
+	add := func(op1, op2 int) int {
+		return op1 + op2
+	}
+	tests := []struct {
+		name string
+		strm *Stream
+	}{
+		{name: "no reduce", strm: New([]int{1}).Map(func(i int) int { return i }).Combine(add)},
+		{name: "bad combiner", strm: New([]int{1}).Reduce(0, add).Combine(42)},
+		{name: "emit interval", strm: New([]int{1}).Batch().EmitEvery(time.Second)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := <-test.strm.Into(collectors.Null()).Open(); err == nil {
+				t.Fatal("expecting error")
+			}
+		})
+	}
+}
+
+func TestStream_Scan(t *testing.T) {
+	add := func(op1, op2 int) int {
+		return op1 + op2
+	}
+	tests := []struct {
+		name     string
+		strm     func(*Stream) *Stream
+		expected string
+	}{
+		{name: "each item", strm: func(s *Stream) *Stream { return s.Scan(0, add) }, expected: "[1 3 6 10 15]"},
+		{name: "each two items", strm: func(s *Stream) *Stream { return s.Scan(0, add).EmitEach(2) }, expected: "[3 10 15]"},
+		{name: "parallel", strm: func(s *Stream) *Stream { return s.Reduce(0, add).Combine(add).Parallel(2).EmitEach(5) }, expected: "[15]"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			snk := collectors.Slice()
+			strm := test.strm(New([]int{1, 2, 3, 4, 5})).Into(snk)
+			if err := <-strm.Open(); err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(snk.Get()) != test.expected {
+				t.Fatalf("expecting %s, got %v", test.expected, snk.Get())
+			}
+		})
+	}
+}
+
+func TestStream_Scan_Unbounded(t *testing.T) {
+	src := make(chan interface{})
+	go func() {
+		for i := 1; ; i++ {
+			select {
+			case src <- i:
+			case <-time.After(time.Second):
+				return
+			}
+		}
+	}()
+
+	var totals []interface{}
+	for total, err := range New(src).Scan(0, func(sum, i int) int { return sum + i }).All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		totals = append(totals, total)
+		if len(totals) == 4 {
+			break
+		}
+	}
+	if fmt.Sprint(totals) != "[1 3 6 10]" {
+		t.Fatal("unexpected running totals ", totals)
+	}
+}
+
+func TestStream_ScanByKey(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New([]string{"apple", "berry", "avocado", "blue"}).ScanByKey(
+		func(word string) string { return word[:1] },
+		0,
+		func(count int, word string) int { return count + len(word) },
+	).Into(snk)
+
+	if err := <-strm.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(snk.Get()) != "[[a 5] [b 5] [a 12] [b 9]]" {
+		t.Fatal("unexpected running states ", snk.Get())
+	}
+}
+
+func TestStream_Scan_Typed(t *testing.T) {
+	type order struct {
+		customer string
+		amount   float64
+	}
+	orders := []order{{"ann", 10}, {"bob", 5}, {"ann", 2.5}}
+
+	snk := collectors.Slice()
+	totals := Scan(Of[order](orders), 0.0, func(total float64, o order) float64 {
+		return total + o.amount
+	}).Into(snk)
+	if err := <-totals.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(snk.Get()) != "[10 15 17.5]" {
+		t.Fatal("unexpected running totals ", snk.Get())
+	}
+
+	snk = collectors.Slice()
+	byCustomer := ScanByKey(Of[order](orders), func(o order) string { return o.customer }, 0.0,
+		func(total float64, o order) float64 { return total + o.amount }).Into(snk)
+	if err := <-byCustomer.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(snk.Get()) != "[[ann 10] [bob 5] [ann 12.5]]" {
+		t.Fatal("unexpected running totals ", snk.Get())
+	}
+}
@@ -0,0 +1,83 @@
+package stream
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofunky/automi/collectors"
+	"github.com/gofunky/automi/emitters"
+)
+
+func TestStream_Branch(t *testing.T) {
+	upper := collectors.Slice()
+	lengths := collectors.Slice()
+	strm := New(emitters.Slice([]string{"hello", "big", "world"}))
+	strm.Filter(func(s string) bool {
+		return s != "big"
+	})
+	branches := strm.Branch(2)
+	branches[0].Map(strings.ToUpper).Into(upper)
+	branches[1].Map(func(s string) int {
+		return len(s)
+	}).Into(lengths)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(upper.Get()) != 2 || upper.Get()[0] != "HELLO" {
+		t.Fatal("unexpected branch 0 result ", upper.Get())
+	}
+	if len(lengths.Get()) != 2 || lengths.Get()[1] != 5 {
+		t.Fatal("unexpected branch 1 result ", lengths.Get())
+	}
+}
+
+func TestStream_Branch_AllErrors(t *testing.T) {
+	err1 := errors.New("sink error 1")
+	err2 := errors.New("sink error 2")
+	strm := New([]string{"A", "B"})
+	branches := strm.Branch(2)
+	branches[0].Into(collectors.Func(func(interface{}) error { return err1 }))
+	branches[1].Into(collectors.Func(func(interface{}) error { return err2 }))
+
+	select {
+	case err := <-strm.Open():
+		if !errors.Is(err, err1) || !errors.Is(err, err2) {
+			t.Fatal("expecting errors from all branches, got ", err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
+
+func TestStream_Branch_Invalid(t *testing.T) {
+	strm := New([]string{"A", "B"})
+	branches := strm.Branch(2)
+	strm.Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error for branched stream with sink")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	select {
+	case err := <-branches[0].Open():
+		if err == nil {
+			t.Fatal("expecting error when opening a branch")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
@@ -0,0 +1,94 @@
+package stream
+
+import (
+	"errors"
+
+	"github.com/gofunky/automi/api"
+)
+
+// ErrorReport is the error sent by Open when a stream fails.  It lists,
+// in the order they were reported, the errors of the stream composition,
+// of its operators, and of its sinks.  Errors raised while processing an
+// item are reported as api.ProcError values carrying the failed item.
+//
+// An ErrorReport wraps its errors so that they can be
+// matched using errors.Is and errors.As.
+type ErrorReport struct {
+	Errors []error
+}
+
+// Error returns the messages of all reported errors, one per line
+func (r *ErrorReport) Error() string {
+	return errors.Join(r.Errors...).Error()
+}
+
+// Unwrap returns the reported errors
+func (r *ErrorReport) Unwrap() []error {
+	return r.Errors
+}
+
+// ProcErrors returns the reported errors that were raised
+// while processing an item.
+func (r *ErrorReport) ProcErrors() []api.ProcError {
+	var perrs []api.ProcError
+	for _, err := range r.Errors {
+		var perr api.ProcError
+		if errors.As(err, &perr) {
+			perrs = append(perrs, perr)
+		}
+	}
+	return perrs
+}
+
+func (r *ErrorReport) add(errs ...error) {
+	for _, err := range errs {
+		if err != nil {
+			r.Errors = append(r.Errors, err)
+		}
+	}
+}
+
+// err returns the report, or nil if no error was reported
+func (r *ErrorReport) err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	return r
+}
+
+// failingSource is implemented by sources that stop emitting on an
+// error, such as the CSV emitter with an error row policy, the error
+// is reported once the stream is done
+type failingSource interface {
+	Err() error
+}
+
+// errorHandler is implemented by operators that support an api.ErrorPolicy
+type errorHandler interface {
+	SetErrorPolicy(api.ErrorPolicy)
+}
+
+// OnError sets the policy used by the last added operator to handle items
+// whose processing failed.  Without a policy, the first error returned
+// fails the stream, while error values returned as results are logged
+// and dropped, a policy applies to both.  Policies apply to operators that run user-defined functions,
+// such as Process, Map, Filter, FlatMap, or Reduce.
+//
+//	strm.Map(parse).OnError(api.SkipOnError().WithRetry(3, time.Millisecond))
+//
+// See Also
+//
+//	"github.com/gofunky/automi/api"#ErrorPolicy
+func (s *Stream) OnError(policy api.ErrorPolicy) *Stream {
+	if len(s.ops) == 0 {
+		s.drainErr(errors.New("error policy requires an operator"))
+		return s
+	}
+	operator, ok := s.lastOp().(errorHandler)
+	if !ok {
+		s.drainErr(errors.New("last operator does not support an error policy"))
+		return s
+	}
+	operator.SetErrorPolicy(policy)
+	return s
+}
@@ -0,0 +1,114 @@
+package stream
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/collectors"
+)
+
+func TestStream_OnError_Skip(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New([]string{"1", "x", "3"}).
+		Map(strconv.Atoi).OnError(api.SkipOnError()).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(snk.Get()) != 2 || snk.Get()[1] != 3 {
+		t.Fatal("expecting failed item to be skipped, got ", snk.Get())
+	}
+}
+
+func TestStream_OnError_DeadLetter(t *testing.T) {
+	dlq := make(chan interface{}, 1)
+	snk := collectors.Slice()
+	strm := New([]string{"1", "x", "3"}).
+		Map(strconv.Atoi).OnError(api.DeadLetterOnError(dlq)).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if perr := (<-dlq).(api.ProcError); perr.Item != "x" {
+		t.Fatal("unexpected dead-lettered item ", perr)
+	}
+}
+
+func TestStream_OnError_FlatMap(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New([]string{"1", "x", "3"}).
+		FlatMap(func(s string) ([]int, error) {
+			i, err := strconv.Atoi(s)
+			return []int{i, i}, err
+		}).OnError(api.SkipOnError()).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(snk.Get()) != 4 || snk.Get()[3] != 3 {
+		t.Fatal("expecting failed item to be skipped, got ", snk.Get())
+	}
+}
+
+func TestStream_OnError_NoOperator(t *testing.T) {
+	strm := New([]int{1}).OnError(api.SkipOnError())
+
+	select {
+	case err := <-strm.Open():
+		var report *ErrorReport
+		if !errors.As(err, &report) || len(report.Errors) != 1 {
+			t.Fatal("expecting composition error, got ", err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
+
+func TestStream_ErrorReport(t *testing.T) {
+	sinkErr := errors.New("sink error")
+	branches := New([]string{"1", "x"}).Branch(2)
+	branches[0].Map(strconv.Atoi)
+	branches[1].Into(collectors.Func(func(interface{}) error {
+		return sinkErr
+	}))
+
+	select {
+	case err := <-branches[0].parent.Open():
+		var report *ErrorReport
+		if !errors.As(err, &report) || len(report.Errors) != 2 {
+			t.Fatal("expecting 2 errors, got ", err)
+		}
+		if perrs := report.ProcErrors(); len(perrs) != 1 || perrs[0].Item != "x" {
+			t.Fatal("unexpected processing errors ", perrs)
+		}
+		if !errors.Is(err, sinkErr) {
+			t.Fatal("expecting sink error in report, got ", err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
@@ -0,0 +1,63 @@
+package stream
+
+import (
+	"iter"
+
+	"github.com/gofunky/automi/collectors"
+	"github.com/gofunky/automi/util"
+)
+
+// All returns an iterator over the items of the stream, which replaces
+// the sink of the stream.  The stream is opened when the iteration starts
+// and can only be iterated once.  If the stream fails, the last iteration
+// yields its error, see Open.  Breaking out of the loop cancels the stream.
+//
+//	for item, err := range strm.All() {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(item)
+//	}
+func (s *Stream) All() iter.Seq2[interface{}, error] {
+	return func(yield func(interface{}, error) bool) {
+		items := make(chan interface{})
+		h := s.Into(collectors.Chan(items).CloseOnFinish()).Start()
+		// the stream is done once its sink sent all items,
+		// or if it failed before its sink was opened
+		for done := false; !done; {
+			select {
+			case item, ok := <-items:
+				if !ok {
+					items = nil
+					continue
+				}
+				if !yield(item, nil) {
+					h.Cancel()
+					h.Wait()
+					return
+				}
+			case <-h.Done():
+				done = true
+			}
+		}
+		if err := h.Wait(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// All returns an iterator over the items of the stream.
+// See Stream.All.
+func (t *TypedStream[T]) All() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for item, err := range t.stream.All() {
+			var val T
+			if err == nil {
+				val, err = util.Cast[T](item)
+			}
+			if !yield(val, err) || err != nil {
+				return
+			}
+		}
+	}
+}
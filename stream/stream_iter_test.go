@@ -0,0 +1,98 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gofunky/automi/emitters"
+)
+
+func TestStream_All(t *testing.T) {
+	strm := New([]int{1, 2, 3}).Map(func(i int) int { return i * 10 })
+	var items []interface{}
+	for item, err := range strm.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, item)
+	}
+	if len(items) != 3 || items[2] != 30 {
+		t.Fatal("unexpected items ", items)
+	}
+}
+
+func TestStream_All_Break(t *testing.T) {
+	stopped := make(chan struct{})
+	naturals := func(yield func(int) bool) {
+		defer close(stopped)
+		for i := 0; yield(i); i++ {
+		}
+	}
+
+	count := 0
+	for _, err := range New(emitters.Seq(naturals)).All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count++; count == 5 {
+			break
+		}
+	}
+	select {
+	case <-stopped:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("source not stopped after break")
+	}
+}
+
+func TestStream_All_Error(t *testing.T) {
+	var failure error
+	for _, err := range New(42).All() {
+		failure = err
+	}
+	if failure == nil {
+		t.Fatal("expecting invalid source error")
+	}
+
+	strm := New([]int{1, 2}).Process(func(i int) (int, error) {
+		if i == 2 {
+			return 0, errors.New("two")
+		}
+		return i, nil
+	})
+	var items []interface{}
+	for item, err := range strm.All() {
+		if err != nil {
+			failure = err
+			continue
+		}
+		items = append(items, item)
+	}
+	if len(items) != 1 || failure == nil || failure.Error() == "" {
+		t.Fatal("expecting item and error, got ", items, failure)
+	}
+}
+
+func TestTypedStream_All(t *testing.T) {
+	strm := Of[string](emitters.Seq(slices.Values([]string{"a", "bb"}))).WithContext(context.Background())
+	lengths := Map(strm, func(s string) int { return len(s) })
+	var total int
+	for n, err := range lengths.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += n
+	}
+	if total != 3 {
+		t.Fatal("unexpected total ", total)
+	}
+
+	for _, err := range Typed[string](New([]int{1})).All() {
+		if err == nil {
+			t.Fatal("expecting type error")
+		}
+	}
+}
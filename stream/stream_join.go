@@ -0,0 +1,65 @@
+package stream
+
+import (
+	"errors"
+
+	"github.com/gofunky/automi/operators/join"
+	"github.com/gofunky/automi/operators/unary"
+)
+
+// Join correlates the items of the stream (left) with the items of the
+// other stream (right).  Parameter other is either a *Stream, with its own
+// operators and no sink, or any source parameter supported by New.
+// The key of each item is extracted using the user-defined functions
+// leftKey and rightKey which must be of type:
+//
+//	func(T) K - where T is the type of the item and K a comparable key type
+//
+// Matched items are emitted downstream as tuple.Pair{left, right}.  The kind
+// selects an inner, left, or outer join and retention bounds how long
+// items are buffered while waiting for a match.
+//
+// By default, each item is matched with all the items of the other side
+// that have the same key, and the items are buffered until the end of the
+// stream, even once matched.  Unbounded sources require a retention window
+// or a maximum number of items, or a one-to-one join, whose items are only
+// buffered until their first match:
+//
+//	orders.Join(payments, join.Inner, orderID, paymentOrderID, join.OneToOne())
+//
+// See Also
+//
+//	"github.com/gofunky/automi/operators/join"#JoinOperator
+func (s *Stream) Join(other interface{}, kind join.Kind, leftKey, rightKey interface{}, retention join.Retention) *Stream {
+	leftOp, err := unary.ProcessFunc(leftKey)
+	if err != nil {
+		s.drainErr(err)
+		return s
+	}
+	rightOp, err := unary.ProcessFunc(rightKey)
+	if err != nil {
+		s.drainErr(err)
+		return s
+	}
+
+	right, ok := other.(*Stream)
+	if !ok {
+		right = New(other).WithContext(s.ctx)
+	}
+	if right.parent != nil {
+		s.drainErr(errors.New("joined stream already belongs to another stream"))
+		return s
+	}
+	if right.snkParam != nil {
+		s.drainErr(errors.New("joined stream cannot have a sink"))
+		return s
+	}
+
+	operator := join.New(s.nodeCtx("join"))
+	operator.SetKind(kind)
+	operator.SetKeys(leftOp, rightOp)
+	operator.SetRetention(retention)
+	right.Into(operator.RightCollector())
+	s.adopt(right)
+	return s.appendOp(operator)
+}
@@ -0,0 +1,171 @@
+package stream
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofunky/automi/api/tuple"
+	"github.com/gofunky/automi/collectors"
+	"github.com/gofunky/automi/operators/join"
+	"github.com/gofunky/automi/tracing"
+)
+
+type order struct {
+	ID       int
+	Customer string
+}
+
+type customer struct {
+	Name    string
+	Country string
+}
+
+func TestStream_Join(t *testing.T) {
+	orders := []order{{1, "ana"}, {2, "bob"}, {3, "ana"}, {4, "eve"}}
+	customers := New([]customer{{"ANA", "PT"}, {"BOB", "US"}}).Map(func(c customer) customer {
+		c.Name = strings.ToLower(c.Name)
+		return c
+	})
+
+	snk := collectors.Slice()
+	strm := New(orders).Join(customers, join.Left,
+		func(o order) string { return o.Customer },
+		func(c customer) string { return c.Name },
+		join.MaxItems(100),
+	).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	matched, unmatched := 0, 0
+	for _, item := range snk.Get() {
+		pair := item.(tuple.Pair)
+		if pair[1] == nil {
+			unmatched++
+			if pair[0].(order).Customer != "eve" {
+				t.Fatal("unexpected unmatched pair ", pair)
+			}
+			continue
+		}
+		matched++
+		if pair[0].(order).Customer != pair[1].(customer).Name {
+			t.Fatal("unexpected matched pair ", pair)
+		}
+	}
+	if matched != 3 || unmatched != 1 {
+		t.Fatal("unexpected join result ", snk.Get())
+	}
+}
+
+func TestStream_Join_Source(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New([]int{1, 2, 3}).Join([]string{"two", "three"}, join.Inner,
+		func(i int) int { return i },
+		func(s string) int { return len(s) },
+		join.Within(time.Second),
+	).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+	if len(snk.Get()) != 1 || snk.Get()[0].(tuple.Pair)[1] != "two" {
+		t.Fatal("unexpected join result ", snk.Get())
+	}
+}
+
+func TestStream_Join_InvalidKey(t *testing.T) {
+	strm := New([]int{1, 2, 3}).Join([]int{1}, join.Inner, "not a func", func(i int) int { return i }, join.Retention{})
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting invalid key function error")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
+
+func TestStream_Join_Invalid(t *testing.T) {
+	key := func(i int) int { return i }
+	parent := New([]int{1})
+	tests := []struct {
+		name  string
+		other *Stream
+		err   string
+	}{
+		{name: "sink", other: New([]int{1}).Into(collectors.Null()), err: "joined stream cannot have a sink"},
+		{name: "parent", other: parent.SideOutput(""), err: "joined stream already belongs to another stream"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			strm := New([]int{1}).Join(test.other, join.Inner, key, key, join.OneToOne()).Into(collectors.Null())
+			select {
+			case err := <-strm.Open():
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatal("unexpected error ", err)
+				}
+			case <-time.After(50 * time.Millisecond):
+				t.Fatal("Waited too long ...")
+			}
+		})
+	}
+}
+
+func TestStream_Join_Tracing(t *testing.T) {
+	spans := tracing.NewMemory()
+	snk := collectors.Slice()
+	strm := New([]int{1, 2, 3}).WithContext(tracing.WithTracer(context.Background(), tracing.New(spans))).
+		Join([]string{"one", "three"}, join.Left,
+			func(i int) int { return i },
+			func(s string) int { return len(s) },
+			join.Retention{},
+		).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+	for _, item := range snk.Get() {
+		if _, ok := item.(tuple.Pair); !ok {
+			t.Fatal("expecting unwrapped pairs, got ", item)
+		}
+	}
+
+	// each pair is traced as a result of a joined item
+	byID := make(map[string]tracing.SpanData)
+	for _, span := range spans.Spans() {
+		byID[span.SpanID] = span
+	}
+	var joined, collected int
+	for _, span := range byID {
+		switch span.Name {
+		case "join":
+			joined++
+		case "slice collector":
+			collected++
+			if byID[span.ParentID].Name != "join" {
+				t.Fatal("expecting the pair to be traced by the join operator, got ", byID[span.ParentID])
+			}
+		}
+	}
+	if joined != 5 || collected != 3 {
+		t.Fatalf("expecting 5 joined and 3 collected items, got %d and %d", joined, collected)
+	}
+}
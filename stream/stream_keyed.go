@@ -0,0 +1,95 @@
+package stream
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/operators/keyed"
+)
+
+// KeyBy sets the key of the items for the keyed operators that are
+// added after it, such as ProcessByKey.  The extractor is either an
+// api.UnOperation or a user-defined function of type:
+//
+//	func(T) K - where T is the type of the incoming item and K is comparable
+//
+// Keyed operators partition the items by key across shards, see Parallel,
+// so that the items of a key are processed by the same worker, in order.
+func (s *Stream) KeyBy(extractor interface{}) *Stream {
+	op, err := unaryOperation(extractor)
+	if err != nil {
+		s.drainErr(err)
+		return s
+	}
+	s.keys = op
+	return s
+}
+
+// ProcessByKey applies the user-defined function to each item along with
+// the state of its key, as set by KeyBy.  The state holds a value per key
+// that the function can get, put, or delete, for sessionization or
+// deduplication for instance.  The function must be of type:
+//
+//	func(api.State, T) R
+//	func(api.State, T) (R, error)
+//
+// A nil result, such as a nil pointer, is not sent downstream.  The items
+// are processed by a single shard, unless set otherwise with Parallel.
+//
+//	strm.KeyBy(func(e Event) string { return e.User }).
+//		ProcessByKey(func(seen api.State, e Event) *Event {
+//			if _, ok := seen.Get(); ok {
+//				return nil
+//			}
+//			seen.Put(true)
+//			return &e
+//		}).StateTTL(time.Hour).Parallel(8)
+//
+// See Also
+//
+//	"github.com/gofunky/automi/api"#State
+func (s *Stream) ProcessByKey(f interface{}) *Stream {
+	op, ok := f.(api.KeyedOperation)
+	if !ok {
+		fn, err := keyed.ProcessFunc(f)
+		if err != nil {
+			s.drainErr(err)
+			return s
+		}
+		op = fn
+	}
+	return s.processByKey(op)
+}
+
+func (s *Stream) processByKey(op api.KeyedOperation) *Stream {
+	if s.keys == nil {
+		s.drainErr(errors.New("keyed operator requires KeyBy"))
+		return s
+	}
+	operator := keyed.New(s.nodeCtx("processByKey"))
+	operator.SetKeyExtractor(s.keys)
+	operator.SetOperation(op)
+	return s.appendOp(operator)
+}
+
+// StateTTL sets the time to live of the key states of the last added
+// keyed operator.  A state expires once ttl elapsed since its value was
+// last put.  By default, states never expire.
+func (s *Stream) StateTTL(ttl time.Duration) *Stream {
+	if ttl <= 0 {
+		s.drainErr(errors.New("state time to live must be positive"))
+		return s
+	}
+	if len(s.ops) == 0 {
+		s.drainErr(errors.New("state time to live requires a keyed operator"))
+		return s
+	}
+	operator, ok := s.ops[len(s.ops)-1].(*keyed.KeyedOperator)
+	if !ok {
+		s.drainErr(errors.New("state time to live requires a keyed operator"))
+		return s
+	}
+	operator.SetStateTTL(ttl)
+	return s
+}
@@ -0,0 +1,137 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/collectors"
+	"github.com/gofunky/automi/tracing"
+)
+
+type click struct {
+	User string
+	Page string
+	At   int
+}
+
+func TestStream_ProcessByKey_Dedup(t *testing.T) {
+	clicks := []click{{"ann", "home", 1}, {"bob", "home", 2}, {"ann", "home", 3}, {"ann", "cart", 4}, {"bob", "home", 5}}
+	snk := collectors.Slice()
+	strm := New(clicks).
+		KeyBy(func(c click) string { return c.User + "/" + c.Page }).
+		ProcessByKey(func(seen api.State, c click) *click {
+			if _, ok := seen.Get(); ok {
+				return nil
+			}
+			seen.Put(true)
+			return &c
+		}).Parallel(3).
+		Map(func(c *click) int { return c.At }).
+		Into(snk)
+
+	if err := <-strm.Open(); err != nil {
+		t.Fatal(err)
+	}
+	var ats []int
+	for _, at := range snk.Get() {
+		ats = append(ats, at.(int))
+	}
+	sort.Ints(ats)
+	if fmt.Sprint(ats) != "[1 2 4]" {
+		t.Fatal("unexpected clicks ", ats)
+	}
+}
+
+func TestStream_ProcessByKey_Sessions(t *testing.T) {
+	type session struct {
+		User   string
+		Clicks int
+	}
+	clicks := make(chan click)
+	go func() {
+		defer close(clicks)
+		clicks <- click{"ann", "home", 1}
+		clicks <- click{"ann", "cart", 2}
+		time.Sleep(50 * time.Millisecond)
+		clicks <- click{"ann", "home", 3}
+	}()
+
+	snk := collectors.Slice()
+	strm := KeyBy(Of[click](clicks), func(c click) string { return c.User })
+	sessions := ProcessByKey(strm, func(state api.State, c click) (session, error) {
+		s, _ := state.Get()
+		current, _ := s.(session)
+		current.User = c.User
+		current.Clicks++
+		state.Put(current)
+		return current, nil
+	}).StateTTL(20 * time.Millisecond).Into(snk)
+
+	if err := <-sessions.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(snk.Get()) != "[{ann 1} {ann 2} {ann 1}]" {
+		t.Fatal("expecting a new session after the idle gap, got ", snk.Get())
+	}
+}
+
+func TestStream_ProcessByKey_Invalid(t *testing.T) {
+	process := func(state api.State, i int) int { return i }
+	tests := []struct {
+		name string
+		strm *Stream
+	}{
+		{name: "no key", strm: New([]int{1}).ProcessByKey(process)},
+		{name: "bad key", strm: New([]int{1}).KeyBy(42).ProcessByKey(process)},
+		{name: "bad func", strm: New([]int{1}).KeyBy(func(i int) int { return i }).ProcessByKey(func(i int) int { return i })},
+		{name: "ttl", strm: New([]int{1}).Map(func(i int) int { return i }).StateTTL(time.Second)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := <-test.strm.Into(collectors.Null()).Open(); err == nil {
+				t.Fatal("expecting error")
+			}
+		})
+	}
+}
+
+func TestStream_ProcessByKey_Tracing(t *testing.T) {
+	spans := tracing.NewMemory()
+	snk := collectors.Slice()
+	strm := New([]string{"a", "b", "a"}).WithContext(tracing.WithTracer(context.Background(), tracing.New(spans))).
+		KeyBy(func(s string) string { return s }).
+		ProcessByKey(func(count api.State, s string) int {
+			n, _ := count.Get()
+			if n == nil {
+				n = 0
+			}
+			count.Put(n.(int) + 1)
+			return n.(int) + 1
+		}).Parallel(2).
+		Into(snk)
+
+	if err := <-strm.Open(); err != nil {
+		t.Fatal(err)
+	}
+	var counts []int
+	for _, count := range snk.Get() {
+		counts = append(counts, count.(int))
+	}
+	sort.Ints(counts)
+	if fmt.Sprint(counts) != "[1 1 2]" {
+		t.Fatal("unexpected counts ", counts)
+	}
+	var processed int
+	for _, span := range spans.Spans() {
+		if span.Name == "processByKey" {
+			processed++
+		}
+	}
+	if processed != 3 {
+		t.Fatal("expecting 3 traced items, got ", processed)
+	}
+}
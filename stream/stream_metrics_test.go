@@ -0,0 +1,52 @@
+package stream
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofunky/automi/collectors"
+	"github.com/gofunky/automi/metrics"
+)
+
+func TestStream_Metrics(t *testing.T) {
+	m := metrics.NewMemory()
+	snk := collectors.Slice()
+	strm := New([]int{1, 2, 3, 4}).WithContext(metrics.WithMetrics(context.Background(), m))
+	strm.Filter(func(i int) bool { return i%2 == 0 })
+	strm.Map(func(i int) int { return i * 10 }).Named("tenfold")
+	strm.Batch().Into(snk)
+
+	if err := <-strm.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats := m.Get("filter"); stats.In != 4 || stats.Out != 2 || stats.Latency.Count != 4 {
+		t.Fatal("unexpected filter stats ", stats)
+	}
+	if stats := m.Get("tenfold"); stats.In != 2 || stats.Out != 2 || stats.Capacity != 1024 {
+		t.Fatal("unexpected map stats ", stats)
+	}
+	if stats := m.Get("batch"); stats.In != 2 || stats.Out != 1 {
+		t.Fatal("unexpected batch stats ", stats)
+	}
+}
+
+func TestStream_Metrics_DefaultNames(t *testing.T) {
+	m := metrics.NewMemory()
+	strm := New([]int{1, 2, 3, 4}).WithContext(metrics.WithMetrics(context.Background(), m)).
+		Map(func(i int) int { return i * 10 }).
+		Filter(func(i int) bool { return i > 20 }).
+		Map(func(i int) int { return i + 1 }).
+		Into(collectors.Null())
+
+	if err := <-strm.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats := m.Get("map"); stats.In != 4 || stats.Out != 4 {
+		t.Fatal("unexpected stats of the first map ", stats)
+	}
+	if stats := m.Get("map#2"); stats.In != 2 || stats.Out != 2 {
+		t.Fatal("unexpected stats of the second map ", stats)
+	}
+}
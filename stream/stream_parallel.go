@@ -0,0 +1,83 @@
+package stream
+
+import (
+	"errors"
+
+	"github.com/gofunky/automi/api"
+)
+
+// concurrentOperator is implemented by operators
+// that can process items concurrently
+type concurrentOperator interface {
+	SetConcurrency(int)
+}
+
+// orderedOperator is implemented by concurrent operators
+// that can send their results in the order of their items
+type orderedOperator interface {
+	SetOrdered(ordered bool, size int)
+}
+
+// Parallel runs the last added operator with n concurrent workers.  The
+// results are sent in the order of their items, those that are ready
+// before the results of earlier items are held in a reorder buffer of
+// 16 items per worker, see ParallelOrdered.  Parallel applies to
+// operators that run user-defined functions on each item, such as
+// Process, Map, Filter, or FlatMap, and to Reduce, see Combine.
+//
+//	strm.Map(hash).Parallel(runtime.NumCPU()).Batch()
+func (s *Stream) Parallel(n int) *Stream {
+	return s.parallel(n, true, 0)
+}
+
+// ParallelOrdered runs the last added operator with n concurrent workers,
+// which keep the order of the items.  At most size items are processed
+// ahead of the earliest item still in process, the reorder buffer holding
+// their results until it is done.  If size is not positive, the buffer
+// holds 16 items per worker.
+func (s *Stream) ParallelOrdered(n, size int) *Stream {
+	return s.parallel(n, true, size)
+}
+
+// ParallelUnordered runs the last added operator with n concurrent
+// workers, which send their results as soon as they are ready, in no
+// particular order.
+func (s *Stream) ParallelUnordered(n int) *Stream {
+	return s.parallel(n, false, 0)
+}
+
+func (s *Stream) parallel(n int, ordered bool, size int) *Stream {
+	if n < 1 {
+		s.drainErr(errors.New("parallel requires at least one worker"))
+		return s
+	}
+	if len(s.ops) == 0 {
+		s.drainErr(errors.New("parallel requires an operator"))
+		return s
+	}
+	operator, ok := s.lastOp().(concurrentOperator)
+	if !ok {
+		s.drainErr(errors.New("last operator does not support parallel execution"))
+		return s
+	}
+	operator.SetConcurrency(n)
+	if operator, ok := operator.(orderedOperator); ok {
+		operator.SetOrdered(ordered, size)
+	}
+	return s
+}
+
+// lastOp returns the last added operator, or the operator of the
+// operation rather than the operator that follows it, see node
+func (s *Stream) lastOp() api.Operator {
+	last := s.ops[len(s.ops)-1]
+	if len(s.ops) > 1 && s.following() {
+		last = s.ops[len(s.ops)-2]
+	}
+	return last
+}
+
+// following reports whether the last added node follows the node before it
+func (s *Stream) following() bool {
+	return len(s.nodes) > 0 && s.nodes[len(s.nodes)-1].follows
+}
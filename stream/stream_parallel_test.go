@@ -0,0 +1,111 @@
+package stream
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofunky/automi/collectors"
+)
+
+func TestStream_Parallel(t *testing.T) {
+	items := make([]int, 200)
+	for i := range items {
+		items[i] = i
+	}
+	snk := collectors.Slice()
+	strm := New(items).Map(func(i int) int {
+		time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+		return i * 2
+	}).Parallel(8).Batch().Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(snk.Get()) != 1 {
+		t.Fatal("expecting a single batch, got ", len(snk.Get()))
+	}
+	batch := snk.Get()[0].([]int)
+	if len(batch) != len(items) {
+		t.Fatalf("expecting %d items, got %d", len(items), len(batch))
+	}
+	for i, item := range batch {
+		if item != i*2 {
+			t.Fatalf("expecting %d at %d, got %v", i*2, i, item)
+		}
+	}
+}
+
+func TestStream_Parallel_FlatMap(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New([]string{"a b", "c", "d e f"}).FlatMap(func(line string) []string {
+		time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+		return strings.Fields(line)
+	}).Parallel(3).Into(snk)
+
+	if err := <-strm.Open(); err != nil {
+		t.Fatal(err)
+	}
+	var words []string
+	for _, item := range snk.Get() {
+		words = append(words, item.(string))
+	}
+	if strings.Join(words, "") != "abcdef" {
+		t.Fatal("unexpected words ", words)
+	}
+}
+
+func TestStream_ParallelUnordered(t *testing.T) {
+	items := make([]int, 100)
+	for i := range items {
+		items[i] = i
+	}
+	snk := collectors.Slice()
+	strm := Of[int](items).Filter(func(i int) bool {
+		return i%2 == 0
+	}).ParallelUnordered(4).Into(snk)
+
+	if err := <-strm.Open(); err != nil {
+		t.Fatal(err)
+	}
+	var result []int
+	for _, item := range snk.Get() {
+		result = append(result, item.(int))
+	}
+	sort.Ints(result)
+	if len(result) != 50 {
+		t.Fatal("expecting 50 items, got ", len(result))
+	}
+	for i, item := range result {
+		if item != i*2 {
+			t.Fatalf("expecting %d, got %d", i*2, item)
+		}
+	}
+}
+
+func TestStream_Parallel_Unsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		strm *Stream
+	}{
+		{name: "no operator", strm: New([]int{1}).Parallel(2)},
+		{name: "no worker", strm: New([]int{1}).Map(func(i int) int { return i }).Parallel(0)},
+		{name: "batch", strm: New([]int{1}).Batch().Parallel(2)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := <-test.strm.Into(collectors.Null()).Open()
+			if err == nil {
+				t.Fatal("expecting error")
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gofunky/automi/api"
+	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/api/tuple"
+	"github.com/gofunky/automi/emitters"
+	"github.com/gofunky/automi/util"
+)
+
+// node is an operator node of the stream along with the auxiliary
+// channel it sends side items on, whether it was named with Named,
+// the store its state is bound to, if any, and whether it follows the
+// node before it as part of the same operation, such as the node that
+// unpacks the results of FlatMap or that casts the typed batches
+type node struct {
+	autoctx.Node
+	aux     chan interface{}
+	named   bool
+	store   api.StateStore
+	follows bool
+}
+
+// sideOutput routes the side items of the nodes named name to out
+type sideOutput struct {
+	name string
+	out  chan interface{}
+}
+
+// nodeCtx registers a new operator node named name and returns
+// the context the operator is created with.  The context carries
+// the node and its auxiliary channel.
+func (s *Stream) nodeCtx(name string) context.Context {
+	n := &node{Node: autoctx.Node{Name: name}, aux: make(chan interface{})}
+	s.nodes = append(s.nodes, n)
+	return autoctx.WithNode(autoctx.WithAuxChan(s.ctx, n.aux), &n.Node)
+}
+
+// Named names the last added operator.  Operators are named after the
+// method that added them by default (i.e. map, filter, reduce, window),
+// the operators added by the same method being numbered from the second
+// one when the stream is opened (i.e. map, map#2, map#3).  The name is
+// used to select side items with SideOutput and to label the metrics
+// and traces of the operator.
+func (s *Stream) Named(name string) *Stream {
+	if len(s.nodes) == 0 {
+		s.drainErr(errors.New("name requires an operator"))
+		return s
+	}
+	n := s.lastNode()
+	if n.store != nil && s.boundTo(n.store, name, n) {
+		s.drainErr(fmt.Errorf("name %q already bound to the state store", name))
+		return s
+	}
+	n.Name = name
+	n.named = true
+	return s
+}
+
+// nameNodes makes the default names of the nodes of the stream and of its
+// child streams unique, the n-th node of a name being suffixed with #n
+func (s *Stream) nameNodes() {
+	counts := make(map[string]int)
+	s.walk(func(s *Stream) {
+		for _, n := range s.nodes {
+			counts[n.Name]++
+			if count := counts[n.Name]; count > 1 && !n.named {
+				n.Name = fmt.Sprintf("%s#%d", n.Name, count)
+			}
+		}
+	})
+}
+
+// lastNode returns the node of the last added operator, or the node of
+// the operation rather than the node that follows it, see node
+func (s *Stream) lastNode() *node {
+	last := len(s.nodes) - 1
+	if s.following() && last > 0 {
+		last--
+	}
+	return s.nodes[last]
+}
+
+// SideOutput returns a stream of the side items sent by the operators
+// named name, or by all operators of the stream if name is empty.
+// Side items are items that are not part of the main flow, such as items
+// dead-lettered by an error policy or late items of event-time windows and batches.
+// Each side item is streamed as tuple.Tagged{name, item}, where name is the
+// name of the operator that sent it.
+//
+// The returned stream can have its own operators and sink, it is opened
+// along with the stream and is done once the operators of the stream are
+// done.  Side items that are not routed to a side output are sent to the
+// auxiliary channel of the stream context, if any, or dropped.
+func (s *Stream) SideOutput(name string) *Stream {
+	side := &sideOutput{name: name, out: make(chan interface{})}
+	s.sides = append(s.sides, side)
+	child := s.adopt(New(emitters.Chan(side.out)).WithContext(s.ctx))
+	child.side = true
+	child.inner = true
+	return child
+}
+
+// routeSide forwards the side items sent by the nodes of the stream to
+// its side outputs until stop is closed.  Side outputs are closed after
+// the last item is forwarded, routers is done once they are closed.
+func (s *Stream) routeSide(stop <-chan struct{}, routers *sync.WaitGroup) {
+	tagged := make(chan tuple.Tagged)
+	var forwarders sync.WaitGroup
+	forwarders.Add(len(s.nodes))
+	for _, n := range s.nodes {
+		go func(n *node) {
+			defer forwarders.Done()
+			for {
+				select {
+				case item := <-n.aux:
+					tagged <- tuple.Tagged{n.Name, item}
+				case <-stop:
+					return
+				}
+			}
+		}(n)
+	}
+	go func() {
+		forwarders.Wait()
+		close(tagged)
+	}()
+
+	userAux, hasUserAux := autoctx.GetAuxChan(s.ctx)
+	routers.Add(1)
+	go func() {
+		defer func() {
+			for _, side := range s.sides {
+				close(side.out)
+			}
+			routers.Done()
+		}()
+		for item := range tagged {
+			routed := false
+			for _, side := range s.sides {
+				if side.name == "" || side.name == item[0] {
+					side.out <- item
+					routed = true
+				}
+			}
+			switch {
+			case routed:
+			case hasUserAux:
+				select {
+				case userAux <- item[1]:
+				case <-s.ctx.Done():
+				}
+			default:
+				util.Logf(s.log, "dropping side item %v of %v", item[1], item[0])
+			}
+		}
+	}()
+}
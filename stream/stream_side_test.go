@@ -0,0 +1,203 @@
+package stream
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/api/tuple"
+	"github.com/gofunky/automi/collectors"
+	"github.com/gofunky/automi/operators/window"
+)
+
+func TestStream_SideOutput_DeadLetter(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New([]string{"1", "x", "3", "y"}).
+		Map(strconv.Atoi).OnError(api.DeadLetterOnError(nil)).Named("parse").
+		Into(snk)
+	rejected := collectors.Slice()
+	strm.SideOutput("parse").Into(rejected)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(snk.Get()) != 2 {
+		t.Fatal("expecting 2 parsed items, got ", snk.Get())
+	}
+	if len(rejected.Get()) != 2 {
+		t.Fatal("expecting 2 rejected items, got ", rejected.Get())
+	}
+	item := rejected.Get()[0].(tuple.Tagged)
+	if item[0] != "parse" || item[1].(api.ProcError).Item != "x" {
+		t.Fatal("unexpected side item ", item)
+	}
+}
+
+func TestStream_SideOutput_FlatMap(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New([]string{"1 2", "x", "3"}).
+		FlatMap(func(s string) ([]int, error) {
+			var ints []int
+			for _, f := range strings.Fields(s) {
+				i, err := strconv.Atoi(f)
+				if err != nil {
+					return nil, err
+				}
+				ints = append(ints, i)
+			}
+			return ints, nil
+		}).OnError(api.DeadLetterOnError(nil)).Named("split").
+		Into(snk)
+	rejected := collectors.Slice()
+	strm.SideOutput("split").Into(rejected)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(snk.Get()) != 3 {
+		t.Fatal("expecting 3 items, got ", snk.Get())
+	}
+	if len(rejected.Get()) != 1 {
+		t.Fatal("expecting the flatMap operator to be named, got ", rejected.Get())
+	}
+	if item := rejected.Get()[0].(tuple.Tagged); item[0] != "split" {
+		t.Fatal("unexpected side item ", item)
+	}
+}
+
+func TestStream_SideOutput_FailingOperator(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New([]string{"1", "x", "3", "y"}).
+		Map(strconv.Atoi).OnError(api.DeadLetterOnError(nil)).Named("parse").
+		Into(snk)
+	retried := collectors.Slice()
+	side := strm.SideOutput("parse").
+		Map(func(item tuple.Tagged) (int, error) {
+			return strconv.Atoi(item[1].(api.ProcError).Item.(string))
+		}).OnError(api.DeadLetterOnError(nil)).Named("retry").
+		Into(retried)
+	rejected := collectors.Slice()
+	side.SideOutput("retry").Into(rejected)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(retried.Get()) != 0 {
+		t.Fatal("expecting no retried items, got ", retried.Get())
+	}
+	if len(rejected.Get()) != 2 {
+		t.Fatal("expecting 2 rejected items, got ", rejected.Get())
+	}
+	if item := rejected.Get()[0].(tuple.Tagged); item[0] != "retry" {
+		t.Fatal("unexpected side item ", item)
+	}
+}
+
+func TestStream_SideOutput_All(t *testing.T) {
+	base := time.Unix(0, 0)
+	side := collectors.Slice()
+	strm := New([]string{"1", "x", "20", "5"}).
+		Map(strconv.Atoi).OnError(api.DeadLetterOnError(nil)).
+		WithTimestamps(func(sec int) time.Time {
+			return base.Add(time.Duration(sec) * time.Second)
+		}, 0).
+		TumblingWindow(10 * time.Second)
+	// side items are further processed by the side output stream
+	strm.SideOutput("").Map(func(item tuple.Tagged) string {
+		return item[0].(string)
+	}).Batch().Sort().Into(side)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	names := side.Get()[0].([]string)
+	if strings.Join(names, ",") != "map,window" {
+		t.Fatal("expecting side items of map and window, got ", names)
+	}
+}
+
+func TestStream_SideOutput_Unrouted(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New([]int{5, 20, 1}).
+		WithTimestamps(func(sec int) time.Time {
+			return time.Unix(int64(sec), 0)
+		}, 0).
+		TumblingWindow(10 * time.Second).
+		Into(snk)
+	late := collectors.Slice()
+	strm.SideOutput("other").Into(late)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(snk.Get()) != 2 || len(late.Get()) != 0 {
+		t.Fatal("expecting late item to be dropped, got ", snk.Get(), late.Get())
+	}
+}
+
+func TestStream_Named_NoOperator(t *testing.T) {
+	strm := New([]int{1}).Named("none")
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error for missing operator")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
+
+func TestStream_SideOutput_LateItem(t *testing.T) {
+	side := collectors.Slice()
+	strm := New([]int{5, 20, 1}).
+		WithTimestamps(func(sec int) time.Time {
+			return time.Unix(int64(sec), 0)
+		}, 0).
+		TumblingWindow(10 * time.Second)
+	strm.SideOutput("window").Into(side)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(side.Get()) != 1 || side.Get()[0].(tuple.Tagged)[1].(window.LateItem).Item != 1 {
+		t.Fatal("expecting late item, got ", side.Get())
+	}
+}
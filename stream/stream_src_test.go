@@ -1,10 +1,15 @@
 package stream
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/api/tuple"
 	"github.com/gofunky/automi/collectors"
 	"github.com/gofunky/automi/emitters"
 )
@@ -98,3 +103,130 @@ func TestStream_ReaderSource(t *testing.T) {
 		t.Fatal("Took too long")
 	}
 }
+
+func TestStream_JSONFiles(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in.ndjson"), filepath.Join(dir, "out.json")
+	if err := os.WriteFile(in, []byte("{\"qty\": 1}\n{\"qty\": 2}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	strm := New(in).Map(func(item map[string]interface{}) float64 {
+		return item["qty"].(float64) * 10
+	}).Into(out)
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "[\n10,\n20\n]\n" {
+		t.Fatalf("unexpected output %q", data)
+	}
+}
+
+func TestStream_CSVSource_Structs(t *testing.T) {
+	type sale struct {
+		Region string  `csv:"region"`
+		Amount float64 `csv:"amount"`
+	}
+	data := "region,amount\neast,10\nwest,5\neast,7.5\n"
+	src := emitters.CSV(strings.NewReader(data)).HasHeaders().As(sale{})
+	snk := collectors.Slice()
+	strm := New(src).Batch().SumByName("Amount").Into(snk)
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+	result := snk.Get()[0].([]map[string]float64)
+	if result[0]["Amount"] != 22.5 {
+		t.Fatal("unexpected sum ", result)
+	}
+}
+
+func TestStream_CSVSource_ErrorRow(t *testing.T) {
+	data := "region,amount\neast,10\nwest,five\neast,7.5\n"
+	src := emitters.CSV(strings.NewReader(data)).HasHeaders().
+		As(struct{ Region, Amount string }{}).AsMaps().OnErrorRow(api.FailOnError())
+	if err := <-New(src).Open(); err == nil {
+		t.Fatal("expecting error for conflicting modes")
+	}
+
+	src = emitters.CSV(strings.NewReader(data)).HasHeaders().
+		As(struct{ Amount int }{}).OnErrorRow(api.FailOnError())
+	snk := collectors.Slice()
+	err := <-New(src).Into(snk).Open()
+	var perr api.ProcError
+	if !errors.As(err, &perr) || perr.ProcName != "csv emitter" {
+		t.Fatal("expecting error row to fail the stream, got ", err)
+	}
+	if len(snk.Get()) != 1 {
+		t.Fatal("expecting rows before the error row, got ", snk.Get())
+	}
+}
+
+func TestStream_MergeSource(t *testing.T) {
+	ch := make(chan string)
+	go func() {
+		ch <- "D"
+		ch <- "E"
+		close(ch)
+	}()
+
+	snk := collectors.Slice()
+	strm := Merge([]string{"A", "B"}, ch).Union(emitters.Slice([]string{"C"})).Into(snk)
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(snk.Get()) != 5 {
+			t.Fatal("expecting 5 items, got", len(snk.Get()))
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestStream_MergeSource_TagOrigin(t *testing.T) {
+	snk := collectors.Slice()
+	strm := Merge([]string{"A", "B"}, []int{1}).TagOrigin().Into(snk)
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+		origins := make(map[interface{}]int)
+		for _, item := range snk.Get() {
+			origins[item.(tuple.Tagged)[0]]++
+		}
+		if origins[0] != 2 || origins[1] != 1 {
+			t.Fatal("unexpected origins", origins)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestStream_MergeSource_Invalid(t *testing.T) {
+	strm := Merge([]string{"A"}, 12)
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting invalid source error")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
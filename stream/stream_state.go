@@ -0,0 +1,94 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/gofunky/automi/api"
+)
+
+// statefulOperator is implemented by operators
+// whose state can be kept in an api.StateStore
+type statefulOperator interface {
+	SetStateStore(api.StateStore, api.Codec)
+}
+
+// StateStore keeps the state of the last added operator in store, encoded
+// with codec, so that the operator resumes where it stopped when the
+// stream is restarted.  It applies to Reduce and Scan, whose state is
+// saved each time it is emitted and when the stream is cancelled, to the
+// batch operators, whose pending batch is saved when the stream is
+// cancelled, and to the keyed operators, whose key states are kept in the
+// store, keys must then be strings, numbers or booleans.  States are
+// stored under the name of their operator, which must be set with Named
+// beforehand, and be distinct among the operators sharing the store.
+//
+//	store, err := state.OpenFile("sessions.state")
+//	...
+//	strm.KeyBy(user).ProcessByKey(sessionize).Named("sessions").StateStore(store, state.Gob())
+//
+// See Also
+//
+//	"github.com/gofunky/automi/state"
+func (s *Stream) StateStore(store api.StateStore, codec api.Codec) *Stream {
+	if store == nil || codec == nil {
+		s.drainErr(errors.New("state store requires a store and a codec"))
+		return s
+	}
+	if len(s.ops) == 0 {
+		s.drainErr(errors.New("state store requires an operator"))
+		return s
+	}
+	operator, ok := s.lastOp().(statefulOperator)
+	if !ok {
+		s.drainErr(errors.New("last operator does not support a state store"))
+		return s
+	}
+	// default names are shared by the operators added by the same method
+	n := s.lastNode()
+	if !n.named {
+		s.drainErr(errors.New("state store requires an operator named with Named"))
+		return s
+	}
+	if s.boundTo(store, n.Name, n) {
+		s.drainErr(fmt.Errorf("name %q already bound to the state store", n.Name))
+		return s
+	}
+	operator.SetStateStore(store, codec)
+	n.store = store
+	return s
+}
+
+// boundTo reports whether a node of the stream, or of the streams it is
+// related to, other than except, is named name and bound to store
+func (s *Stream) boundTo(store api.StateStore, name string, except *node) bool {
+	root := s
+	for root.parent != nil {
+		root = root.parent
+	}
+	return root.findBound(store, name, except)
+}
+
+func (s *Stream) findBound(store api.StateStore, name string, except *node) bool {
+	for _, n := range s.nodes {
+		if n != except && n.Name == name && sameStore(n.store, store) {
+			return true
+		}
+	}
+	for _, child := range s.children {
+		if child.findBound(store, name, except) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameStore reports whether a and b are the same store,
+// stores of types that are not comparable are distinct
+func sameStore(a, b api.StateStore) bool {
+	if a == nil || b == nil || reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+	return a == b
+}
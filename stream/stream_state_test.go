@@ -0,0 +1,117 @@
+package stream
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/collectors"
+	"github.com/gofunky/automi/state"
+)
+
+func TestStream_StateStore_Reduce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "total.state")
+	run := func(items []int) []interface{} {
+		store, err := state.OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer store.Close()
+		snk := collectors.Slice()
+		strm := New(items).
+			Reduce(0, func(total, i int) int { return total + i }).Named("total").
+			StateStore(store, state.Gob()).
+			Into(snk)
+		if err := <-strm.Open(); err != nil {
+			t.Fatal(err)
+		}
+		return snk.Get()
+	}
+
+	if totals := run([]int{1, 2, 3}); fmt.Sprint(totals) != "[6]" {
+		t.Fatal("unexpected totals ", totals)
+	}
+	if totals := run([]int{4}); fmt.Sprint(totals) != "[10]" {
+		t.Fatal("expecting the total to resume, got ", totals)
+	}
+}
+
+func TestStream_StateStore_ProcessByKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clicks.state")
+	run := func(clicks []click) []string {
+		store, err := state.OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer store.Close()
+		snk := collectors.Slice()
+		strm := New(clicks).
+			KeyBy(func(c click) string { return c.User }).
+			ProcessByKey(func(count api.State, c click) string {
+				n, _ := count.Get()
+				if n == nil {
+					n = 0
+				}
+				count.Put(n.(int) + 1)
+				return fmt.Sprintf("%s:%d", c.User, n.(int)+1)
+			}).Named("clicks").Parallel(2).
+			StateStore(store, state.Gob()).
+			Into(snk)
+		if err := <-strm.Open(); err != nil {
+			t.Fatal(err)
+		}
+		var counts []string
+		for _, count := range snk.Get() {
+			counts = append(counts, count.(string))
+		}
+		sort.Strings(counts)
+		return counts
+	}
+
+	run([]click{{"ann", "home", 1}, {"bob", "home", 2}, {"ann", "cart", 3}})
+	if counts := run([]click{{"ann", "home", 4}, {"bob", "cart", 5}}); fmt.Sprint(counts) != "[ann:3 bob:2]" {
+		t.Fatal("expecting the key states to resume, got ", counts)
+	}
+}
+
+func TestStream_StateStore_Invalid(t *testing.T) {
+	store := state.NewMemory()
+	sum := func(total, i int) int { return total + i }
+	tests := []struct {
+		name string
+		strm *Stream
+	}{
+		{name: "no operator", strm: New([]int{1}).StateStore(store, state.Gob())},
+		{name: "no store", strm: New([]int{1}).Reduce(0, sum).Named("total").StateStore(nil, state.Gob())},
+		{name: "no codec", strm: New([]int{1}).Reduce(0, sum).Named("total").StateStore(store, nil)},
+		{name: "stateless", strm: New([]int{1}).Map(func(i int) int { return i }).Named("id").StateStore(store, state.Gob())},
+		{name: "unnamed", strm: New([]int{1}).Reduce(0, sum).StateStore(store, state.Gob())},
+		{name: "same name", strm: New([]int{1}).
+			Reduce(0, sum).Named("total").StateStore(store, state.Gob()).
+			Reduce(0, sum).Named("total").StateStore(store, state.Gob())},
+		{name: "renamed", strm: New([]int{1}).
+			Reduce(0, sum).Named("total").StateStore(store, state.Gob()).
+			Reduce(0, sum).Named("count").StateStore(store, state.Gob()).Named("total")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := <-test.strm.Into(collectors.Null()).Open(); err == nil {
+				t.Fatal("expecting error")
+			}
+		})
+	}
+}
+
+func TestStream_StateStore_Names(t *testing.T) {
+	store := state.NewMemory()
+	sum := func(total, i int) int { return total + i }
+	strm := New([]int{1, 2}).
+		Reduce(0, sum).Named("total").StateStore(store, state.Gob()).
+		Reduce(0, sum).Named("total").StateStore(state.NewMemory(), state.Gob()).
+		Into(collectors.Null())
+	if err := <-strm.Open(); err != nil {
+		t.Fatal("expecting the same name to be allowed with distinct stores, got ", err)
+	}
+}
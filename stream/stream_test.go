@@ -119,3 +119,42 @@ func TestStream_Open_WithOp(t *testing.T) {
 	}
 	m.RUnlock()
 }
+
+func TestStream_Into_SlicePtr(t *testing.T) {
+	var lengths []int64
+	strm := New([]string{"hello", "world", "!"}).
+		Map(func(s string) int { return len(s) }).
+		Into(&lengths)
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+	if len(lengths) != 3 || lengths[0] != 5 || lengths[2] != 1 {
+		t.Fatal("unexpected items ", lengths)
+	}
+}
+
+func TestStream_Into_Chan(t *testing.T) {
+	out := make(chan string)
+	strm := New([]string{"hello", "world"}).Into(out)
+	result := strm.Open()
+
+	var items []string
+	for item := range out {
+		items = append(items, item)
+	}
+	if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[1] != "world" {
+		t.Fatal("unexpected items ", items)
+	}
+
+	if err := <-New([]int{1}).Into(make(<-chan int)).Open(); err == nil {
+		t.Fatal("expecting error for receive-only channel")
+	}
+}
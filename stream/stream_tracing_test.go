@@ -0,0 +1,55 @@
+package stream
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/collectors"
+	"github.com/gofunky/automi/tracing"
+)
+
+func TestStream_Tracing(t *testing.T) {
+	spans := tracing.NewMemory()
+	snk := collectors.Slice()
+	strm := New([][]int{{1, 2}, {3}}).WithContext(tracing.WithTracer(context.Background(), tracing.New(spans)))
+	strm.ReStream()
+	strm.Filter(func(i int) bool { return i != 2 })
+	strm.Transform(api.UnFunc(func(ctx context.Context, item interface{}) (interface{}, error) {
+		if tracing.SpanFromContext(ctx) == nil {
+			t.Error("expecting span in operation context")
+		}
+		return item.(int) * 10, nil
+	})).Named("tenfold")
+	strm.Into(snk)
+
+	if err := <-strm.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if len(snk.Get()) != 2 || snk.Get()[0] != 10 || snk.Get()[1] != 30 {
+		t.Fatal("expecting unwrapped items, got ", snk.Get())
+	}
+
+	// each item is traced from the emitter to the collector
+	byID := make(map[string]tracing.SpanData)
+	for _, span := range spans.Spans() {
+		byID[span.SpanID] = span
+	}
+	var traces int
+	for _, span := range byID {
+		if span.Name != "slice collector" {
+			continue
+		}
+		traces++
+		var path []string
+		for s, ok := span, true; ok; s, ok = byID[s.ParentID] {
+			path = append([]string{s.Name}, path...)
+		}
+		if len(path) != 4 || path[0] != "slice emitter" || path[1] != "filter" || path[2] != "tenfold" {
+			t.Fatal("unexpected trace ", path)
+		}
+	}
+	if traces != 2 {
+		t.Fatal("expecting 2 traced items, got ", traces)
+	}
+}
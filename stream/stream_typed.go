@@ -0,0 +1,231 @@
+package stream
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/api/tuple"
+	"github.com/gofunky/automi/operators/batch"
+	"github.com/gofunky/automi/operators/binary"
+	"github.com/gofunky/automi/operators/keyed"
+	"github.com/gofunky/automi/operators/unary"
+	"github.com/gofunky/automi/util"
+)
+
+// TypedStream is a type-safe view of a Stream whose items are of type T.
+// Its operations take plain Go functions whose signatures are checked at
+// compile time and invoked directly, without reflection.
+//
+// Because Go methods cannot introduce type parameters, operations that
+// change the item type (Map, FlatMap, Process, Reduce, Batch) are package functions:
+//
+//	strm := stream.Of[string](emitters.SliceOf(words))
+//	lengths := stream.Map(strm.Filter(notEmpty), func(s string) int { return len(s) })
+//	lengths.Into(collectors.SliceOf[int]())
+type TypedStream[T any] struct {
+	stream *Stream
+}
+
+// Of creates a new *TypedStream value for items of type T.
+// The source parameter accepts any value supported by New.
+func Of[T any](src interface{}) *TypedStream[T] {
+	return &TypedStream[T]{stream: New(src)}
+}
+
+// Typed returns a type-safe view of the stream with items of type T.
+// It can be used to continue a stream that was composed using the
+// reflection-based operations of Stream.
+func Typed[T any](s *Stream) *TypedStream[T] {
+	return &TypedStream[T]{stream: s}
+}
+
+// Stream returns the underlying *Stream, which can be used to attach
+// operations that are only available on the untyped API.
+func (t *TypedStream[T]) Stream() *Stream {
+	return t.stream
+}
+
+// WithContext sets a context.Context to use.
+func (t *TypedStream[T]) WithContext(ctx context.Context) *TypedStream[T] {
+	t.stream.WithContext(ctx)
+	return t
+}
+
+// Transform applies the unary operation op to the streamed items.
+// The operation is expected to preserve item type T.
+func (t *TypedStream[T]) Transform(op api.UnOperation) *TypedStream[T] {
+	t.stream.Transform(op)
+	return t
+}
+
+// Filter takes a predicate which filters the stream.
+// If f returns true, the current item continues downstream.
+func (t *TypedStream[T]) Filter(f func(T) bool) *TypedStream[T] {
+	t.stream.transform("filter", unary.FilterOf(f))
+	return t
+}
+
+// OnError sets the error policy of the last added operation.
+// See Stream.OnError.
+func (t *TypedStream[T]) OnError(policy api.ErrorPolicy) *TypedStream[T] {
+	t.stream.OnError(policy)
+	return t
+}
+
+// Parallel runs the last added operation with n concurrent
+// workers, keeping the order of the items.  See Stream.Parallel.
+func (t *TypedStream[T]) Parallel(n int) *TypedStream[T] {
+	t.stream.Parallel(n)
+	return t
+}
+
+// ParallelUnordered runs the last added operation with n concurrent
+// workers, in no particular order.  See Stream.ParallelUnordered.
+func (t *TypedStream[T]) ParallelUnordered(n int) *TypedStream[T] {
+	t.stream.ParallelUnordered(n)
+	return t
+}
+
+// Combine sets the function that merges two partial results
+// of the last added Reduce.  See Stream.Combine.
+func (t *TypedStream[T]) Combine(f func(T, T) T) *TypedStream[T] {
+	t.stream.Combine(binary.ReduceOf(f))
+	return t
+}
+
+// EmitEvery emits the partial result of the last added
+// Reduce at each interval.  See Stream.EmitEvery.
+func (t *TypedStream[T]) EmitEvery(interval time.Duration) *TypedStream[T] {
+	t.stream.EmitEvery(interval)
+	return t
+}
+
+// StateTTL sets the time to live of the key states of the
+// last added keyed operation.  See Stream.StateTTL.
+func (t *TypedStream[T]) StateTTL(ttl time.Duration) *TypedStream[T] {
+	t.stream.StateTTL(ttl)
+	return t
+}
+
+// StateStore keeps the state of the last added operation
+// in store.  See Stream.StateStore.
+func (t *TypedStream[T]) StateStore(store api.StateStore, codec api.Codec) *TypedStream[T] {
+	t.stream.StateStore(store, codec)
+	return t
+}
+
+// Named names the last added operation.
+// See Stream.Named.
+func (t *TypedStream[T]) Named(name string) *TypedStream[T] {
+	t.stream.Named(name)
+	return t
+}
+
+// SideOutput returns a stream of the side items sent by the operations
+// named name.  See Stream.SideOutput.
+func (t *TypedStream[T]) SideOutput(name string) *Stream {
+	return t.stream.SideOutput(name)
+}
+
+// Into sets the terminal stream sink to use
+func (t *TypedStream[T]) Into(snk interface{}) *TypedStream[T] {
+	t.stream.Into(snk)
+	return t
+}
+
+// Open opens the underlying stream.
+// See Stream.Open.
+func (t *TypedStream[T]) Open() <-chan error {
+	return t.stream.Open()
+}
+
+// Process applies the function f for general processing of incoming items of
+// type T, producing items of type R.  An error returned by f is reported on the
+// stream as it is for Stream.Process.
+func Process[T, R any](t *TypedStream[T], f func(T) (R, error)) *TypedStream[R] {
+	t.stream.transform("process", unary.ProcessOf(f))
+	return Typed[R](t.stream)
+}
+
+// Map maps, one-to-one, each incoming item of type T to an item of type R.
+func Map[T, R any](t *TypedStream[T], f func(T) R) *TypedStream[R] {
+	t.stream.transform("map", unary.MapOf(f))
+	return Typed[R](t.stream)
+}
+
+// FlatMap maps each incoming item of type T to a slice []R whose
+// items are individually streamed downstream.
+func FlatMap[T, R any](t *TypedStream[T], f func(T) []R) *TypedStream[R] {
+	t.stream.transform("flatMap", unary.FlatMapOf(f))
+	t.stream.ReStream()
+	t.stream.nodes[len(t.stream.nodes)-1].follows = true
+	return Typed[R](t.stream)
+}
+
+// Reduce accumulates items of type T into a single value of type S,
+// starting from seed.  As with Stream.Reduce, the value is emitted once
+// the upstream is done.
+func Reduce[S, T any](t *TypedStream[T], seed S, f func(S, T) S) *TypedStream[S] {
+	operator := binary.New(t.stream.nodeCtx("reduce"))
+	operator.SetOperation(binary.ReduceOf(f))
+	operator.SetInitialState(seed)
+	t.stream.appendOp(operator)
+	return Typed[S](t.stream)
+}
+
+// Scan accumulates items of type T into a value of type S, starting
+// from seed, and emits the value after each item.  See Stream.Scan.
+func Scan[S, T any](t *TypedStream[T], seed S, f func(S, T) S) *TypedStream[S] {
+	operator := binary.New(t.stream.nodeCtx("scan"))
+	operator.SetOperation(binary.ReduceOf(f))
+	operator.SetInitialState(seed)
+	operator.SetEmitCount(1)
+	t.stream.appendOp(operator)
+	return Typed[S](t.stream)
+}
+
+// ScanByKey accumulates items of type T into a value of type S per key,
+// starting from seed, and emits the value of the key of each item as
+// tuple.KV{key, value}.  See Stream.ScanByKey.
+func ScanByKey[K comparable, S, T any](t *TypedStream[T], key func(T) K, seed S, f func(S, T) S) *TypedStream[tuple.KV] {
+	t.stream.transform("scanByKey", unary.ScanByKeyFunc(unary.MapOf(key), seed, binary.ReduceOf(f)))
+	return Typed[tuple.KV](t.stream)
+}
+
+// KeyBy sets the key of the items, of type K, for the keyed
+// operations that are added after it.  See Stream.KeyBy.
+func KeyBy[T any, K comparable](t *TypedStream[T], key func(T) K) *TypedStream[T] {
+	t.stream.keys = unary.MapOf(key)
+	return t
+}
+
+// ProcessByKey applies f to each item of type T along with the state of
+// its key, as set by KeyBy.  See Stream.ProcessByKey.
+func ProcessByKey[T, R any](t *TypedStream[T], f func(api.State, T) (R, error)) *TypedStream[R] {
+	t.stream.processByKey(keyed.ProcessOf(f))
+	return Typed[R](t.stream)
+}
+
+// Batch batches all incoming items into a single []T.
+func Batch[T any](t *TypedStream[T]) *TypedStream[[]T] {
+	return batchOf[T](t.stream, batch.TriggerAll())
+}
+
+// BatchBySize batches incoming items into slices []T of the specified size.
+func BatchBySize[T any](t *TypedStream[T], size int64) *TypedStream[[]T] {
+	return batchOf[T](t.stream, batch.TriggerBySize(size))
+}
+
+// batchOf adds a batch operator followed by an operation that
+// guarantees that batches are delivered as []T.
+func batchOf[T any](s *Stream, trigger api.BatchTrigger) *TypedStream[[]T] {
+	operator := batch.New(s.nodeCtx("batch"))
+	operator.SetTrigger(trigger)
+	s.appendOp(operator)
+	s.transform("castBatch", api.UnFunc(func(ctx context.Context, data interface{}) (interface{}, error) {
+		return util.CastSlice[T](data)
+	}))
+	s.nodes[len(s.nodes)-1].follows = true
+	return Typed[[]T](s)
+}
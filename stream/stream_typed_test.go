@@ -0,0 +1,181 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/api/tuple"
+	"github.com/gofunky/automi/collectors"
+	"github.com/gofunky/automi/emitters"
+	"github.com/gofunky/automi/metrics"
+	"github.com/gofunky/automi/state"
+)
+
+func TestTypedStream_FilterMap(t *testing.T) {
+	snk := collectors.SliceOf[int]()
+	strm := Of[string](emitters.SliceOf([]string{"HELLO", "WORLD", "HOW", "ARE", "YOU"})).
+		Filter(func(s string) bool {
+			return strings.Contains(s, "O")
+		})
+	Map(strm, func(s string) int {
+		return len(s)
+	}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	sum := 0
+	for _, val := range snk.Get() {
+		sum += val
+	}
+	if sum != 16 {
+		t.Fatal("expecting sum 16, got ", sum)
+	}
+}
+
+func TestTypedStream_FlatMapReduce(t *testing.T) {
+	snk := collectors.SliceOf[map[string]int]()
+	words := FlatMap(Of[string]([]string{"a b", "b c a", "a"}), func(s string) []string {
+		return strings.Split(s, " ")
+	})
+	Reduce(words, map[string]int{}, func(counts map[string]int, word string) map[string]int {
+		counts[word]++
+		return counts
+	}).Into(snk)
+
+	select {
+	case err := <-words.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	counts := snk.Get()[0]
+	if counts["a"] != 3 || counts["b"] != 2 || counts["c"] != 1 {
+		t.Fatal("unexpected word counts ", counts)
+	}
+}
+
+func TestTypedStream_FlatMap_OnError(t *testing.T) {
+	snk := collectors.SliceOf[int]()
+	strm := FlatMap(Typed[int](New([]interface{}{1, "two", 3})), func(i int) []int {
+		return []int{i, i}
+	}).OnError(api.DeadLetterOnError(nil)).Named("double").Into(snk)
+	rejected := collectors.Slice()
+	strm.SideOutput("double").Into(rejected)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(snk.Get()) != 4 {
+		t.Fatal("expecting 4 items, got ", snk.Get())
+	}
+	if len(rejected.Get()) != 1 {
+		t.Fatal("expecting the flatMap operator to dead-letter the item, got ", rejected.Get())
+	}
+	if item := rejected.Get()[0].(tuple.Tagged); item[0] != "double" || item[1].(api.ProcError).Item != "two" {
+		t.Fatal("unexpected side item ", item)
+	}
+}
+
+func TestTypedStream_FlatMap_Parallel(t *testing.T) {
+	snk := collectors.SliceOf[string]()
+	strm := FlatMap(Of[string]([]string{"a b", "c", "d e f", "g"}), func(s string) []string {
+		return strings.Fields(s)
+	}).Parallel(3).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	words := snk.Get()
+	sort.Strings(words)
+	if strings.Join(words, "") != "abcdefg" {
+		t.Fatal("unexpected words ", words)
+	}
+}
+
+func TestTypedStream_Batch(t *testing.T) {
+	snk := collectors.SliceOf[[]interface{}]()
+	strm := BatchBySize(Of[interface{}]([]interface{}{1, "two", 3.0}), 2).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(snk.Get()) != 2 || len(snk.Get()[0]) != 2 {
+		t.Fatal("unexpected batches ", snk.Get())
+	}
+}
+
+func TestTypedStream_Batch_Named(t *testing.T) {
+	m := metrics.NewMemory()
+	snk := collectors.SliceOf[[]int]()
+	strm := BatchBySize(Of[int]([]int{1, 2, 3}).WithContext(metrics.WithMetrics(context.Background(), m)), 2).
+		Named("pairs").StateStore(state.NewMemory(), state.JSON([]int{})).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(snk.Get()) != 2 {
+		t.Fatal("unexpected batches ", snk.Get())
+	}
+	if stats := m.Get("pairs"); stats.In != 3 || stats.Out != 2 {
+		t.Fatal("expecting the batch operator to be named, got ", stats)
+	}
+	if stats := m.Get("batch#2"); stats.In != 0 {
+		t.Fatal("expecting the cast of the batches not to be named after the batch operator, got ", stats)
+	}
+}
+
+func TestTypedStream_Process_WithError(t *testing.T) {
+	strm := Of[int](emitters.SliceOf([]int{1, 2, 3}))
+	Process(strm, func(i int) (string, error) {
+		return "", errors.New("test error")
+	})
+
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
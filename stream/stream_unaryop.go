@@ -9,7 +9,12 @@ import (
 // unary operations to streamed elements (i.e. filter, map, etc)
 // It is exposed here for completeness, use the other more specific methods.
 func (s *Stream) Transform(op api.UnOperation) *Stream {
-	operator := unary.New(s.ctx)
+	return s.transform("transform", op)
+}
+
+// transform adds a unary operator named name that applies op
+func (s *Stream) transform(name string, op api.UnOperation) *Stream {
+	operator := unary.New(s.nodeCtx(name))
 	operator.SetOperation(op)
 	s.ops = append(s.ops, operator)
 	return s
@@ -29,7 +34,7 @@ func (s *Stream) Process(f interface{}) *Stream {
 		s.drainErr(err)
 		return s
 	}
-	return s.Transform(op)
+	return s.transform("process", op)
 }
 
 // Filter takes a predicate user-defined func that filters the stream.
@@ -42,7 +47,7 @@ func (s *Stream) Filter(f interface{}) *Stream {
 		s.drainErr(err)
 		return s
 	}
-	return s.Transform(op)
+	return s.transform("filter", op)
 }
 
 // Map uses the user-defined function to take the value of an incoming item and
@@ -55,7 +60,7 @@ func (s *Stream) Map(f interface{}) *Stream {
 		s.drainErr(err)
 		return s
 	}
-	return s.Transform(op)
+	return s.transform("map", op)
 }
 
 // FlatMap similar to Map, however, the user-defined function is expected to return
@@ -70,7 +75,8 @@ func (s *Stream) FlatMap(f interface{}) *Stream {
 		s.drainErr(err)
 		return s
 	}
-	s.Transform(op) // add flatmap as unary op
-	s.ReStream()    // add streamop to unpack flatmap result
+	s.transform("flatMap", op) // add flatmap as unary op
+	s.ReStream()               // add streamop to unpack flatmap result
+	s.nodes[len(s.nodes)-1].follows = true
 	return s
 }
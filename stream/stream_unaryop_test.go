@@ -95,7 +95,7 @@ func TestStream_Map_WithError(t *testing.T) {
 
 	select {
 	case err := <-strm.Open():
-		if err != expectedErr {
+		if !errors.Is(err, expectedErr) {
 			t.Fatal(err)
 		}
 	case <-time.After(50 * time.Millisecond):
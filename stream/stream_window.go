@@ -0,0 +1,92 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofunky/automi/api"
+	"github.com/gofunky/automi/operators/unary"
+	"github.com/gofunky/automi/operators/window"
+	"github.com/gofunky/automi/util"
+)
+
+// WithTimestamps switches the windows and event-time batches that are added
+// after it to event time.  The extractor is either an api.TimestampExtractor
+// or a user-defined function of type:
+//
+//	func(T) time.Time - where T is the type of the incoming item
+//
+// A watermark trails the greatest timestamp seen by maxOutOfOrder, and
+// windows fire once the watermark passes their end.  Items that arrive
+// after their window was fired are sent to the auxiliary channel of the
+// stream context as window.LateItem values.
+func (s *Stream) WithTimestamps(extractor interface{}, maxOutOfOrder time.Duration) *Stream {
+	ts, err := timestampExtractor(extractor)
+	if err != nil {
+		s.drainErr(err)
+		return s
+	}
+	s.timestamps = ts
+	s.lateness = maxOutOfOrder
+	return s
+}
+
+func timestampExtractor(extractor interface{}) (api.TimestampExtractor, error) {
+	if extractor == nil {
+		return nil, errors.New("timestamp extractor cannot be nil")
+	}
+	if ts, ok := extractor.(api.TimestampExtractor); ok {
+		return ts, nil
+	}
+	op, err := unary.ProcessFunc(extractor)
+	if err != nil {
+		return nil, err
+	}
+	return api.TimestampFunc(func(item interface{}) (time.Time, error) {
+		result, err := op(context.Background(), item)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return util.Cast[time.Time](result)
+	}), nil
+}
+
+// Window groups incoming items into time windows as specified by spec.
+// Each window is emitted downstream as an api.Window, carrying the window
+// start and end times along with the batched items as []T.  Windows are
+// emitted once their end time is reached, even if no new item arrives.
+// When timestamps are set using WithTimestamps, windows are assigned and
+// fired by event time instead.
+// Batch operations such as Sum, GroupByKey, or Sort can be applied to
+// the emitted windows and will return their result as an api.Window.
+//
+// See Also
+//
+//	"github.com/gofunky/automi/operators/window"#Spec
+func (s *Stream) Window(spec window.Spec) *Stream {
+	operator := window.New(s.nodeCtx("window"))
+	operator.SetSpec(spec)
+	if s.timestamps != nil {
+		operator.SetEventTime(s.timestamps, s.lateness)
+	}
+	return s.appendOp(operator)
+}
+
+// TumblingWindow groups incoming items into fixed,
+// non-overlapping windows of the given size.
+func (s *Stream) TumblingWindow(size time.Duration) *Stream {
+	return s.Window(window.Tumbling(size))
+}
+
+// SlidingWindow groups incoming items into overlapping windows
+// of the given size that start every slide interval.
+func (s *Stream) SlidingWindow(size, slide time.Duration) *Stream {
+	return s.Window(window.Sliding(size, slide))
+}
+
+// SessionWindow groups incoming items into windows that are closed
+// once no item arrives for the duration of gap.
+func (s *Stream) SessionWindow(gap time.Duration) *Stream {
+	return s.Window(window.Session(gap))
+}
@@ -0,0 +1,150 @@
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofunky/automi/api"
+	autoctx "github.com/gofunky/automi/api/context"
+	"github.com/gofunky/automi/collectors"
+	"github.com/gofunky/automi/operators/window"
+)
+
+func TestStream_TumblingWindow_Sum(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New([]int{1, 2, 3, 4}).TumblingWindow(time.Hour).Sum().Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(snk.Get()) != 1 {
+		t.Fatal("expecting 1 window, got ", snk.Get())
+	}
+	win := snk.Get()[0].(api.Window)
+	if win.Data.(float64) != 10 || win.End.Sub(win.Start) != time.Hour {
+		t.Fatal("unexpected window ", win)
+	}
+}
+
+func TestStream_SessionWindow(t *testing.T) {
+	ch := make(chan string)
+	go func() {
+		ch <- "b"
+		ch <- "a"
+		time.Sleep(30 * time.Millisecond)
+		ch <- "c"
+		close(ch)
+	}()
+
+	snk := collectors.Slice()
+	strm := New(ch).SessionWindow(10 * time.Millisecond).Sort().Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(snk.Get()) != 2 {
+		t.Fatal("expecting 2 sessions, got ", snk.Get())
+	}
+	first := snk.Get()[0].(api.Window).Data.([]string)
+	if first[0] != "a" || first[1] != "b" {
+		t.Fatal("unexpected session ", first)
+	}
+}
+
+type reading struct {
+	Sec int
+	Val int
+}
+
+func TestStream_WithTimestamps_Window(t *testing.T) {
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	aux := make(chan interface{}, 10)
+	snk := collectors.Slice()
+	strm := New([]reading{{1, 1}, {12, 2}, {8, 3}, {16, 4}, {3, 5}}).
+		WithContext(autoctx.WithAuxChan(context.Background(), aux)).
+		WithTimestamps(func(r reading) time.Time {
+			return base.Add(time.Duration(r.Sec) * time.Second)
+		}, 5*time.Second).
+		TumblingWindow(10 * time.Second).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(snk.Get()) != 2 {
+		t.Fatal("expecting 2 windows, got ", snk.Get())
+	}
+	first := snk.Get()[0].(api.Window)
+	if !first.Start.Equal(base) || len(first.Data.([]reading)) != 2 {
+		t.Fatal("unexpected window ", first)
+	}
+	select {
+	case item := <-aux:
+		if item.(window.LateItem).Item.(reading).Val != 5 {
+			t.Fatal("unexpected late item ", item)
+		}
+	default:
+		t.Fatal("expecting a late item")
+	}
+}
+
+func TestStream_BatchByEventTime(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New([]int{1, 4, 12, 9, 15, 31}).
+		WithTimestamps(func(sec int) time.Time {
+			return time.Unix(int64(sec), 0)
+		}, 3*time.Second).
+		BatchByEventTime(10 * time.Second).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(snk.Get()) != 3 {
+		t.Fatal("expecting 3 batches, got ", snk.Get())
+	}
+	// 9 arrives after 12, before the watermark passes 10s, and is batched by its interval
+	if first := snk.Get()[0].([]int); len(first) != 3 || first[2] != 9 {
+		t.Fatal("unexpected batch ", first)
+	}
+	if second := snk.Get()[1].([]int); len(second) != 2 || second[0] != 12 {
+		t.Fatal("unexpected batch ", second)
+	}
+}
+
+func TestStream_BatchByEventTime_NoTimestamps(t *testing.T) {
+	strm := New([]int{1}).BatchByEventTime(time.Second).Into(collectors.Null())
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error for missing timestamps")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
@@ -0,0 +1,96 @@
+package stream
+
+import (
+	"context"
+	"sync"
+)
+
+// valve gates the items emitted by the source of a stream before they
+// reach the operators.  A paused valve stops pulling items from the source.
+type valve struct {
+	ctx    context.Context
+	input  <-chan interface{}
+	output chan interface{}
+
+	mutex  sync.Mutex
+	resume chan struct{} // set while paused
+	wake   chan struct{} // signals a pause to a waiting valve
+}
+
+func newValve(ctx context.Context) *valve {
+	return &valve{
+		ctx:    ctx,
+		output: make(chan interface{}, 1024),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// SetInput sets the input channel of the valve
+func (v *valve) SetInput(in <-chan interface{}) {
+	v.input = in
+}
+
+// GetOutput returns the output channel of the valve
+func (v *valve) GetOutput() <-chan interface{} {
+	return v.output
+}
+
+// Exec forwards items until the input is closed or the context is done
+func (v *valve) Exec(drain chan<- error) {
+	go func() {
+		defer close(v.output)
+		for {
+			if resume := v.paused(); resume != nil {
+				select {
+				case <-resume:
+				case <-v.ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case item, opened := <-v.input:
+				if !opened {
+					return
+				}
+				select {
+				case v.output <- item:
+				case <-v.ctx.Done():
+					return
+				}
+			case <-v.wake:
+			case <-v.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// pause stops pulling items, items already forwarded continue downstream
+func (v *valve) pause() {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if v.resume == nil {
+		v.resume = make(chan struct{})
+		select {
+		case v.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// unpause resumes pulling items
+func (v *valve) unpause() {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if v.resume != nil {
+		close(v.resume)
+		v.resume = nil
+	}
+}
+
+func (v *valve) paused() chan struct{} {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	return v.resume
+}
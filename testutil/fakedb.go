@@ -1,24 +1,201 @@
 package testutil
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
 )
 
-type FakeDB struct{}
+// FakeTable is a table of a FakeDB.  Row values must be driver values,
+// a value that is an error fails the read of its row.
+type FakeTable struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// FakeDB is an in-memory stand-in for a database driver.  It understands
+// two statements: "SELECT * FROM table", which returns the rows of the
+// table, and "INSERT INTO table ...", which appends its arguments to the
+// rows of the table.  Inserts are applied when their transaction commits.
+type FakeDB struct {
+	mutex   sync.Mutex
+	tables  map[string]*FakeTable
+	commits int
+
+	// FailExec, when set, is called with the arguments of each
+	// insert, an error it returns fails the insert.
+	FailExec func(args []driver.Value) error
+}
+
+// NewFakeDB returns a *FakeDB holding tables and
+// a *sql.DB that connects to it.
+func NewFakeDB(tables map[string]*FakeTable) (*FakeDB, *sql.DB) {
+	if tables == nil {
+		tables = make(map[string]*FakeTable)
+	}
+	d := &FakeDB{tables: tables}
+	return d, sql.OpenDB(d)
+}
+
+// Table returns a copy of the named table
+func (d *FakeDB) Table(name string) FakeTable {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	table, ok := d.tables[name]
+	if !ok {
+		return FakeTable{}
+	}
+	return FakeTable{
+		Columns: table.Columns,
+		Rows:    append([][]interface{}(nil), table.Rows...),
+	}
+}
+
+// Commits returns the number of committed transactions
+func (d *FakeDB) Commits() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.commits
+}
 
-func (d *FakeDB) Begin() (*sql.Tx, error) { return nil, nil }
-func (d *FakeDB) Close() error            { return nil }
-func (d *FakeDB) Driver() driver.Driver   { return nil }
-func (d *FakeDB) Exec(string, ...interface{}) (sql.Result, error) {
-	return nil, nil
+// Connect implements driver.Connector
+func (d *FakeDB) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{db: d}, nil
 }
-func (d *FakeDB) Ping() error                       { return nil }
-func (d *FakeDB) Prepare(string) (*sql.Stmt, error) { return nil, nil }
-func (d *FakeDB) Query(string, ...interface{}) (*sql.Rows, error) {
-	return nil, nil
+
+// Driver implements driver.Connector
+func (d *FakeDB) Driver() driver.Driver {
+	return fakeDriver{d}
+}
+
+type fakeDriver struct{ db *FakeDB }
+
+func (d fakeDriver) Open(string) (driver.Conn, error) {
+	return &fakeConn{db: d.db}, nil
+}
+
+type fakeConn struct {
+	db      *FakeDB
+	pending map[string][][]interface{}
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	fields := strings.Fields(query)
+	switch {
+	case len(fields) >= 4 && strings.EqualFold(fields[0], "select") && strings.EqualFold(fields[2], "from"):
+		return &fakeStmt{conn: c, table: fields[3]}, nil
+	case len(fields) >= 3 && strings.EqualFold(fields[0], "insert") && strings.EqualFold(fields[1], "into"):
+		return &fakeStmt{conn: c, table: fields[2], insert: true}, nil
+	}
+	return nil, fmt.Errorf("fakedb: unsupported statement %q", query)
 }
-func (d *FakeDB) QueryRow(string, ...interface{}) *sql.Row {
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	if c.pending != nil {
+		return nil, errors.New("fakedb: transaction in progress")
+	}
+	c.pending = make(map[string][][]interface{})
+	return c, nil
+}
+
+// Commit applies the pending inserts of the transaction
+func (c *fakeConn) Commit() error {
+	c.db.mutex.Lock()
+	defer c.db.mutex.Unlock()
+	for name, rows := range c.pending {
+		table, ok := c.db.tables[name]
+		if !ok {
+			table = new(FakeTable)
+			c.db.tables[name] = table
+		}
+		table.Rows = append(table.Rows, rows...)
+	}
+	c.db.commits++
+	c.pending = nil
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.pending = nil
+	return nil
+}
+
+type fakeStmt struct {
+	conn   *fakeConn
+	table  string
+	insert bool
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if !s.insert {
+		return nil, errors.New("fakedb: select statement cannot be executed")
+	}
+	if s.conn.db.FailExec != nil {
+		if err := s.conn.db.FailExec(args); err != nil {
+			return nil, err
+		}
+	}
+	row := make([]interface{}, len(args))
+	for i, arg := range args {
+		row[i] = arg
+	}
+	if s.conn.pending != nil {
+		s.conn.pending[s.table] = append(s.conn.pending[s.table], row)
+		return driver.RowsAffected(1), nil
+	}
+
+	s.conn.db.mutex.Lock()
+	defer s.conn.db.mutex.Unlock()
+	table, ok := s.conn.db.tables[s.table]
+	if !ok {
+		table = new(FakeTable)
+		s.conn.db.tables[s.table] = table
+	}
+	table.Rows = append(table.Rows, row)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if s.insert {
+		return nil, errors.New("fakedb: insert statement cannot be queried")
+	}
+	table := s.conn.db.Table(s.table)
+	if table.Columns == nil {
+		return nil, fmt.Errorf("fakedb: unknown table %q", s.table)
+	}
+	return &fakeRows{table: table}, nil
+}
+
+type fakeRows struct {
+	table FakeTable
+	pos   int
+}
+
+func (r *fakeRows) Columns() []string { return r.table.Columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.table.Rows) {
+		return io.EOF
+	}
+	row := r.table.Rows[r.pos]
+	r.pos++
+	for i := range dest {
+		value := row[i]
+		if err, ok := value.(error); ok {
+			return err
+		}
+		dest[i] = value
+	}
 	return nil
 }
-func (d *FakeDB) SetMaxIdleConns(int) {}
@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// JSONLines is an Exporter that writes each span as a line of JSON.
+// It is safe for concurrent use.
+type JSONLines struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+	closer  io.Closer
+	err     error
+}
+
+// NewJSONLines creates a new *JSONLines value that writes to w
+func NewJSONLines(w io.Writer) *JSONLines {
+	return &JSONLines{encoder: json.NewEncoder(w)}
+}
+
+// CreateJSONLines creates, or truncates, the named file and
+// returns a *JSONLines value that writes to it.  The file is
+// closed by Close.
+func CreateJSONLines(name string) (*JSONLines, error) {
+	file, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	j := NewJSONLines(file)
+	j.closer = file
+	return j, nil
+}
+
+// Export writes span as a line of JSON.  Spans are no
+// longer written once writing failed, see Err.
+func (j *JSONLines) Export(span SpanData) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if j.err != nil {
+		return
+	}
+	j.err = j.encoder.Encode(span)
+}
+
+// Err returns the first error that occurred while writing spans
+func (j *JSONLines) Err() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.err
+}
+
+// Close closes the file created by CreateJSONLines, if any, and
+// returns the first error that occurred while writing spans.
+func (j *JSONLines) Close() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if j.closer != nil {
+		if err := j.closer.Close(); err != nil && j.err == nil {
+			j.err = err
+		}
+		j.closer = nil
+	}
+	return j.err
+}
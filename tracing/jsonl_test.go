@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := New(NewJSONLines(&buf))
+	root := tracer.Start(nil, "emitter")
+	tracer.Start(root, "map").End(nil)
+	root.End(nil)
+
+	scanner := bufio.NewScanner(&buf)
+	var spans []SpanData
+	for scanner.Scan() {
+		var span SpanData
+		if err := json.Unmarshal(scanner.Bytes(), &span); err != nil {
+			t.Fatal(err)
+		}
+		spans = append(spans, span)
+	}
+	if len(spans) != 2 {
+		t.Fatal("expecting 2 lines, got ", len(spans))
+	}
+	if spans[0].Name != "map" || spans[0].ParentID != spans[1].SpanID {
+		t.Fatal("unexpected spans ", spans)
+	}
+}
+
+func TestCreateJSONLines(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "spans.jsonl")
+	exporter, err := CreateJSONLines(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	New(exporter).Start(nil, "emitter").End(nil)
+	if err := exporter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var span SpanData
+	if err := json.Unmarshal(data, &span); err != nil {
+		t.Fatal(err)
+	}
+	if span.Name != "emitter" {
+		t.Fatal("unexpected span ", span)
+	}
+}
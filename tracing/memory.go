@@ -0,0 +1,42 @@
+package tracing
+
+import "sync"
+
+// Memory is an Exporter that keeps the spans in memory, it is
+// meant for tests.  It is safe for concurrent use.
+type Memory struct {
+	mutex sync.Mutex
+	spans []SpanData
+}
+
+// NewMemory creates a new *Memory value
+func NewMemory() *Memory {
+	return new(Memory)
+}
+
+// Export keeps span in memory
+func (m *Memory) Export(span SpanData) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.spans = append(m.spans, span)
+}
+
+// Spans returns a copy of the exported spans, in the order they ended
+func (m *Memory) Spans() []SpanData {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]SpanData(nil), m.spans...)
+}
+
+// Trace returns the exported spans of the trace identified by traceID
+func (m *Memory) Trace(traceID string) []SpanData {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var spans []SpanData
+	for _, span := range m.spans {
+		if span.TraceID == traceID {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/gofunky/automi/api"
+)
+
+// SpanData describes an ended span
+type SpanData struct {
+	TraceID  string    `json:"trace_id"`
+	SpanID   string    `json:"span_id"`
+	ParentID string    `json:"parent_id,omitempty"`
+	Name     string    `json:"name"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Duration returns the duration of the span
+func (d SpanData) Duration() time.Duration {
+	return d.End.Sub(d.Start)
+}
+
+// Exporter exports the spans once they end.
+// Implementations must be safe for concurrent use.
+type Exporter interface {
+	Export(span SpanData)
+}
+
+// Tracer is an api.Tracer that hands its spans
+// to an Exporter once they end.
+type Tracer struct {
+	exporter Exporter
+}
+
+// New creates a new *Tracer value that exports its spans to exporter
+func New(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+// Start starts a span named name as a child of parent,
+// or as the root span of a new trace if parent is nil.
+// Parents not started by a *Tracer are ignored.
+func (t *Tracer) Start(parent api.Span, name string) api.Span {
+	s := &span{tracer: t}
+	s.data.Name = name
+	s.data.SpanID = newID()
+	if p, ok := parent.(*span); ok {
+		s.data.TraceID = p.data.TraceID
+		s.data.ParentID = p.data.SpanID
+	} else {
+		s.data.TraceID = newID() + newID()
+	}
+	s.data.Start = time.Now()
+	return s
+}
+
+type span struct {
+	tracer *Tracer
+	data   SpanData
+	once   sync.Once
+}
+
+// End ends the span and exports it, only the first call has an effect
+func (s *span) End(err error) {
+	s.once.Do(func() {
+		data := s.data
+		data.End = time.Now()
+		if err != nil {
+			data.Error = err.Error()
+		}
+		s.tracer.exporter.Export(data)
+	})
+}
+
+func newID() string {
+	return fmt.Sprintf("%016x", rand.Uint64())
+}
@@ -0,0 +1,134 @@
+// Package tracing traces items as they flow through a stream.  Tracing
+// is turned on by providing an api.Tracer in the stream context:
+//
+//	spans := tracing.NewMemory()
+//	strm := stream.New(src).WithContext(tracing.WithTracer(ctx, tracing.New(spans)))
+//
+// Emitters start a root span for each item they emit, the operators and
+// collectors that handle the item then start child spans.  The span of an
+// item travels with the item between nodes, and is set in the context passed
+// to the operations applied to the item, see SpanFromContext.
+package tracing
+
+import (
+	"context"
+
+	"github.com/gofunky/automi/api"
+	autoctx "github.com/gofunky/automi/api/context"
+)
+
+type ctxKey int
+
+const (
+	tracerKey ctxKey = 1
+	spanKey   ctxKey = 2
+)
+
+// WithTracer sets the api.Tracer used by the nodes created with ctx
+func WithTracer(ctx context.Context, t api.Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey, t)
+}
+
+// GetTracer returns the api.Tracer of the context, if any
+func GetTracer(ctx context.Context) api.Tracer {
+	t, _ := ctx.Value(tracerKey).(api.Tracer)
+	return t
+}
+
+// ContextWithSpan returns a copy of ctx that carries span
+func ContextWithSpan(ctx context.Context, span api.Span) context.Context {
+	return context.WithValue(ctx, spanKey, span)
+}
+
+// SpanFromContext returns the span carried by ctx, if any
+func SpanFromContext(ctx context.Context) api.Span {
+	span, _ := ctx.Value(spanKey).(api.Span)
+	return span
+}
+
+// traced is an item in flight along with its span
+type traced struct {
+	span api.Span
+	item interface{}
+}
+
+// Wrap attaches span to item so that the next node that handles the
+// item can continue its trace.  The item is returned as is if span is nil.
+func Wrap(span api.Span, item interface{}) interface{} {
+	if span == nil {
+		return item
+	}
+	return traced{span: span, item: item}
+}
+
+// Unwrap returns the item and its span, if item was wrapped by Wrap
+func Unwrap(item interface{}) (api.Span, interface{}) {
+	if t, ok := item.(traced); ok {
+		return t.span, t.item
+	}
+	return nil, item
+}
+
+// Recorder traces the items handled by a single node.  A nil *Recorder,
+// returned when the context has no tracer, records nothing but still
+// unwraps the items it is given.
+type Recorder struct {
+	tracer api.Tracer
+	ctx    context.Context
+	def    string
+}
+
+// NewRecorder returns a *Recorder for the node of ctx, or for a node
+// named def when ctx does not belong to a node.  It returns nil
+// if ctx has no tracer.
+func NewRecorder(ctx context.Context, def string) *Recorder {
+	t := GetTracer(ctx)
+	if t == nil {
+		return nil
+	}
+	return &Recorder{tracer: t, ctx: ctx, def: def}
+}
+
+// Start unwraps item and starts its span for the node.  It returns
+// a copy of ctx that carries the new span along with the unwrapped item.
+func (r *Recorder) Start(ctx context.Context, item interface{}) (context.Context, interface{}) {
+	parent, item := Unwrap(item)
+	if r == nil {
+		return ctx, item
+	}
+	span := r.tracer.Start(parent, autoctx.GetNodeName(r.ctx, r.def))
+	return ContextWithSpan(ctx, span), item
+}
+
+// End ends the span started in ctx by Start
+func (r *Recorder) End(ctx context.Context, err error) {
+	if r == nil {
+		return
+	}
+	if span := SpanFromContext(ctx); span != nil {
+		span.End(err)
+	}
+}
+
+// Wrap attaches the span started in ctx by Start to item,
+// which is typically the result of the node for the item.
+func (r *Recorder) Wrap(ctx context.Context, item interface{}) interface{} {
+	if r == nil {
+		return item
+	}
+	return Wrap(SpanFromContext(ctx), item)
+}
+
+// Emit sends item on output within a new root span, unless ctx is done
+// first.  It is used by emitters, it returns false if ctx is done.
+func (r *Recorder) Emit(ctx context.Context, output chan<- interface{}, item interface{}) bool {
+	itemCtx, item := r.Start(ctx, item)
+	select {
+	case output <- r.Wrap(itemCtx, item):
+		r.End(itemCtx, nil)
+		return true
+	case <-ctx.Done():
+		r.End(itemCtx, ctx.Err())
+		return false
+	}
+}
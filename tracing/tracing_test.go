@@ -0,0 +1,100 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTracer_Start(t *testing.T) {
+	spans := NewMemory()
+	tracer := New(spans)
+
+	root := tracer.Start(nil, "emitter")
+	child := tracer.Start(root, "map")
+	child.End(errors.New("failed"))
+	child.End(nil)
+	root.End(nil)
+
+	exported := spans.Spans()
+	if len(exported) != 2 {
+		t.Fatal("expecting 2 spans, got ", len(exported))
+	}
+	c, r := exported[0], exported[1]
+	if r.Name != "emitter" || r.ParentID != "" || r.TraceID == "" {
+		t.Fatal("unexpected root span ", r)
+	}
+	if c.Name != "map" || c.TraceID != r.TraceID || c.ParentID != r.SpanID {
+		t.Fatal("unexpected child span ", c)
+	}
+	if c.Error != "failed" || c.Duration() < 0 {
+		t.Fatal("unexpected child span ", c)
+	}
+	if len(spans.Trace(r.TraceID)) != 2 {
+		t.Fatal("expecting 2 spans in trace")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	if item := Wrap(nil, 1); item != 1 {
+		t.Fatal("expecting item as is without span, got ", item)
+	}
+	span := New(NewMemory()).Start(nil, "test")
+	s, item := Unwrap(Wrap(span, 1))
+	if s != span || item != 1 {
+		t.Fatal("unexpected unwrapped item ", s, item)
+	}
+	if s, item := Unwrap(2); s != nil || item != 2 {
+		t.Fatal("unexpected unwrapped item ", s, item)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	spans := NewMemory()
+	ctx := WithTracer(context.Background(), New(spans))
+	r := NewRecorder(ctx, "node")
+
+	output := make(chan interface{}, 1)
+	if !r.Emit(context.Background(), output, "a") {
+		t.Fatal("expecting item to be emitted")
+	}
+	itemCtx, item := r.Start(ctx, <-output)
+	if item != "a" || SpanFromContext(itemCtx) == nil {
+		t.Fatal("expecting item to be unwrapped in a span, got ", item)
+	}
+	parent, result := Unwrap(r.Wrap(itemCtx, "A"))
+	if parent != SpanFromContext(itemCtx) || result != "A" {
+		t.Fatal("expecting result in the span of the item")
+	}
+	r.End(itemCtx, nil)
+
+	exported := spans.Spans()
+	if len(exported) != 2 || exported[1].ParentID != exported[0].SpanID {
+		t.Fatal("unexpected spans ", exported)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if r.Emit(cancelled, make(chan interface{}), "b") {
+		t.Fatal("expecting emit to be cancelled")
+	}
+	if spans := spans.Spans(); spans[2].Error != context.Canceled.Error() {
+		t.Fatal("expecting cancelled span, got ", spans[2])
+	}
+}
+
+func TestRecorder_Nil(t *testing.T) {
+	r := NewRecorder(context.Background(), "node")
+	if r != nil {
+		t.Fatal("expecting nil recorder without tracer")
+	}
+	span := New(NewMemory()).Start(nil, "test")
+	ctx, item := r.Start(context.Background(), Wrap(span, 1))
+	if item != 1 || SpanFromContext(ctx) != nil {
+		t.Fatal("expecting item to be unwrapped without span, got ", item)
+	}
+	if item := r.Wrap(ctx, 2); item != 2 {
+		t.Fatal("expecting item as is, got ", item)
+	}
+	r.End(ctx, nil)
+}
@@ -0,0 +1,85 @@
+package util
+
+import (
+	"reflect"
+)
+
+// CopyState returns a deep copy of state, so that it can be sent
+// downstream while the operation keeps updating the original.  Maps,
+// slices, arrays, pointers, and the exported fields of structs are
+// copied.  Other values, such as channels, functions, or the unexported
+// fields of structs, are shared with the original.
+func CopyState(state interface{}) interface{} {
+	if state == nil {
+		return nil
+	}
+	return deepCopy(reflect.ValueOf(state), make(map[copied]reflect.Value)).Interface()
+}
+
+// copied identifies a copied pointer by its type along with its address,
+// which a pointer to a struct shares with a pointer to its first field
+type copied struct {
+	t reflect.Type
+	p uintptr
+}
+
+// deepCopy copies v, the pointers already copied are kept in seen
+// so that cyclic values are copied once
+func deepCopy(v reflect.Value, seen map[copied]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value(), seen))
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i), seen))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i), seen))
+		}
+		return c
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		key := copied{v.Type(), v.Pointer()}
+		if c, ok := seen[key]; ok {
+			return c
+		}
+		c := reflect.New(v.Type().Elem())
+		seen[key] = c
+		c.Elem().Set(deepCopy(v.Elem(), seen))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem(), seen))
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i), seen))
+			}
+		}
+		return c
+	}
+	return v
+}
@@ -0,0 +1,56 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestCopyState(t *testing.T) {
+	type node struct {
+		Values []int
+		Next   *node
+		hidden map[string]int
+	}
+	n := &node{Values: []int{1, 2}, hidden: map[string]int{"a": 1}}
+	n.Next = n
+	state := map[string]interface{}{"node": n, "list": []int{3}, "count": 4}
+
+	copied := CopyState(state).(map[string]interface{})
+	c := copied["node"].(*node)
+	if c == n || c.Next != c {
+		t.Fatal("expecting the cyclic pointer to be copied once")
+	}
+	c.Values[0] = 10
+	copied["list"].([]int)[0] = 30
+	copied["count"] = 40
+	if n.Values[0] != 1 || state["list"].([]int)[0] != 3 || state["count"] != 4 {
+		t.Fatal("expecting the copy to be independent, got ", state)
+	}
+	if c.hidden["a"] != 1 {
+		t.Fatal("expecting unexported fields to be shared")
+	}
+	if CopyState(nil) != nil {
+		t.Fatal("expecting nil")
+	}
+}
+
+func TestCopyState_FieldPointer(t *testing.T) {
+	type counter struct {
+		Count int
+		Name  string
+	}
+	type state struct {
+		Counter *counter
+		Count   *int
+	}
+	c := &counter{Count: 1, Name: "a"}
+	s := state{Counter: c, Count: &c.Count}
+
+	copied := CopyState(s).(state)
+	if copied.Counter == c || copied.Counter.Count != 1 || copied.Counter.Name != "a" || *copied.Count != 1 {
+		t.Fatal("unexpected copy ", copied)
+	}
+	*copied.Count = 2
+	if c.Count != 1 {
+		t.Fatal("expecting the copy to be independent")
+	}
+}
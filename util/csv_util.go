@@ -0,0 +1,131 @@
+package util
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	textUnmarshalType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// ParseCSVValue parses the CSV value s into val, which must be settable.
+// Strings, booleans, numbers, time.Duration, time.Time using layout, and
+// encoding.TextUnmarshaler values are supported, as are pointers to them.
+// An empty value leaves val to its zero value.
+func ParseCSVValue(val reflect.Value, s, layout string) error {
+	if s == "" {
+		val.Set(reflect.Zero(val.Type()))
+		return nil
+	}
+	if val.Kind() == reflect.Ptr {
+		ptr := reflect.New(val.Type().Elem())
+		if err := ParseCSVValue(ptr.Elem(), s, layout); err != nil {
+			return err
+		}
+		val.Set(ptr)
+		return nil
+	}
+
+	switch val.Type() {
+	case timeType:
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return err
+		}
+		val.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		val.SetInt(int64(d))
+		return nil
+	}
+	if val.CanAddr() && val.Addr().Type().Implements(textUnmarshalType) {
+		return val.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch val.Kind() {
+	case reflect.String:
+		val.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		val.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, val.Type().Bits())
+		if err != nil {
+			return err
+		}
+		val.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, val.Type().Bits())
+		if err != nil {
+			return err
+		}
+		val.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, val.Type().Bits())
+		if err != nil {
+			return err
+		}
+		val.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", val.Type())
+	}
+	return nil
+}
+
+// FormatCSVValue returns the CSV value of val.  Numbers, booleans and
+// strings are formatted with package strconv, time.Time values using
+// layout, and encoding.TextMarshaler values with their MarshalText
+// method.  Nil values are empty, other values are formatted by fmt.
+func FormatCSVValue(val reflect.Value, layout string) (string, error) {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return "", nil
+		}
+		val = val.Elem()
+	}
+	if !val.IsValid() {
+		return "", nil
+	}
+
+	switch val.Type() {
+	case timeType:
+		return val.Interface().(time.Time).Format(layout), nil
+	case durationType:
+		return time.Duration(val.Int()).String(), nil
+	}
+	if val.CanInterface() {
+		if m, ok := val.Interface().(encoding.TextMarshaler); ok {
+			text, err := m.MarshalText()
+			return string(text), err
+		}
+	}
+
+	switch val.Kind() {
+	case reflect.String:
+		return val.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(val.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(val.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(val.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(val.Float(), 'f', -1, val.Type().Bits()), nil
+	case reflect.Func, reflect.Chan, reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		return "", fmt.Errorf("unsupported type %s", val.Type())
+	}
+	return fmt.Sprint(val.Interface()), nil
+}
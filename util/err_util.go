@@ -0,0 +1,14 @@
+package util
+
+import "github.com/gofunky/automi/api"
+
+// ProcErr returns err as an api.ProcError carrying the failed item.
+// If err already is an api.ProcError, its other fields are kept.
+func ProcErr(err error, item interface{}) api.ProcError {
+	perr, ok := err.(api.ProcError)
+	if !ok {
+		perr = api.ProcError{Err: err}
+	}
+	perr.Item = item
+	return perr
+}
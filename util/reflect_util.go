@@ -3,6 +3,7 @@ package util
 import (
 	"reflect"
 	"runtime"
+	"strings"
 )
 
 func TraceFunc() string {
@@ -46,6 +47,10 @@ func ValueAsFloat(item reflect.Value) float64 {
 	if IsFloatValue(itemVal) {
 		return itemVal.Float()
 	}
+	switch itemVal.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(itemVal.Uint())
+	}
 	if IsIntValue(itemVal) {
 		return float64(itemVal.Int())
 	}
@@ -53,6 +58,16 @@ func ValueAsFloat(item reflect.Value) float64 {
 }
 
 func IsLess(itemI, itemJ reflect.Value) bool {
+	// compare the values held by interfaces, i.e. items of []interface{}
+	if itemI.Kind() == reflect.Interface {
+		itemI = itemI.Elem()
+	}
+	if itemJ.Kind() == reflect.Interface {
+		itemJ = itemJ.Elem()
+	}
+	if !itemI.IsValid() || !itemJ.IsValid() {
+		return false
+	}
 	switch {
 	case IsIntValue(itemI) && IsIntValue(itemJ):
 		return itemI.Int() < itemJ.Int()
@@ -67,3 +82,144 @@ func IsLess(itemI, itemJ reflect.Value) bool {
 	}
 	return false
 }
+
+// indirect returns the value held by the interface or pointer val
+func indirect(val reflect.Value) reflect.Value {
+	for val.IsValid() && (val.Kind() == reflect.Interface || val.Kind() == reflect.Pointer) {
+		if val.IsNil() {
+			return reflect.Value{}
+		}
+		val = val.Elem()
+	}
+	return val
+}
+
+// FieldByName returns the field name of the struct held by val.  As with the
+// batch functions, the capitalized name selects the exported field if the
+// struct has no field name.  The returned value is invalid if there is no field.
+func FieldByName(val reflect.Value, name string) reflect.Value {
+	val = indirect(val)
+	if !val.IsValid() || val.Kind() != reflect.Struct || name == "" {
+		return reflect.Value{}
+	}
+	field, ok := val.Type().FieldByName(name)
+	if !ok {
+		name = strings.ToUpper(name[:1]) + name[1:]
+		if field, ok = val.Type().FieldByName(name); !ok {
+			return reflect.Value{}
+		}
+	}
+	if !field.IsExported() {
+		return reflect.Value{}
+	}
+	return val.FieldByIndex(field.Index)
+}
+
+// ValueAtPos returns the item at position pos of the slice or array
+// held by val.  The returned value is invalid if there is no such item.
+func ValueAtPos(val reflect.Value, pos int) reflect.Value {
+	val = indirect(val)
+	if !val.IsValid() || (val.Kind() != reflect.Slice && val.Kind() != reflect.Array && val.Kind() != reflect.String) {
+		return reflect.Value{}
+	}
+	if pos < 0 || pos >= val.Len() {
+		return reflect.Value{}
+	}
+	return val.Index(pos)
+}
+
+// ValueAtKey returns the value of key in the map held by val.  Key is
+// converted to the key type of the map if needed.  The returned value
+// is invalid if the map has no such key.
+func ValueAtKey(val reflect.Value, key interface{}) reflect.Value {
+	val = indirect(val)
+	if !val.IsValid() || val.Kind() != reflect.Map {
+		return reflect.Value{}
+	}
+	keyVal := reflect.ValueOf(key)
+	keyType := val.Type().Key()
+	switch {
+	case !keyVal.IsValid():
+		return reflect.Value{}
+	case keyVal.Type().AssignableTo(keyType):
+	case IsNumericValue(keyVal) && IsNumericValue(reflect.Zero(keyType)):
+		// numbers are only converted if no precision is lost
+		converted := keyVal.Convert(keyType)
+		if ValueAsFloat(converted) != ValueAsFloat(keyVal) {
+			return reflect.Value{}
+		}
+		keyVal = converted
+	case keyVal.Kind() == reflect.String && keyType.Kind() == reflect.String:
+		keyVal = keyVal.Convert(keyType)
+	default:
+		return reflect.Value{}
+	}
+	return val.MapIndex(keyVal)
+}
+
+// StructField is a struct field mapped to a column.  Name is the
+// column name, taken from the tag of the field or, without a tag,
+// the field name.  Index is the index path of the field.
+type StructField struct {
+	Name  string
+	Index []int
+}
+
+// StructFields returns the fields of struct type t mapped to columns by
+// their tag, i.e. "csv" for `csv:"name"`.  Exported fields, including
+// those promoted from embedded structs, are mapped unless tagged "-".
+// Fields promoted through embedded pointers are not mapped.
+func StructFields(t reflect.Type, tag string) []StructField {
+	var fields []StructField
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous && field.Type.Kind() == reflect.Struct {
+			continue
+		}
+		if viaPointer(t, field.Index) {
+			continue
+		}
+		name := field.Name
+		if value, ok := field.Tag.Lookup(tag); ok {
+			value, _, _ = strings.Cut(value, ",")
+			if value == "-" {
+				continue
+			}
+			if value != "" {
+				name = value
+			}
+		}
+		fields = append(fields, StructField{Name: name, Index: field.Index})
+	}
+	return fields
+}
+
+// viaPointer reports whether the field at index path of
+// struct type t is promoted through an embedded pointer
+func viaPointer(t reflect.Type, index []int) bool {
+	for _, i := range index[:len(index)-1] {
+		t = t.Field(i).Type
+		if t.Kind() == reflect.Ptr {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchFields returns the fields matching the column names, by name or,
+// failing that, ignoring case.  Columns without a field get a StructField
+// with a nil Index.
+func MatchFields(names []string, fields []StructField) []StructField {
+	columns := make([]StructField, len(names))
+	for i, name := range names {
+		for _, field := range fields {
+			if field.Name == name {
+				columns[i] = field
+				break
+			}
+			if columns[i].Index == nil && strings.EqualFold(field.Name, name) {
+				columns[i] = field
+			}
+		}
+	}
+	return columns
+}
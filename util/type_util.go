@@ -0,0 +1,69 @@
+package util
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Cast asserts that item is of type T. A nil item yields the zero value of T
+// so that nil pointers, maps, etc. can travel through typed operations.
+func Cast[T any](item interface{}) (T, error) {
+	var zero T
+	if item == nil {
+		return zero, nil
+	}
+	val, ok := item.(T)
+	if !ok {
+		return zero, fmt.Errorf("unexpected item type %T, expecting %s", item, reflect.TypeOf(&zero).Elem())
+	}
+	return val, nil
+}
+
+// CastSlice converts item, a slice or array of any element type, into []T.
+// Each element is asserted to be of type T.
+func CastSlice[T any](item interface{}) ([]T, error) {
+	if val, ok := item.([]T); ok {
+		return val, nil
+	}
+	itemVal := reflect.ValueOf(item)
+	if !itemVal.IsValid() {
+		return nil, nil
+	}
+	switch itemVal.Kind() {
+	case reflect.Slice, reflect.Array:
+	default:
+		return nil, fmt.Errorf("unexpected item type %T, expecting a slice", item)
+	}
+	result := make([]T, itemVal.Len())
+	for i := 0; i < itemVal.Len(); i++ {
+		val, err := Cast[T](itemVal.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		result[i] = val
+	}
+	return result, nil
+}
+
+// ConvertTo returns item as a value of type t.  Items assignable to t are
+// returned as is, numbers are converted to numeric types, and values of
+// other types are converted if their underlying types match.  A nil item
+// yields the zero value of t.
+func ConvertTo(item interface{}, t reflect.Type) (reflect.Value, error) {
+	val := reflect.ValueOf(item)
+	switch {
+	case !val.IsValid():
+		return reflect.Zero(t), nil
+	case val.Type().AssignableTo(t):
+		return val, nil
+	case IsNumericValue(val) && isNumericKind(t.Kind()):
+		return val.Convert(t), nil
+	case val.Kind() == t.Kind() && val.Type().ConvertibleTo(t):
+		return val.Convert(t), nil
+	}
+	return reflect.Value{}, fmt.Errorf("unexpected item type %T, expecting %s", item, t)
+}
+
+func isNumericKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64 && kind != reflect.Uintptr
+}